@@ -1,145 +1,322 @@
 package main
 
 import (
-	"database/sql"
-	"fmt"
+	"context"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/cep"
 	"socialBuddy/internal/comment"
+	"socialBuddy/internal/config"
+	"socialBuddy/internal/deletion"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/lifecycle"
+	"socialBuddy/internal/mergepatch"
+	"socialBuddy/internal/metrics"
+	"socialBuddy/internal/migration"
 	"socialBuddy/internal/post"
+	"socialBuddy/internal/reaction"
+	"socialBuddy/internal/search"
 	"socialBuddy/internal/user"
+	"syscall"
+	"time"
 )
 
 func main() {
-	file := "../internal/database/socialbuddy.db"
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	err = setupLogger(cfg.Log)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
 
-	db, err := startSqlite("Users", "Posts", "Comment", file)
+	if len(args) > 0 && args[0] == "migrate" {
+		err := runMigrate(cfg.Database, args[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	err = cfg.ValidateServe()
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
+	db, err := startDatabase(cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	meters := metrics.New()
+	db.SetObserver(meters.ObserveQuery)
+
+	policy := auth.NewPolicy()
+	deletions := deletion.Policy{Cascade: cfg.Deletion.Cascade, GracePeriod: cfg.Deletion.GracePeriod}
 
 	repUser := user.NewRepository(db)
-	cli := http.DefaultClient
-	fac := user.NewFacade("https://viacep.com.br", cli)
-	servUser := user.NewService(repUser, fac)
+	cli := &http.Client{Timeout: cfg.CEP.Timeout}
+	var cepStore cep.Store
+	if cfg.CEP.CachePersist {
+		cepStore = cep.NewRepository(db)
+	}
+	providers, err := cepProviders(cfg.CEP, cli, meters)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	// the chain bounds every provider with the timeout, so a hung provider still leaves time for the next one
+	chain := cep.NewChain(cfg.CEP.Timeout, meters.ObserveCEPAnswer, providers...)
+	fac := cep.NewFacade(chain, cep.Options{
+		Retries:         cfg.CEP.Retries,
+		Backoff:         cfg.CEP.Backoff,
+		CacheSize:       cfg.CEP.CacheSize,
+		CacheTTL:        cfg.CEP.CacheTTL,
+		BreakerFailures: cfg.CEP.BreakerFailures,
+		BreakerCooldown: cfg.CEP.BreakerCooldown,
+		Store:           cepStore,
+	})
+	servUser := user.NewService(repUser, fac, policy, db, deletions, user.SuggestionOptions{
+		Limit:     cfg.Suggestions.Limit,
+		CacheSize: cfg.Suggestions.CacheSize,
+		CacheTTL:  cfg.Suggestions.CacheTTL,
+	})
 	serUser := user.NewServer(servUser)
 
+	repReaction := reaction.NewRepository(db)
+	var counter post.ReactionCounter
+	if cfg.Features.Reactions {
+		counter = repReaction
+	}
+
 	repPost := post.NewRepository(db)
-	servPost := post.NewService(repPost, servUser)
+	servPost := post.NewService(repPost, servUser, policy, counter, db, deletions)
 	serPost := post.NewServer(servPost)
 
 	repCom := comment.NewRepository(db)
-	servCom := comment.NewService(repCom, servPost, servUser)
+	servCom := comment.NewService(repCom, servPost, servUser, policy, counter, db, deletions)
 	serCom := comment.NewServer(servCom)
 
+	servReaction := reaction.NewService(repReaction, servPost, servCom, servUser, db)
+	serReaction := reaction.NewServer(servReaction)
+
+	signer := auth.NewSigner([]byte(cfg.Auth.Secret), cfg.Auth.AccessTTL)
+	repAuth := auth.NewRepository(db)
+	servAuth := auth.NewService(repAuth, servUser, signer, cfg.Auth.RefreshTTL)
+	serAuth := auth.NewServer(servAuth)
+
+	searchAvailable, err := search.Available(context.Background(), db)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	serSearch := search.NewServer(search.NewService(search.NewRepository(db)))
+
+	readiness := &lifecycle.Readiness{}
+	readiness.AddCheck("database", db.PingContext)
+	if cfg.CEP.CheckReadiness {
+		// the chain and not fac, a cached address or an open breaker would hide whether the providers answer
+		readiness.AddCheck("cep", user.CepCheck(chain))
+	}
+
 	router := chi.NewRouter()
 	router.Use(middleware.Logger)
+	router.Use(meters.Middleware)
+	router.Use(requestTimeout(cfg.Server.RequestTimeout))
+
+	router.Get("/healthz", lifecycle.Healthz)
+	router.Get("/readyz", readiness.Handler)
+	router.Method(http.MethodGet, "/metrics", meters.Handler())
+
+	router.Post("/v1/auth/login", serAuth.Login)
+	router.Post("/v1/auth/refresh", serAuth.Refresh)
+	router.Post("/v1/auth/logout", serAuth.Logout)
 
 	router.Get("/v1/user", serUser.GetUsers)
 	router.Get("/v1/user/{id}", serUser.GetUserByID)
 	router.Get("/v1/user/email/{email}", serUser.GetUserByEmail)
 	router.Post("/v1/user", serUser.CreateUser)
-	router.Put("/v1/user/{id}", serUser.UpdateUser)
-	router.Delete("/v1/user/{id}", serUser.DeleteUser)
-
-	router.Put("/v1/user/{id}/following/{following_id}", serUser.FollowUser)
-	router.Delete("/v1/user/{id}/following/{following_id}", serUser.DeleteConnection)
-	router.Get("/v1/user/{id}/following", serUser.GetFollow)
+	router.Get("/v1/user/{id}/following", serUser.GetFollowingByUserID)
+	router.Get("/v1/user/{id}/following/{following_id}", serUser.IsFollowing)
+	router.Get("/v1/user/{id}/followers", serUser.GetUserFollowers)
+	router.Get("/v1/user/{id}/mutuals", serUser.GetMutuals)
 
 	router.Get("/v1/post", serPost.GetPosts)
 	router.Get("/v1/post/{id}", serPost.GetPostByID)
 	router.Get("/v1/post/id/{id_user}", serPost.GetPostByUserID)
 	router.Get("/v1/post/title/{title}", serPost.GetPostByTitle)
 	router.Get("/v1/post/date/{date}", serPost.GetPostByDate)
-	router.Post("/v1/post", serPost.CreatePost)
-	router.Put("/v1/post/{id}", serPost.EditPost)
-	router.Delete("/v1/post/{id}", serPost.DeletePost)
 
 	router.Get("/v1/comment", serCom.GetCom)
 	router.Get("/v1/post/{id_post}/comment", serCom.GetComByPostID)
 	router.Get("/v1/user/{id_user}/comment", serCom.GetComByUserID)
 	router.Get("/v1/post/{id_post}/comment/{id}", serCom.GetComByID)
 	router.Get("/v1/post/{id_post}/date/{date}/comment", serCom.GetComByDate)
-	router.Post("/v1/post/{id_post}/comment", serCom.CreateCom)
-	router.Put("/v1/post/{id_post}/comment/{id}", serCom.EditCom)
-	router.Delete("/v1/post/{id_post}/comment/{id}", serCom.DeleteCom)
+	if cfg.Features.Threads {
+		router.Get("/v1/post/{id_post}/thread", serCom.GetThreads)
+	}
 
-	log.Println("server's running on the port: 8081")
-	err = http.ListenAndServe(":8081", router)
+	if cfg.Features.Reactions {
+		router.Get("/v1/post/{id}/reaction", serReaction.GetPostReactions)
+		router.Get("/v1/post/{id_post}/comment/{id}/reaction", serReaction.GetComReactions)
+	}
+
+	if cfg.Features.Search && searchAvailable {
+		router.Get("/v1/search", serSearch.Search)
+	} else if cfg.Features.Search {
+		slog.Warn("full-text search is disabled, build with -tags sqlite_fts5 on SQLite to enable /v1/search")
+	}
+
+	router.Group(func(protected chi.Router) {
+		protected.Use(auth.Middleware(signer))
+
+		// A deleted account can still restore itself, every other route needs an active one.
+		protected.Post("/v1/user/{id}/restore", serUser.RestoreUser)
+		protected = protected.With(auth.RequireActive(servUser))
+
+		protected.Put("/v1/user/{id}", serUser.UpdateUser)
+		protected.With(mergepatch.Require).Patch("/v1/user/{id}", serUser.PatchUser)
+		protected.Delete("/v1/user/{id}", serUser.DeleteUser)
+		protected.Put("/v1/user/{id}/following/{following_id}", serUser.FollowUser)
+		protected.Delete("/v1/user/{id}/following/{following_id}", serUser.DeleteConnection)
+		protected.Get("/v1/user/{id}/suggestions", serUser.GetSuggestions)
+		protected.Get("/v1/user/{id}/feed", serPost.GetFeed)
+
+		protected.Post("/v1/post", serPost.CreatePost)
+		protected.Put("/v1/post/{id}", serPost.EditPost)
+		protected.With(mergepatch.Require).Patch("/v1/post/{id}", serPost.PatchPost)
+		protected.Delete("/v1/post/{id}", serPost.DeletePost)
+		protected.Post("/v1/post/{id}/restore", serPost.RestorePost)
+
+		protected.Post("/v1/post/{id_post}/comment", serCom.CreateCom)
+		protected.Put("/v1/post/{id_post}/comment/{id}", serCom.EditCom)
+		protected.With(mergepatch.Require).Patch("/v1/post/{id_post}/comment/{id}", serCom.PatchCom)
+		protected.Delete("/v1/post/{id_post}/comment/{id}", serCom.DeleteCom)
+		protected.Post("/v1/post/{id_post}/comment/{id}/restore", serCom.RestoreCom)
+
+		if cfg.Features.Reactions {
+			protected.Put("/v1/post/{id}/reaction", serReaction.ReactPost)
+			protected.Delete("/v1/post/{id}/reaction", serReaction.DeletePostReaction)
+			protected.Put("/v1/post/{id_post}/comment/{id}/reaction", serReaction.ReactCom)
+			protected.Delete("/v1/post/{id_post}/comment/{id}/reaction", serReaction.DeleteComReaction)
+		}
+	})
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// the comments go first so the replies of a purged post or user are not left to the foreign keys alone,
+	// and under restrict a post or user whose comments were just purged can go in the same run
+	purge := func(ctx context.Context) {
+		deletion.Run(ctx, cfg.Deletion.PurgeInterval, cfg.Deletion.GracePeriod,
+			deletion.Target{Name: "comment", Purge: deletions.Purge(repCom.PurgeComs)},
+			deletion.Target{Name: "post", Purge: deletions.Purge(repPost.PurgePosts)},
+			deletion.Target{Name: "user", Purge: deletions.Purge(repUser.PurgeUsers)},
+			deletion.Target{Name: "refresh token", Purge: repAuth.PurgeRefreshTokens},
+		)
+	}
+	listener, err := net.Listen("tcp", cfg.Server.Addr)
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
-}
-func startSqlite(tableName1 string, tableName2 string, tableName3 string, file string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", file)
+	srv := &http.Server{
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	slog.Info("server's running", "addr", listener.Addr().String())
+	err = lifecycle.Run(ctx, srv, listener, readiness, lifecycle.Options{
+		DrainDelay:      cfg.Server.DrainDelay,
+		ShutdownTimeout: cfg.Server.ShutdownTimeout,
+		Closers:         []io.Closer{db},
+		Workers:         []func(ctx context.Context){purge},
+	})
 	if err != nil {
 		log.Fatal(err)
-		return nil, err
+		return
+	}
+	slog.Info("server stopped")
+}
+
+// requestTimeout puts a deadline on the context of every request, the queries and the CEP lookups
+// started by the handlers give up once it passes.
+func requestTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func setupLogger(cfg config.Log) error {
+	level, err := cfg.SlogLevel()
+	if err != nil {
+		return err
 	}
-	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
-    												ID INTEGER PRIMARY KEY AUTOINCREMENT,
-    												Name 		 TEXT,
-    												Age 		 TEXT,
-    												DocumentNumber TEXT,
-    												Email 		 TEXT,
-    												Phone 		 TEXT,
-    												ZipCode 	 TEXT,
-    												Country 	 TEXT, 
-    												State        TEXT,
-    												City 		 TEXT, 
-    												Neighborhood TEXT
-													Street       TEXT
-													Number       TEXT
-                              						Complement   TEXT
-    												)    `, tableName1))
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+	return nil
+}
+
+func startDatabase(cfg config.Database) (*dialect.DB, error) {
+	db, err := dialect.Open(cfg.Dialect, cfg.DSN)
 	if err != nil {
-		log.Println(err)
-		err := db.Close()
-		if err != nil {
-			return nil, err
-		}
 		return nil, err
 	}
-	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
-    												ID INTEGER PRIMARY KEY AUTOINCREMENT,
-    												IDUser INTEGER,
-    												DatePost DATE,
-    												Title TEXT,
-    												Content TEXT,
-    												FOREIGN KEY (IDUser) REFERENCES Users(ID)
-    												)    `, tableName2))
+	migrations, err := migration.For(db.Dialect())
 	if err != nil {
-		log.Println(err)
-		err := db.Close()
-		if err != nil {
-			return nil, err
-		}
-
+		_ = db.Close()
 		return nil, err
 	}
-	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
-  													ID INTEGER PRIMARY KEY AUTOINCREMENT,
-   													IDPost INTEGER,
-    												IDUser INTEGER,
-   													DateComment DATE,
-    												Content TEXT,
-    												FOREIGN KEY (IDPost) REFERENCES Posts(ID)
-   													FOREIGN KEY (IDUser) REFERENCES Users(ID)
-													)`, tableName3))
+	applied, err := migration.NewMigrator(db, migrations).Up()
 	if err != nil {
-		log.Println(err)
-		err := db.Close()
-		if err != nil {
-			return nil, err
-		}
-
+		_ = db.Close()
 		return nil, err
 	}
+	if applied > 0 {
+		slog.Info("applied migrations", "count", applied)
+	}
 	return db, nil
 }
+
+func cepProviders(cfg config.CEP, cli *http.Client, meters *metrics.Metrics) ([]cep.Provider, error) {
+	var providers []cep.Provider
+	for _, name := range cfg.Providers {
+		var facade user.Facade
+		switch name {
+		case cep.ProviderViaCEP:
+			facade = user.NewFacade(cfg.URL, cli)
+		case cep.ProviderBrasilAPI:
+			facade = cep.NewBrasilAPI(cfg.BrasilAPIURL, cli)
+		case cep.ProviderOpenCEP:
+			facade = cep.NewOpenCEP(cfg.OpenCEPURL, cli)
+		case cep.ProviderOffline:
+			offline, err := cep.NewOffline(cfg.OfflineCSV)
+			if err != nil {
+				return nil, err
+			}
+			facade = offline
+		}
+		name := name
+		observe := func(success bool) {
+			meters.ObserveCEP(name, success)
+		}
+		providers = append(providers, cep.Provider{Name: name, Facade: user.NewObservedFacade(facade, observe)})
+	}
+	return providers, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"socialBuddy/internal/config"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/migration"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+func runMigrate(cfg config.Database, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	db, err := dialect.Open(cfg.Dialect, cfg.DSN)
+	if err != nil {
+		return err
+	}
+	defer func(db *dialect.DB) {
+		_ = db.Close()
+	}(db)
+
+	migrations, err := migration.For(db.Dialect())
+	if err != nil {
+		return err
+	}
+	migrator := migration.NewMigrator(db, migrations)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid number of steps: %w", err)
+			}
+		}
+		// The first migration adopts the tables of the databases created before the migrations, reverting
+		// it drops every user, post and comment. The command never goes below version 1.
+		revertible, err := revertibleSteps(migrator)
+		if err != nil {
+			return err
+		}
+		if steps > revertible {
+			return fmt.Errorf("refusing to revert %d migration(s): only %d can be reverted without dropping the base tables and their data", steps, revertible)
+		}
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migration(s)\n", reverted)
+	case "status":
+		listStatus, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range listStatus {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d %-30s %s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
+
+// revertibleSteps counts the applied migrations above version 1.
+func revertibleSteps(migrator migration.Migrator) (int, error) {
+	listStatus, err := migrator.Status()
+	if err != nil {
+		return 0, err
+	}
+	revertible := 0
+	for _, status := range listStatus {
+		if status.Applied && status.Version > 1 {
+			revertible++
+		}
+	}
+	return revertible, nil
+}
@@ -0,0 +1,63 @@
+package apperror
+
+import (
+	"errors"
+)
+
+var (
+	ErrValidation    = errors.New("validation failed")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrForbidden     = errors.New("forbidden")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrUnavailable   = errors.New("unavailable")
+	ErrGone          = errors.New("gone")
+	ErrUnprocessable = errors.New("unprocessable")
+	ErrUnsupported   = errors.New("unsupported media type")
+)
+
+// Error is a domain error, its Kind is one of the sentinels above and decides the HTTP status.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func Validation(message string) error {
+	return &Error{Kind: ErrValidation, Message: message}
+}
+
+func NotFound(message string) error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+func Conflict(message string) error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+func Forbidden(message string) error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+func Unauthorized(message string) error {
+	return &Error{Kind: ErrUnauthorized, Message: message}
+}
+
+func Unavailable(message string) error {
+	return &Error{Kind: ErrUnavailable, Message: message}
+}
+
+func Gone(message string) error {
+	return &Error{Kind: ErrGone, Message: message}
+}
+
+func Unsupported(message string) error {
+	return &Error{Kind: ErrUnsupported, Message: message}
+}
@@ -0,0 +1,72 @@
+package apperror
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	CodeRequired   = "required"
+	CodeInvalid    = "invalid"
+	CodeOutOfRange = "out_of_range"
+)
+
+// FieldError is one failed check of a request body, Field is the JSON path of the checked value.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// InvalidFields reports every failed check of a request at once, it is still a validation error
+// but it is written as a 422 with the list of fields.
+type InvalidFields struct {
+	Fields []FieldError
+}
+
+func (e *InvalidFields) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *InvalidFields) Unwrap() []error {
+	return []error{ErrUnprocessable, ErrValidation}
+}
+
+func (e *InvalidFields) Extensions() map[string]any {
+	return map[string]any{"errors": e.Fields}
+}
+
+// Fields collects the result of several checks, the zero value is ready to use.
+type Fields struct {
+	fields []FieldError
+	err    error
+}
+
+// Check records a failed validation under field, an error that is not a validation is kept and
+// returned by Err instead of the fields.
+func (f *Fields) Check(field string, code string, err error) {
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, ErrValidation) {
+		if f.err == nil {
+			f.err = err
+		}
+		return
+	}
+	f.fields = append(f.fields, FieldError{Field: field, Code: code, Message: err.Error()})
+}
+
+func (f *Fields) Err() error {
+	if f.err != nil {
+		return f.err
+	}
+	if len(f.fields) == 0 {
+		return nil
+	}
+	return &InvalidFields{Fields: f.fields}
+}
@@ -0,0 +1,105 @@
+package apperror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+const ContentType = "application/problem+json"
+
+// StatusClientClosedRequest is written when the client went away before the answer, nobody reads it
+// but it keeps the disconnects apart from the failures in the logs and metrics.
+const StatusClientClosedRequest = 499
+
+// Problem is the RFC 7807 body written for every failed request.
+type Problem struct {
+	Type       string         `json:"type"`
+	Title      string         `json:"title"`
+	Status     int            `json:"status"`
+	Detail     string         `json:"detail,omitempty"`
+	Extensions map[string]any `json:"-"`
+}
+
+// Extender is implemented by errors that add members to the problem body.
+type Extender interface {
+	Extensions() map[string]any
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	body := make(map[string]any, len(p.Extensions)+4)
+	for key, value := range p.Extensions {
+		body[key] = value
+	}
+	body["type"] = p.Type
+	body["title"] = p.Title
+	body["status"] = p.Status
+	if p.Detail != "" {
+		body["detail"] = p.Detail
+	}
+	return json.Marshal(body)
+}
+
+func Status(err error) int {
+	switch {
+	case errors.Is(err, ErrUnprocessable):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrGone):
+		return http.StatusGone
+	case errors.Is(err, ErrUnsupported):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func NewProblem(err error) Problem {
+	status := Status(err)
+	title := http.StatusText(status)
+	if status == StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  title,
+		Status: status,
+		Detail: err.Error(),
+	}
+	if status == http.StatusInternalServerError {
+		problem.Detail = ""
+	}
+	var extender Extender
+	if errors.As(err, &extender) {
+		problem.Extensions = extender.Extensions()
+	}
+	return problem
+}
+
+// Write renders err as a problem+json response, unknown errors are logged and hidden behind a 500.
+func Write(w http.ResponseWriter, err error) {
+	problem := NewProblem(err)
+	if problem.Status == http.StatusInternalServerError {
+		slog.Error("internal error", "error", err)
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
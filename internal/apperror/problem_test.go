@@ -0,0 +1,136 @@
+package apperror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type argWrite struct {
+	name   string
+	err    error
+	status int
+	detail string
+}
+
+type extendedError struct{}
+
+func (e *extendedError) Error() string {
+	return "the user is not allowed to edit the post 1"
+}
+
+func (e *extendedError) Unwrap() error {
+	return ErrForbidden
+}
+
+func (e *extendedError) Extensions() map[string]any {
+	return map[string]any{"resource": "post"}
+}
+
+func TestWrite(t *testing.T) {
+	test := []argWrite{
+		{name: "Write() a validation error", err: Validation("name is not valid"), status: http.StatusBadRequest, detail: "name is not valid"},
+		{name: "Write() a not found error", err: NotFound("the post is not in database"), status: http.StatusNotFound, detail: "the post is not in database"},
+		{name: "Write() a wrapped conflict error", err: fmt.Errorf("follow: %w", Conflict("already following")), status: http.StatusConflict, detail: "follow: already following"},
+		{name: "Write() an unavailable error", err: Unavailable("the zip code lookup is unavailable"), status: http.StatusServiceUnavailable, detail: "the zip code lookup is unavailable"},
+		{name: "Write() a gone error", err: Gone("the user can no longer be restored"), status: http.StatusGone, detail: "the user can no longer be restored"},
+		{name: "Write() an unsupported media type", err: Unsupported("the patch must be application/merge-patch+json"), status: http.StatusUnsupportedMediaType, detail: "the patch must be application/merge-patch+json"},
+		{name: "Write() an unauthorized error", err: Unauthorized("the token is not valid"), status: http.StatusUnauthorized, detail: "the token is not valid"},
+		{name: "Write() a request past its deadline", err: fmt.Errorf("get posts: %w", context.DeadlineExceeded), status: http.StatusGatewayTimeout, detail: "get posts: context deadline exceeded"},
+		{name: "Write() a request canceled by the client", err: context.Canceled, status: StatusClientClosedRequest, detail: "context canceled"},
+		{name: "Write() an unknown error", err: errors.New("database is locked"), status: http.StatusInternalServerError, detail: ""},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Write(rec, tt.err)
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if rec.Header().Get("Content-Type") != ContentType {
+				t.Fatalf("expected content type %s, got %s", ContentType, rec.Header().Get("Content-Type"))
+			}
+			var problem map[string]any
+			err := json.NewDecoder(rec.Body).Decode(&problem)
+			if err != nil {
+				t.Fatalf("the decode of body is failed %v", err)
+			}
+			title := http.StatusText(tt.status)
+			if tt.status == StatusClientClosedRequest {
+				title = "Client Closed Request"
+			}
+			if problem["status"] != float64(tt.status) || problem["title"] != title {
+				t.Fatalf("unexpected problem %+v", problem)
+			}
+			detail, _ := problem["detail"].(string)
+			if detail != tt.detail {
+				t.Fatalf("expected detail %q, got %q", tt.detail, detail)
+			}
+		})
+	}
+}
+
+func TestWriteExtensions(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Write(rec, &extendedError{})
+	var problem map[string]any
+	err := json.NewDecoder(rec.Body).Decode(&problem)
+	if err != nil {
+		t.Fatalf("the decode of body is failed %v", err)
+	}
+	if rec.Code != http.StatusForbidden || problem["resource"] != "post" {
+		t.Fatalf("unexpected problem %d %+v", rec.Code, problem)
+	}
+}
+
+func TestWriteInvalidFields(t *testing.T) {
+	var fields Fields
+	fields.Check("name", CodeInvalid, Validation("name is not valid"))
+	fields.Check("age", CodeOutOfRange, nil)
+	fields.Check("address.zip_code", CodeInvalid, Validation("the zip code is not valid"))
+	err := fields.Err()
+	if !errors.Is(err, ErrValidation) || !errors.Is(err, ErrUnprocessable) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	rec := httptest.NewRecorder()
+	Write(rec, err)
+	var problem struct {
+		Status int          `json:"status"`
+		Detail string       `json:"detail"`
+		Errors []FieldError `json:"errors"`
+	}
+	err = json.NewDecoder(rec.Body).Decode(&problem)
+	if err != nil {
+		t.Fatalf("the decode of body is failed %v", err)
+	}
+	if rec.Code != http.StatusUnprocessableEntity || problem.Status != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d", rec.Code)
+	}
+	expected := []FieldError{
+		{Field: "name", Code: CodeInvalid, Message: "name is not valid"},
+		{Field: "address.zip_code", Code: CodeInvalid, Message: "the zip code is not valid"},
+	}
+	if len(problem.Errors) != len(expected) || problem.Errors[0] != expected[0] || problem.Errors[1] != expected[1] {
+		t.Fatalf("unexpected errors %+v", problem.Errors)
+	}
+	if problem.Detail != "name is not valid; the zip code is not valid" {
+		t.Fatalf("unexpected detail %q", problem.Detail)
+	}
+}
+
+func TestFieldsErr(t *testing.T) {
+	var fields Fields
+	if fields.Err() != nil {
+		t.Fatalf("expected no error without failed checks")
+	}
+	failure := errors.New("regexp failed")
+	fields.Check("name", CodeInvalid, Validation("name is not valid"))
+	fields.Check("email", CodeInvalid, failure)
+	if fields.Err() != failure {
+		t.Fatalf("expected the unexpected error to be returned, got %v", fields.Err())
+	}
+}
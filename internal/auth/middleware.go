@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"net/http"
+	"socialBuddy/internal/apperror"
+	"strings"
+)
+
+// Middleware rejects requests without a valid bearer token and stores the caller's Identity in the request context.
+func Middleware(tokenSigner Signer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			token, found := strings.CutPrefix(header, "Bearer ")
+			if !found || token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				apperror.Write(w, apperror.Unauthorized("the authorization token is missing"))
+				return
+			}
+			identity, err := tokenSigner.Parse(token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				apperror.Write(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), *identity)))
+		})
+	}
+}
+
+// RequireActive rejects the callers whose account was deleted after their token was issued. The restore of
+// an account is the one route left out of it, RestoreUser checks the caller on its own.
+func RequireActive(identities IdentityProvider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := FromContext(r.Context())
+			if !ok {
+				apperror.Write(w, ErrInvalidToken)
+				return
+			}
+			active, err := identities.GetIdentity(r.Context(), identity.ID)
+			if err != nil {
+				apperror.Write(w, err)
+				return
+			}
+			if active == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				apperror.Write(w, ErrDeletedAccount)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type argMiddleware struct {
+	name          string
+	authorization string
+	status        int
+}
+
+func TestMiddleware(t *testing.T) {
+	tokenSigner := NewSigner([]byte("secret"), time.Minute)
+	token, _, err := tokenSigner.Sign(Identity{ID: 7})
+	if err != nil {
+		t.Fatalf("the creation of token is failed %v", err)
+	}
+	otherSigner := NewSigner([]byte("other"), time.Minute)
+	forged, _, err := otherSigner.Sign(Identity{ID: 7})
+	if err != nil {
+		t.Fatalf("the creation of token is failed %v", err)
+	}
+	expiredSigner := NewSigner([]byte("secret"), -time.Minute)
+	expired, _, err := expiredSigner.Sign(Identity{ID: 7})
+	if err != nil {
+		t.Fatalf("the creation of token is failed %v", err)
+	}
+
+	handler := Middleware(tokenSigner)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := FromContext(r.Context())
+		if !ok || identity.ID != 7 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	test := []argMiddleware{
+		{name: "Middleware() with a valid token", authorization: "Bearer " + token, status: http.StatusOK},
+		{name: "Middleware() without token", authorization: "", status: http.StatusUnauthorized},
+		{name: "Middleware() with another scheme", authorization: "Basic " + token, status: http.StatusUnauthorized},
+		{name: "Middleware() with a forged token", authorization: "Bearer " + forged, status: http.StatusUnauthorized},
+		{name: "Middleware() with an expired token", authorization: "Bearer " + expired, status: http.StatusUnauthorized},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/post", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+type stubIdentityProvider struct {
+	active map[int]bool
+}
+
+func (s stubIdentityProvider) Authenticate(ctx context.Context, email string, password string) (*Identity, error) {
+	return nil, ErrInvalidCredentials
+}
+
+func (s stubIdentityProvider) GetIdentity(ctx context.Context, idUser int) (*Identity, error) {
+	if !s.active[idUser] {
+		return nil, nil
+	}
+	return &Identity{ID: idUser, Role: RoleUser}, nil
+}
+
+type argRequireActive struct {
+	name     string
+	identity *Identity
+	status   int
+}
+
+func TestRequireActive(t *testing.T) {
+	handler := RequireActive(stubIdentityProvider{active: map[int]bool{7: true}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	test := []argRequireActive{
+		{name: "RequireActive() with an active account", identity: &Identity{ID: 7}, status: http.StatusOK},
+		{name: "RequireActive() with a deleted account", identity: &Identity{ID: 8}, status: http.StatusUnauthorized},
+		{name: "RequireActive() without identity", status: http.StatusUnauthorized},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/post", nil)
+			if tt.identity != nil {
+				req = req.WithContext(WithIdentity(req.Context(), *tt.identity))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"fmt"
+	"socialBuddy/internal/apperror"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+
+	ActionView    = "view"
+	ActionEdit    = "edit"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+
+	ResourceUser    = "user"
+	ResourcePost    = "post"
+	ResourceComment = "comment"
+)
+
+type Resource struct {
+	Kind    string
+	ID      int
+	IDOwner int
+}
+
+type ForbiddenError struct {
+	Action   string
+	Resource string
+	ID       int
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("the user is not allowed to %s the %s %d", e.Action, e.Resource, e.ID)
+}
+
+func (e *ForbiddenError) Unwrap() error {
+	return apperror.ErrForbidden
+}
+
+func (e *ForbiddenError) Extensions() map[string]any {
+	return map[string]any{"action": e.Action, "resource": e.Resource, "id": e.ID}
+}
+
+// Policy decides whether an authenticated identity may act on a resource owned by another user.
+type Policy interface {
+	Authorize(actor Identity, action string, resource Resource) error
+}
+
+type policy struct{}
+
+func (p *policy) Authorize(actor Identity, action string, resource Resource) error {
+	if actor.ID == resource.IDOwner {
+		return nil
+	}
+	// accounts can only be changed by their owner, posts and comments can also be moderated by an admin,
+	// who may restore a deleted account as well
+	if actor.Role == RoleAdmin && (resource.Kind != ResourceUser || action == ActionRestore) {
+		return nil
+	}
+	return &ForbiddenError{Action: action, Resource: resource.Kind, ID: resource.ID}
+}
+
+func NewPolicy() Policy {
+	return &policy{}
+}
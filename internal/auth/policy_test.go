@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"socialBuddy/internal/apperror"
+	"testing"
+)
+
+type argPolicy struct {
+	name      string
+	actor     Identity
+	action    string
+	resource  Resource
+	forbidden bool
+}
+
+func TestAuthorize(t *testing.T) {
+	policy := NewPolicy()
+	test := []argPolicy{
+		{
+			name:     "Authorize() the author of a post",
+			actor:    Identity{ID: 2, Role: RoleUser},
+			resource: Resource{Kind: ResourcePost, ID: 1, IDOwner: 2},
+		},
+		{
+			name:      "Authorize() another user on a post",
+			actor:     Identity{ID: 3, Role: RoleUser},
+			resource:  Resource{Kind: ResourcePost, ID: 1, IDOwner: 2},
+			forbidden: true,
+		},
+		{
+			name:     "Authorize() an admin on a comment",
+			actor:    Identity{ID: 3, Role: RoleAdmin},
+			resource: Resource{Kind: ResourceComment, ID: 1, IDOwner: 2},
+		},
+		{
+			name:     "Authorize() the owner of an account",
+			actor:    Identity{ID: 2, Role: RoleUser},
+			resource: Resource{Kind: ResourceUser, ID: 2, IDOwner: 2},
+		},
+		{
+			name:      "Authorize() an admin on another account",
+			actor:     Identity{ID: 3, Role: RoleAdmin},
+			resource:  Resource{Kind: ResourceUser, ID: 2, IDOwner: 2},
+			forbidden: true,
+		},
+		{
+			name:      "Authorize() an admin viewing what only the owner of an account sees",
+			actor:     Identity{ID: 3, Role: RoleAdmin},
+			action:    ActionView,
+			resource:  Resource{Kind: ResourceUser, ID: 2, IDOwner: 2},
+			forbidden: true,
+		},
+		{
+			name:     "Authorize() an admin restoring another account",
+			actor:    Identity{ID: 3, Role: RoleAdmin},
+			action:   ActionRestore,
+			resource: Resource{Kind: ResourceUser, ID: 2, IDOwner: 2},
+		},
+		{
+			name:      "Authorize() another user restoring an account",
+			actor:     Identity{ID: 3, Role: RoleUser},
+			action:    ActionRestore,
+			resource:  Resource{Kind: ResourceUser, ID: 2, IDOwner: 2},
+			forbidden: true,
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			action := tt.action
+			if action == "" {
+				action = ActionEdit
+			}
+			err := policy.Authorize(tt.actor, action, tt.resource)
+			if errors.Is(err, apperror.ErrForbidden) != tt.forbidden {
+				t.Fatalf("expected forbidden %v, got %v", tt.forbidden, err)
+			}
+		})
+	}
+}
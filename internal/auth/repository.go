@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"socialBuddy/internal/dialect"
+	"time"
+)
+
+type Repository interface {
+	CreateRefreshToken(ctx context.Context, idUser int, tokenHash string, expiresAt time.Time) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	DeleteRefreshToken(ctx context.Context, tokenHash string) error
+	PurgeRefreshTokens(ctx context.Context, before time.Time) (int64, error)
+}
+
+type repository struct {
+	db *dialect.DB
+}
+
+func (r *repository) CreateRefreshToken(ctx context.Context, idUser int, tokenHash string, expiresAt time.Time) error {
+	ctx = dialect.Operation(ctx, "auth", "CreateRefreshToken")
+	_, err := r.db.ExecContext(ctx, `INSERT INTO RefreshToken (IDUser, TokenHash, ExpiresAt) VALUES (?, ?, ?)`,
+		idUser, tokenHash, expiresAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	ctx = dialect.Operation(ctx, "auth", "GetRefreshToken")
+	var token RefreshToken
+	err := r.db.QueryRowContext(ctx, "SELECT ID, IDUser, TokenHash, ExpiresAt FROM RefreshToken WHERE TokenHash = ?", tokenHash).Scan(
+		&token.ID,
+		&token.IDUser,
+		&token.TokenHash,
+		&token.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// DeleteRefreshToken is ErrInvalidToken when the token is not there, so of two rotations of the same token
+// only one gets to delete it.
+func (r *repository) DeleteRefreshToken(ctx context.Context, tokenHash string) error {
+	ctx = dialect.Operation(ctx, "auth", "DeleteRefreshToken")
+	res, err := r.db.ExecContext(ctx, "DELETE FROM RefreshToken WHERE TokenHash = ?", tokenHash)
+	if err != nil {
+		return err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// PurgeRefreshTokens deletes the refresh tokens that expired before the cutoff.
+func (r *repository) PurgeRefreshTokens(ctx context.Context, before time.Time) (int64, error) {
+	ctx = dialect.Operation(ctx, "auth", "PurgeRefreshTokens")
+	res, err := r.db.ExecContext(ctx, "DELETE FROM RefreshToken WHERE ExpiresAt < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func NewRepository(db *dialect.DB) Repository {
+	return &repository{db}
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"log"
+	"reflect"
+	"socialBuddy/internal/dialect"
+	"testing"
+	"time"
+)
+
+type argCreate struct {
+	name      string
+	idUser    int
+	tokenHash string
+	expiresAt time.Time
+	hasError  error
+}
+
+type argGet struct {
+	name      string
+	tokenHash string
+	output    *RefreshToken
+	hasError  error
+}
+
+func TestCreateRefreshToken(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	expiresAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec("INSERT INTO RefreshToken").WithArgs(1, "hash", expiresAt).WillReturnResult(sqlmock.NewResult(1, 1))
+	test := []argCreate{
+		{
+			name:      "CreateRefreshToken() is succeed",
+			idUser:    1,
+			tokenHash: "hash",
+			expiresAt: expiresAt,
+			hasError:  nil,
+		},
+		{
+			name:      "CreateRefreshToken() is failed",
+			idUser:    2,
+			tokenHash: "hash",
+			expiresAt: expiresAt,
+			hasError:  errors.New("the token wasn't created"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rep.CreateRefreshToken(context.Background(), tt.idUser, tt.tokenHash, tt.expiresAt)
+			log.Printf("err: %v", err)
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
+
+func TestGetRefreshToken(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	expiresAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	result := sqlmock.NewRows([]string{"ID", "IDUser", "TokenHash", "ExpiresAt"}).AddRow(1, 2, "hash", expiresAt)
+	mock.ExpectQuery("SELECT ID, IDUser, TokenHash, ExpiresAt FROM RefreshToken WHERE TokenHash = \\?").WithArgs("hash").WillReturnRows(result)
+	mock.ExpectQuery("SELECT ID, IDUser, TokenHash, ExpiresAt FROM RefreshToken WHERE TokenHash = \\?").WithArgs("unknown").WillReturnRows(sqlmock.NewRows([]string{"ID", "IDUser", "TokenHash", "ExpiresAt"}))
+	test := []argGet{
+		{
+			name:      "GetRefreshToken() is succeed",
+			tokenHash: "hash",
+			output:    &RefreshToken{ID: 1, IDUser: 2, TokenHash: "hash", ExpiresAt: expiresAt},
+			hasError:  nil,
+		},
+		{
+			name:      "GetRefreshToken() when the token wasn't found",
+			tokenHash: "unknown",
+			output:    nil,
+			hasError:  nil,
+		},
+		{
+			name:      "GetRefreshToken() is failed",
+			tokenHash: "other",
+			output:    nil,
+			hasError:  errors.New("the query is failed"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := rep.GetRefreshToken(context.Background(), tt.tokenHash)
+			log.Printf("token: %+v, err: %v", token, err)
+			if !reflect.DeepEqual(token, tt.output) {
+				t.Fatalf("expected %+v, got %+v", tt.output, token)
+			}
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
+
+func TestDeleteRefreshToken(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectExec("DELETE FROM RefreshToken WHERE TokenHash = \\?").WithArgs("hash").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM RefreshToken WHERE TokenHash = \\?").WithArgs("rotated").WillReturnResult(sqlmock.NewResult(0, 0))
+	err = rep.DeleteRefreshToken(context.Background(), "hash")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	err = rep.DeleteRefreshToken(context.Background(), "rotated")
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected the token to be invalid, got %v", err)
+	}
+	err = rep.DeleteRefreshToken(context.Background(), "hash")
+	if err == nil {
+		t.Fatalf("expected error deleting without expectation")
+	}
+}
+
+func TestPurgeRefreshTokens(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec("DELETE FROM RefreshToken WHERE ExpiresAt < \\?").WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 3))
+	purged, err := rep.PurgeRefreshTokens(context.Background(), before)
+	if err != nil || purged != 3 {
+		t.Fatalf("expected 3 tokens purged, got %d, err %v", purged, err)
+	}
+}
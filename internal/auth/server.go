@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"socialBuddy/internal/apperror"
+)
+
+type Server struct {
+	authService Service
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (s *Server) Login(w http.ResponseWriter, r *http.Request) {
+	var login Login
+	err := json.NewDecoder(r.Body).Decode(&login)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	err = r.Body.Close()
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	tokens, err := s.authService.Login(r.Context(), login)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(tokens)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func (s *Server) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	err = r.Body.Close()
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	tokens, err := s.authService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(tokens)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func (s *Server) Logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	err = r.Body.Close()
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	err = s.authService.Logout(r.Context(), req.RefreshToken)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func NewServer(authService Service) *Server {
+	return &Server{authService}
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"socialBuddy/internal/apperror"
+	"time"
+)
+
+var ErrInvalidCredentials = apperror.Unauthorized("email or password is not valid")
+
+var ErrDeletedAccount = apperror.Unauthorized("the account is deleted, it can only be restored")
+
+// IdentityProvider is implemented by the user service, which owns the credentials. Authenticate also finds
+// the deleted accounts, GetIdentity only the active ones.
+type IdentityProvider interface {
+	Authenticate(ctx context.Context, email string, password string) (*Identity, error)
+	GetIdentity(ctx context.Context, idUser int) (*Identity, error)
+}
+
+type service struct {
+	AuthRepository   Repository
+	IdentityProvider IdentityProvider
+	TokenSigner      Signer
+	RefreshTTL       time.Duration
+}
+
+type Service interface {
+	Login(ctx context.Context, login Login) (*Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*Tokens, error)
+	Logout(ctx context.Context, refreshToken string) error
+}
+
+// Login gives a deleted account an access token without refresh token, enough to restore the account and
+// nothing else.
+func (s *service) Login(ctx context.Context, login Login) (*Tokens, error) {
+	identity, err := s.IdentityProvider.Authenticate(ctx, login.Email, login.Password)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.IdentityProvider.GetIdentity(ctx, identity.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active == nil {
+		return s.sign(*identity)
+	}
+	return s.issue(ctx, *identity)
+}
+
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	tokenHash := hashToken(refreshToken)
+	stored, err := s.AuthRepository.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, ErrInvalidToken
+	}
+	err = s.AuthRepository.DeleteRefreshToken(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	identity, err := s.IdentityProvider.GetIdentity(ctx, stored.IDUser)
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil {
+		return nil, ErrInvalidToken
+	}
+	return s.issue(ctx, *identity)
+}
+
+func (s *service) Logout(ctx context.Context, refreshToken string) error {
+	err := s.AuthRepository.DeleteRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil && !errors.Is(err, ErrInvalidToken) {
+		return err
+	}
+	return nil
+}
+
+func (s *service) issue(ctx context.Context, identity Identity) (*Tokens, error) {
+	tokens, err := s.sign(identity)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.AuthRepository.CreateRefreshToken(ctx, identity.ID, hashToken(refreshToken), time.Now().Add(s.RefreshTTL))
+	if err != nil {
+		return nil, err
+	}
+	tokens.RefreshToken = refreshToken
+	return tokens, nil
+}
+
+func (s *service) sign(identity Identity) (*Tokens, error) {
+	accessToken, expiresAt, err := s.TokenSigner.Sign(identity)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func NewService(authRepository Repository, identityProvider IdentityProvider, tokenSigner Signer, refreshTTL time.Duration) Service {
+	return &service{authRepository, identityProvider, tokenSigner, refreshTTL}
+}
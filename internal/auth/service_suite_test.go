@@ -0,0 +1,13 @@
+package auth
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestAuthService(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Auth Service Suite")
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type mockRepository struct {
+	mock.Mock
+}
+
+type mockIdentityProvider struct {
+	mock.Mock
+}
+
+func (m *mockRepository) CreateRefreshToken(ctx context.Context, idUser int, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(idUser, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefreshToken), args.Error(1)
+}
+
+func (m *mockRepository) DeleteRefreshToken(ctx context.Context, tokenHash string) error {
+	args := m.Called(tokenHash)
+	return args.Error(0)
+}
+
+func (m *mockRepository) PurgeRefreshTokens(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockIdentityProvider) Authenticate(ctx context.Context, email string, password string) (*Identity, error) {
+	args := m.Called(email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Identity), args.Error(1)
+}
+
+func (m *mockIdentityProvider) GetIdentity(ctx context.Context, idUser int) (*Identity, error) {
+	args := m.Called(idUser)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Identity), args.Error(1)
+}
+
+var _ = Describe("The Service Test", func() {
+	var (
+		mockAuthRepository *mockRepository
+		mockService        *mockIdentityProvider
+		tokenSigner        Signer
+	)
+	BeforeEach(func() {
+		mockAuthRepository = new(mockRepository)
+		mockService = new(mockIdentityProvider)
+		tokenSigner = NewSigner([]byte("secret"), time.Minute)
+	})
+	It("should Login successfully", func() {
+		mockService.On("Authenticate", "name.first@gmail.com", "secret123").Return(&Identity{ID: 1, Role: RoleAdmin}, nil)
+		mockService.On("GetIdentity", 1).Return(&Identity{ID: 1, Role: RoleAdmin}, nil)
+		mockAuthRepository.On("CreateRefreshToken", 1, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		tokens, err := newService.Login(context.Background(), Login{Email: "name.first@gmail.com", Password: "secret123"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(tokens.RefreshToken).ShouldNot(BeEmpty())
+		identity, err := tokenSigner.Parse(tokens.AccessToken)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(*identity).Should(Equal(Identity{ID: 1, Role: RoleAdmin}))
+	})
+	It("should Login a deleted account without refresh token", func() {
+		mockService.On("Authenticate", "name.first@gmail.com", "secret123").Return(&Identity{ID: 1, Role: RoleUser}, nil)
+		mockService.On("GetIdentity", 1).Return(nil, nil)
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		tokens, err := newService.Login(context.Background(), Login{Email: "name.first@gmail.com", Password: "secret123"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(tokens.AccessToken).ShouldNot(BeEmpty())
+		Expect(tokens.RefreshToken).Should(BeEmpty())
+		mockAuthRepository.AssertNotCalled(GinkgoT(), "CreateRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+	})
+	It("should Login unsuccessfully", func() {
+		mockService.On("Authenticate", "name.first@gmail.com", "wrong").Return(nil, ErrInvalidCredentials)
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		tokens, err := newService.Login(context.Background(), Login{Email: "name.first@gmail.com", Password: "wrong"})
+		Expect(err).Should(MatchError(ErrInvalidCredentials))
+		Expect(tokens).Should(BeNil())
+	})
+	It("should Refresh successfully", func() {
+		tokenHash := hashToken("refresh")
+		mockAuthRepository.On("GetRefreshToken", tokenHash).Return(&RefreshToken{
+			ID:        1,
+			IDUser:    2,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}, nil)
+		mockAuthRepository.On("DeleteRefreshToken", tokenHash).Return(nil)
+		mockService.On("GetIdentity", 2).Return(&Identity{ID: 2, Role: RoleUser}, nil)
+		mockAuthRepository.On("CreateRefreshToken", 2, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		tokens, err := newService.Refresh(context.Background(), "refresh")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(tokens.RefreshToken).ShouldNot(Equal("refresh"))
+	})
+	It("should Refresh unsuccessfully when the token is expired", func() {
+		tokenHash := hashToken("refresh")
+		mockAuthRepository.On("GetRefreshToken", tokenHash).Return(&RefreshToken{
+			ID:        1,
+			IDUser:    2,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(-time.Hour),
+		}, nil)
+		mockAuthRepository.On("DeleteRefreshToken", tokenHash).Return(nil)
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		tokens, err := newService.Refresh(context.Background(), "refresh")
+		Expect(err).Should(MatchError(ErrInvalidToken))
+		Expect(tokens).Should(BeNil())
+	})
+	It("should Refresh unsuccessfully when the user no longer exists", func() {
+		tokenHash := hashToken("refresh")
+		mockAuthRepository.On("GetRefreshToken", tokenHash).Return(&RefreshToken{
+			ID:        1,
+			IDUser:    2,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}, nil)
+		mockAuthRepository.On("DeleteRefreshToken", tokenHash).Return(nil)
+		mockService.On("GetIdentity", 2).Return(nil, nil)
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		tokens, err := newService.Refresh(context.Background(), "refresh")
+		Expect(err).Should(MatchError(ErrInvalidToken))
+		Expect(tokens).Should(BeNil())
+	})
+	It("should Refresh unsuccessfully when the token was already rotated", func() {
+		tokenHash := hashToken("refresh")
+		mockAuthRepository.On("GetRefreshToken", tokenHash).Return(&RefreshToken{
+			ID:        1,
+			IDUser:    2,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}, nil)
+		mockAuthRepository.On("DeleteRefreshToken", tokenHash).Return(ErrInvalidToken)
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		tokens, err := newService.Refresh(context.Background(), "refresh")
+		Expect(err).Should(MatchError(ErrInvalidToken))
+		Expect(tokens).Should(BeNil())
+		mockAuthRepository.AssertNotCalled(GinkgoT(), "CreateRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+	})
+	It("should Refresh unsuccessfully when the token is unknown", func() {
+		mockAuthRepository.On("GetRefreshToken", hashToken("unknown")).Return(nil, nil)
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		tokens, err := newService.Refresh(context.Background(), "unknown")
+		Expect(err).Should(MatchError(ErrInvalidToken))
+		Expect(tokens).Should(BeNil())
+	})
+	It("should Logout successfully", func() {
+		mockAuthRepository.On("DeleteRefreshToken", hashToken("refresh")).Return(nil)
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		err := newService.Logout(context.Background(), "refresh")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("should Logout successfully when the token is unknown", func() {
+		mockAuthRepository.On("DeleteRefreshToken", hashToken("unknown")).Return(ErrInvalidToken)
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		err := newService.Logout(context.Background(), "unknown")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("should Logout unsuccessfully", func() {
+		mockAuthRepository.On("DeleteRefreshToken", hashToken("refresh")).Return(errors.New("error while DeleteRefreshToken()"))
+		newService := NewService(mockAuthRepository, mockService, tokenSigner, time.Hour)
+		err := newService.Logout(context.Background(), "refresh")
+		Expect(err).Should(HaveOccurred())
+	})
+})
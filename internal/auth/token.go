@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"socialBuddy/internal/apperror"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidToken = apperror.Unauthorized("the token is not valid")
+
+type Signer interface {
+	Sign(identity Identity) (string, time.Time, error)
+	Parse(token string) (*Identity, error)
+}
+
+type claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type signer struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func (s *signer) Sign(identity Identity) (string, time.Time, error) {
+	issuedAt := s.now()
+	expiresAt := issuedAt.Add(s.ttl)
+	tokenClaims := claims{
+		Role: identity.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(identity.ID),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims).SignedString(s.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+func (s *signer) Parse(token string) (*Identity, error) {
+	var tokenClaims claims
+	_, err := jwt.ParseWithClaims(token, &tokenClaims, func(*jwt.Token) (any, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithTimeFunc(s.now))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	id, err := strconv.Atoi(tokenClaims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &Identity{ID: id, Role: tokenClaims.Role}, nil
+}
+
+func NewSigner(secret []byte, ttl time.Duration) Signer {
+	return &signer{secret, ttl, time.Now}
+}
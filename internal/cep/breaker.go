@@ -0,0 +1,59 @@
+package cep
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker stops calling the provider after failures consecutive failures. Once cooldown has passed
+// it lets a single call through, its result closes the breaker again or reopens it.
+type breaker struct {
+	mu       sync.Mutex
+	failures int
+	cooldown time.Duration
+	now      func() time.Time
+	count    int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(failures int, cooldown time.Duration) *breaker {
+	return &breaker{failures: failures, cooldown: cooldown, now: time.Now}
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.count < b.failures {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.count = 0
+	b.probing = false
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.count++
+	b.probing = false
+	if b.count >= b.failures {
+		b.openedAt = b.now()
+	}
+}
+
+// abandon ends a call whose caller went away without counting it, a probe can be let through again.
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
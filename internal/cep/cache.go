@@ -0,0 +1,113 @@
+package cep
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"socialBuddy/internal/user"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	zipCode  string
+	address  user.Address
+	cachedAt time.Time
+}
+
+// cache keeps the last addresses looked up, the least recently used one is evicted when it is full.
+// With a store, misses fall back to it and every new address is written through.
+type cache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	order   *list.List
+	entries map[string]*list.Element
+	store   Store
+}
+
+func newCache(size int, ttl time.Duration, store Store) *cache {
+	return &cache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+		store:   store,
+	}
+}
+
+func (c *cache) get(ctx context.Context, zipCode string) (user.Address, bool) {
+	address, ok := c.memory(zipCode)
+	if ok || c.store == nil {
+		return address, ok
+	}
+	// the store is read without the lock, so a slow database does not hold up the lookups served from memory
+	stored, cachedAt, err := c.store.GetAddress(ctx, zipCode)
+	if err != nil {
+		slog.Warn("the CEP cache cannot be read", "error", err)
+		return user.Address{}, false
+	}
+	if stored == nil || !c.fresh(cachedAt) {
+		return user.Address{}, false
+	}
+	c.mu.Lock()
+	c.replace(zipCode, *stored, cachedAt)
+	c.mu.Unlock()
+	return *stored, true
+}
+
+func (c *cache) memory(zipCode string) (user.Address, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[zipCode]
+	if !ok {
+		return user.Address{}, false
+	}
+	cached := element.Value.(*entry)
+	if c.fresh(cached.cachedAt) {
+		c.order.MoveToFront(element)
+		return cached.address, true
+	}
+	c.order.Remove(element)
+	delete(c.entries, zipCode)
+	return user.Address{}, false
+}
+
+func (c *cache) put(ctx context.Context, zipCode string, address user.Address) {
+	address.Number = ""
+	address.Complement = ""
+	cachedAt := c.now()
+	c.mu.Lock()
+	c.replace(zipCode, address, cachedAt)
+	c.mu.Unlock()
+	if c.store != nil {
+		err := c.store.SaveAddress(ctx, zipCode, address, cachedAt)
+		if err != nil {
+			slog.Warn("the CEP cache cannot be written", "error", err)
+		}
+	}
+}
+
+// replace adds the address in place of the one cached for zipCode, the caller holds the lock.
+func (c *cache) replace(zipCode string, address user.Address, cachedAt time.Time) {
+	if element, ok := c.entries[zipCode]; ok {
+		c.order.Remove(element)
+		delete(c.entries, zipCode)
+	}
+	c.add(zipCode, address, cachedAt)
+}
+
+func (c *cache) add(zipCode string, address user.Address, cachedAt time.Time) {
+	c.entries[zipCode] = c.order.PushFront(&entry{zipCode, address, cachedAt})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).zipCode)
+	}
+}
+
+func (c *cache) fresh(cachedAt time.Time) bool {
+	return c.now().Sub(cachedAt) < c.ttl
+}
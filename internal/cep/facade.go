@@ -0,0 +1,94 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/user"
+	"time"
+)
+
+type Options struct {
+	// Timeout bounds every attempt, zero leaves it to next, as a chain bounds each of its providers.
+	// The caller's context still bounds the whole lookup.
+	Timeout time.Duration
+	Retries int
+	// Backoff is the wait before the first retry, it doubles on every other one.
+	Backoff         time.Duration
+	CacheSize       int
+	CacheTTL        time.Duration
+	BreakerFailures int
+	BreakerCooldown time.Duration
+	// Store persists the cache, nil keeps it in memory only.
+	Store Store
+}
+
+type facade struct {
+	next    user.Facade
+	opts    Options
+	cache   *cache
+	breaker *breaker
+}
+
+// NewFacade decorates next with a cache, per-attempt timeouts, retries and a circuit breaker.
+// Only transport failures are retried, an invalid or unknown zip code is the provider's answer.
+func NewFacade(next user.Facade, opts Options) user.Facade {
+	return &facade{
+		next:    next,
+		opts:    opts,
+		cache:   newCache(opts.CacheSize, opts.CacheTTL, opts.Store),
+		breaker: newBreaker(opts.BreakerFailures, opts.BreakerCooldown),
+	}
+}
+
+func (f *facade) FindCep(ctx context.Context, cepUser string, number string, complement string) (*user.Address, error) {
+	key := digits(cepUser)
+	if address, ok := f.cache.get(ctx, key); ok {
+		address.ZipCode = cepUser
+		address.Number = number
+		address.Complement = complement
+		return &address, nil
+	}
+
+	backoff := f.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		if !f.breaker.allow() {
+			return nil, apperror.Unavailable("the zip code lookup is unavailable, try again later")
+		}
+		address, err := f.attempt(ctx, cepUser, number, complement)
+		var appErr *apperror.Error
+		if err == nil || errors.As(err, &appErr) {
+			f.breaker.success()
+			if err == nil {
+				f.cache.put(ctx, key, *address)
+			}
+			return address, err
+		}
+		// a caller that gave up or ran out of time says nothing about the provider
+		if ctx.Err() != nil {
+			f.breaker.abandon()
+			return nil, ctx.Err()
+		}
+		f.breaker.failure()
+		slog.Warn("the zip code lookup failed", "attempt", attempt+1, "error", err)
+		if attempt == f.opts.Retries {
+			return nil, apperror.Unavailable("the zip code lookup is unavailable, try again later")
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (f *facade) attempt(ctx context.Context, cepUser string, number string, complement string) (*user.Address, error) {
+	if f.opts.Timeout == 0 {
+		return f.next.FindCep(ctx, cepUser, number, complement)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, f.opts.Timeout)
+	defer cancel()
+	return f.next.FindCep(attemptCtx, cepUser, number, complement)
+}
@@ -0,0 +1,210 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/user"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const viaCepBody = `{"cep": "12246-260", "logradouro": "Avenida Salmão", "bairro": "Parque Residencial Aquarius",
+	"localidade": "São José dos Campos", "uf": "SP"}`
+
+var options = Options{
+	Timeout:         time.Second,
+	Retries:         2,
+	Backoff:         time.Millisecond,
+	CacheSize:       2,
+	CacheTTL:        time.Hour,
+	BreakerFailures: 5,
+	BreakerCooldown: time.Minute,
+}
+
+// newProvider serves the answers in order, repeating the last one, and counts the calls.
+func newProvider(t *testing.T, answers ...func(w http.ResponseWriter)) (*httptest.Server, *atomic.Int32) {
+	calls := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		if n >= len(answers) {
+			n = len(answers) - 1
+		}
+		answers[n](w)
+	}))
+	t.Cleanup(server.Close)
+	return server, calls
+}
+
+func found(w http.ResponseWriter) {
+	_, _ = w.Write([]byte(viaCepBody))
+}
+
+func notFound(w http.ResponseWriter) {
+	_, _ = w.Write([]byte(`{"erro": "true"}`))
+}
+
+func failed(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+func newFacade(server *httptest.Server, opts Options) *facade {
+	return NewFacade(user.NewFacade(server.URL, server.Client()), opts).(*facade)
+}
+
+func TestFindCepCache(t *testing.T) {
+	server, calls := newProvider(t, found)
+	api := newFacade(server, options)
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	api.cache.now = func() time.Time { return now }
+
+	first, err := api.FindCep(context.Background(), "12246-260", "10", "Torre C")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second, err := api.FindCep(context.Background(), "12246260", "20", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected the second lookup to be cached, got %d calls", calls.Load())
+	}
+	if first.Number != "10" || first.Complement != "Torre C" || second.Number != "20" || second.Complement != "" {
+		t.Fatalf("expected the number and complement of each lookup, got %+v and %+v", first, second)
+	}
+	if second.ZipCode != "12246260" || second.City != "São José dos Campos" {
+		t.Fatalf("unexpected cached address %+v", second)
+	}
+
+	now = now.Add(options.CacheTTL)
+	_, err = api.FindCep(context.Background(), "12246260", "20", "")
+	if err != nil || calls.Load() != 2 {
+		t.Fatalf("expected the expired entry to be looked up again, got %d calls, err %v", calls.Load(), err)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := newCache(2, time.Hour, nil)
+	c.put(context.Background(), "1", user.Address{City: "one"})
+	c.put(context.Background(), "2", user.Address{City: "two"})
+	c.get(context.Background(), "1")
+	c.put(context.Background(), "3", user.Address{City: "three"})
+	if _, ok := c.get(context.Background(), "2"); ok {
+		t.Fatalf("expected the least recently used entry to be evicted")
+	}
+	for _, zipCode := range []string{"1", "3"} {
+		if _, ok := c.get(context.Background(), zipCode); !ok {
+			t.Fatalf("expected %s to be cached", zipCode)
+		}
+	}
+}
+
+func TestFindCepRetries(t *testing.T) {
+	test := []struct {
+		name     string
+		answers  []func(w http.ResponseWriter)
+		calls    int32
+		hasError error
+	}{
+		{name: "FindCep() retries until it is succeed", answers: []func(w http.ResponseWriter){failed, failed, found}, calls: 3},
+		{name: "FindCep() gives up after the retries", answers: []func(w http.ResponseWriter){failed}, calls: 3, hasError: apperror.ErrUnavailable},
+		{name: "FindCep() does not retry an unknown CEP", answers: []func(w http.ResponseWriter){notFound}, calls: 1, hasError: apperror.ErrNotFound},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := newProvider(t, tt.answers...)
+			_, err := newFacade(server, options).FindCep(context.Background(), "12246260", "10", "")
+			if (tt.hasError == nil && err != nil) || (tt.hasError != nil && !errors.Is(err, tt.hasError)) {
+				t.Fatalf("expected %v, got %v", tt.hasError, err)
+			}
+			if calls.Load() != tt.calls {
+				t.Fatalf("expected %d calls, got %d", tt.calls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestFindCepTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server, calls := newProvider(t, func(w http.ResponseWriter) {
+		<-release
+	})
+	defer close(release)
+	opts := options
+	opts.Timeout = 20 * time.Millisecond
+	opts.Retries = 1
+	start := time.Now()
+	_, err := newFacade(server, opts).FindCep(context.Background(), "12246260", "10", "")
+	if !errors.Is(err, apperror.ErrUnavailable) {
+		t.Fatalf("expected the lookup to be unavailable, got %v", err)
+	}
+	if calls.Load() != 2 || time.Since(start) > time.Second {
+		t.Fatalf("expected two attempts cut by the timeout, got %d calls in %s", calls.Load(), time.Since(start))
+	}
+}
+
+func TestFindCepCircuitBreaker(t *testing.T) {
+	healthy := &atomic.Bool{}
+	server, calls := newProvider(t, func(w http.ResponseWriter) {
+		if healthy.Load() {
+			found(w)
+			return
+		}
+		failed(w)
+	})
+	opts := options
+	opts.Retries = 0
+	opts.BreakerFailures = 2
+	api := newFacade(server, opts)
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	api.breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, err := api.FindCep(context.Background(), "12246260", "10", "")
+		if !errors.Is(err, apperror.ErrUnavailable) {
+			t.Fatalf("expected the lookup to be unavailable, got %v", err)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected the open breaker to skip the provider, got %d calls", calls.Load())
+	}
+
+	now = now.Add(opts.BreakerCooldown)
+	healthy.Store(true)
+	_, err := api.FindCep(context.Background(), "12246260", "10", "")
+	if err != nil || calls.Load() != 3 {
+		t.Fatalf("expected the breaker to let a probe through, got %d calls, err %v", calls.Load(), err)
+	}
+	_, err = api.FindCep(context.Background(), "01001000", "10", "")
+	if err != nil || calls.Load() != 4 {
+		t.Fatalf("expected the breaker to be closed, got %d calls, err %v", calls.Load(), err)
+	}
+}
+
+func TestFindCepCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server, calls := newProvider(t, func(w http.ResponseWriter) {
+		<-release
+	})
+	defer close(release)
+	opts := options
+	opts.Retries = 0
+	opts.BreakerFailures = 1
+	api := newFacade(server, opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := api.FindCep(ctx, "12246260", "10", "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the caller deadline, got %v", err)
+	}
+	if !api.breaker.allow() {
+		t.Fatalf("expected a caller that gave up not to open the breaker")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected one call, got %d", calls.Load())
+	}
+}
@@ -0,0 +1,225 @@
+package cep
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/user"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderViaCEP    = "viacep"
+	ProviderBrasilAPI = "brasilapi"
+	ProviderOpenCEP   = "opencep"
+	ProviderOffline   = "offline"
+)
+
+// Provider is a named source of addresses, the name is what the logs and metrics report.
+type Provider struct {
+	Name   string
+	Facade user.Facade
+}
+
+type chain struct {
+	timeout   time.Duration
+	answered  func(provider string)
+	providers []Provider
+}
+
+// NewChain asks the providers in order until one finds the zip code. An invalid zip code stops the chain,
+// a provider that does not know the zip code, fails or takes longer than timeout hands over to the next one.
+// answered, when not nil, is told the name of the provider whose address is returned.
+func NewChain(timeout time.Duration, answered func(provider string), providers ...Provider) user.Facade {
+	return &chain{timeout, answered, providers}
+}
+
+func (c *chain) FindCep(ctx context.Context, cepUser string, number string, complement string) (*user.Address, error) {
+	var notFound, failure error
+	for _, provider := range c.providers {
+		providerCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		address, err := provider.Facade.FindCep(providerCtx, cepUser, number, complement)
+		cancel()
+		switch {
+		case err == nil:
+			if c.answered != nil {
+				c.answered(provider.Name)
+			}
+			return address, nil
+		case errors.Is(err, apperror.ErrValidation):
+			return nil, err
+		case errors.Is(err, apperror.ErrNotFound):
+			notFound = err
+		default:
+			slog.Warn("the zip code provider failed", "provider", provider.Name, "error", err)
+			failure = fmt.Errorf("%s: %w", provider.Name, err)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	if notFound != nil {
+		return nil, notFound
+	}
+	return nil, failure
+}
+
+type brasilAPI struct {
+	url    string
+	client *http.Client
+}
+
+func NewBrasilAPI(url string, client *http.Client) user.Facade {
+	return &brasilAPI{url, client}
+}
+
+func (b *brasilAPI) FindCep(ctx context.Context, cepUser string, number string, complement string) (*user.Address, error) {
+	var result struct {
+		State        string `json:"state"`
+		City         string `json:"city"`
+		Neighborhood string `json:"neighborhood"`
+		Street       string `json:"street"`
+	}
+	status, err := getJSON(ctx, b.client, fmt.Sprintf("%s/api/cep/v1/%s", b.url, digits(cepUser)), &result)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(status)
+	}
+	return &user.Address{
+		ZipCode:      cepUser,
+		Country:      "Brasil",
+		State:        result.State,
+		City:         result.City,
+		Neighborhood: result.Neighborhood,
+		Street:       result.Street,
+		Number:       number,
+		Complement:   complement,
+	}, nil
+}
+
+type openCEP struct {
+	url    string
+	client *http.Client
+}
+
+// NewOpenCEP reads the ViaCEP-like answers of OpenCEP, which tells an unknown zip code with a 404.
+func NewOpenCEP(url string, client *http.Client) user.Facade {
+	return &openCEP{url, client}
+}
+
+func (o *openCEP) FindCep(ctx context.Context, cepUser string, number string, complement string) (*user.Address, error) {
+	var result map[string]string
+	status, err := getJSON(ctx, o.client, fmt.Sprintf("%s/v1/%s", o.url, digits(cepUser)), &result)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(status)
+	}
+	return &user.Address{
+		ZipCode:      cepUser,
+		Country:      "Brasil",
+		State:        result["uf"],
+		City:         result["localidade"],
+		Neighborhood: result["bairro"],
+		Street:       result["logradouro"],
+		Number:       number,
+		Complement:   complement,
+	}, nil
+}
+
+type offline struct {
+	addresses map[string]user.Address
+}
+
+// NewOffline loads a CSV dataset with the header cep,state,city,neighborhood,street.
+func NewOffline(path string) (user.Facade, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("the CEP dataset cannot be read: %w", err)
+	}
+	defer file.Close()
+	return readOffline(file)
+}
+
+func readOffline(r io.Reader) (user.Facade, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 5
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("the CEP dataset has no header: %w", err)
+	}
+	if strings.Join(header, ",") != "cep,state,city,neighborhood,street" {
+		return nil, fmt.Errorf("the CEP dataset header %q is not cep,state,city,neighborhood,street", strings.Join(header, ","))
+	}
+	addresses := map[string]user.Address{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the CEP dataset is not valid: %w", err)
+		}
+		addresses[digits(record[0])] = user.Address{
+			Country:      "Brasil",
+			State:        record[1],
+			City:         record[2],
+			Neighborhood: record[3],
+			Street:       record[4],
+		}
+	}
+	return &offline{addresses}, nil
+}
+
+func (o *offline) FindCep(_ context.Context, cepUser string, number string, complement string) (*user.Address, error) {
+	address, ok := o.addresses[digits(cepUser)]
+	if !ok {
+		return nil, apperror.NotFound("the zip code was not found")
+	}
+	address.ZipCode = cepUser
+	address.Number = number
+	address.Complement = complement
+	return &address, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, result any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return res.StatusCode, nil
+	}
+	return res.StatusCode, json.NewDecoder(res.Body).Decode(result)
+}
+
+func statusError(status int) error {
+	switch status {
+	case http.StatusBadRequest:
+		return apperror.Validation("the zip code is not valid")
+	case http.StatusNotFound:
+		return apperror.NotFound("the zip code was not found")
+	default:
+		return fmt.Errorf("the zip code provider answered %d", status)
+	}
+}
+
+func digits(zipCode string) string {
+	return strings.ReplaceAll(zipCode, "-", "")
+}
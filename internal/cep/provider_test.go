@@ -0,0 +1,238 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/user"
+	"strings"
+	"testing"
+	"time"
+)
+
+type argProvider struct {
+	name     string
+	facade   func(server *httptest.Server) user.Facade
+	path     string
+	status   int
+	body     string
+	hasError error
+}
+
+var expectedAddress = &user.Address{
+	ZipCode:      "01001-000",
+	Country:      "Brasil",
+	State:        "SP",
+	City:         "São Paulo",
+	Neighborhood: "Sé",
+	Street:       "Praça da Sé",
+	Number:       "1",
+	Complement:   "Sala 2",
+}
+
+func TestProviders(t *testing.T) {
+	brasilAPI := func(server *httptest.Server) user.Facade { return NewBrasilAPI(server.URL, server.Client()) }
+	openCEP := func(server *httptest.Server) user.Facade { return NewOpenCEP(server.URL, server.Client()) }
+	test := []argProvider{
+		{
+			name:   "BrasilAPI FindCep() is succeed",
+			facade: brasilAPI,
+			path:   "/api/cep/v1/01001000",
+			status: http.StatusOK,
+			body:   `{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Sé", "street": "Praça da Sé", "service": "correios"}`,
+		},
+		{
+			name:     "BrasilAPI FindCep() of an unknown CEP",
+			facade:   brasilAPI,
+			path:     "/api/cep/v1/01001000",
+			status:   http.StatusNotFound,
+			body:     `{"name": "CepPromiseError"}`,
+			hasError: apperror.ErrNotFound,
+		},
+		{
+			name:   "OpenCEP FindCep() is succeed",
+			facade: openCEP,
+			path:   "/v1/01001000",
+			status: http.StatusOK,
+			body:   `{"cep": "01001-000", "logradouro": "Praça da Sé", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP"}`,
+		},
+		{
+			name:     "OpenCEP FindCep() of an invalid CEP",
+			facade:   openCEP,
+			path:     "/v1/01001000",
+			status:   http.StatusBadRequest,
+			hasError: apperror.ErrValidation,
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					t.Errorf("expected the path %s, got %s", tt.path, r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			address, err := tt.facade(server).FindCep(context.Background(), "01001-000", "1", "Sala 2")
+			if tt.hasError != nil {
+				if !errors.Is(err, tt.hasError) {
+					t.Fatalf("expected %v, got %v", tt.hasError, err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(address, expectedAddress) {
+				t.Fatalf("expected %+v, got %+v, err %v", expectedAddress, address, err)
+			}
+		})
+	}
+}
+
+func TestOffline(t *testing.T) {
+	facade, err := NewOffline("testdata/ceps.csv")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	address, err := facade.FindCep(context.Background(), "01001-000", "1", "Sala 2")
+	if err != nil || !reflect.DeepEqual(address, expectedAddress) {
+		t.Fatalf("expected %+v, got %+v, err %v", expectedAddress, address, err)
+	}
+	_, err = facade.FindCep(context.Background(), "99999-999", "1", "")
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	_, err = readOffline(strings.NewReader("zip,uf\n01001000,SP\n"))
+	if err == nil {
+		t.Fatalf("expected the header to be rejected")
+	}
+}
+
+type stubFacade struct {
+	calls   int
+	address *user.Address
+	err     error
+}
+
+func (s *stubFacade) FindCep(ctx context.Context, cepUser string, number string, complement string) (*user.Address, error) {
+	s.calls++
+	return s.address, s.err
+}
+
+func TestChain(t *testing.T) {
+	failed := errors.New("connection refused")
+	test := []struct {
+		name     string
+		stubs    []*stubFacade
+		calls    []int
+		answered string
+		hasError error
+	}{
+		{
+			name:     "FindCep() falls back after a failure and an unknown CEP",
+			stubs:    []*stubFacade{{err: failed}, {err: apperror.NotFound("the zip code was not found")}, {address: expectedAddress}, {address: expectedAddress}},
+			calls:    []int{1, 1, 1, 0},
+			answered: "c",
+		},
+		{
+			name:     "FindCep() stops at an invalid CEP",
+			stubs:    []*stubFacade{{err: apperror.Validation("the zip code is not valid")}, {address: expectedAddress}},
+			calls:    []int{1, 0},
+			hasError: apperror.ErrValidation,
+		},
+		{
+			name:     "FindCep() is not found when a provider answered",
+			stubs:    []*stubFacade{{err: apperror.NotFound("the zip code was not found")}, {err: failed}},
+			calls:    []int{1, 1},
+			hasError: apperror.ErrNotFound,
+		},
+		{
+			name:     "FindCep() fails when every provider failed",
+			stubs:    []*stubFacade{{err: failed}, {err: failed}},
+			calls:    []int{1, 1},
+			hasError: failed,
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			var providers []Provider
+			for i, stub := range tt.stubs {
+				providers = append(providers, Provider{Name: string(rune('a' + i)), Facade: stub})
+			}
+			var answered string
+			chain := NewChain(time.Second, func(provider string) {
+				answered = provider
+			}, providers...)
+			address, err := chain.FindCep(context.Background(), "01001-000", "1", "Sala 2")
+			if (tt.hasError == nil && (err != nil || address != expectedAddress)) || (tt.hasError != nil && !errors.Is(err, tt.hasError)) {
+				t.Fatalf("expected %v, got %+v, err %v", tt.hasError, address, err)
+			}
+			for i, stub := range tt.stubs {
+				if stub.calls != tt.calls[i] {
+					t.Fatalf("expected provider %d to be called %d times, got %d", i, tt.calls[i], stub.calls)
+				}
+			}
+			if answered != tt.answered {
+				t.Fatalf("expected the provider %q to answer, got %q", tt.answered, answered)
+			}
+		})
+	}
+}
+
+// blockingFacade answers only once the context of the call is done, as a provider that hangs.
+type blockingFacade struct{}
+
+func (blockingFacade) FindCep(ctx context.Context, cepUser string, number string, complement string) (*user.Address, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestChainAfterBlockingProvider(t *testing.T) {
+	healthy := &stubFacade{address: expectedAddress}
+	var answered string
+	chain := NewChain(20*time.Millisecond, func(provider string) {
+		answered = provider
+	}, Provider{Name: ProviderViaCEP, Facade: blockingFacade{}}, Provider{Name: ProviderBrasilAPI, Facade: healthy})
+	api := NewFacade(chain, Options{Retries: 2, Backoff: time.Millisecond, CacheSize: 2, CacheTTL: time.Hour,
+		BreakerFailures: 5, BreakerCooldown: time.Minute})
+
+	start := time.Now()
+	address, err := api.FindCep(context.Background(), "01001-000", "1", "Sala 2")
+	if err != nil || address == nil || answered != ProviderBrasilAPI || healthy.calls != 1 {
+		t.Fatalf("expected BrasilAPI to answer after the hung provider, got %+v from %q, err %v", address, answered, err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("expected the hung provider to be cut by its timeout, took %s", time.Since(start))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewChain(time.Second, nil, Provider{Name: ProviderViaCEP, Facade: blockingFacade{}},
+		Provider{Name: ProviderBrasilAPI, Facade: healthy}).FindCep(ctx, "01001-000", "1", "")
+	if !errors.Is(err, context.Canceled) || healthy.calls != 1 {
+		t.Fatalf("expected a cancelled caller to stop the chain, got %v after %d calls", err, healthy.calls)
+	}
+}
+
+func TestChainReadiness(t *testing.T) {
+	offline, err := NewOffline("testdata/ceps.csv")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	check := user.CepCheck(NewChain(time.Second, nil, Provider{Name: ProviderOffline, Facade: offline}))
+	if err := check(context.Background()); err != nil {
+		t.Fatalf("expected an offline-only chain to be ready, got %v", err)
+	}
+	failing := &stubFacade{err: errors.New("connection refused")}
+	check = user.CepCheck(NewChain(time.Second, nil, Provider{Name: ProviderViaCEP, Facade: failing},
+		Provider{Name: ProviderBrasilAPI, Facade: &stubFacade{address: expectedAddress}}))
+	if err := check(context.Background()); err != nil {
+		t.Fatalf("expected the chain to be ready while a provider answers, got %v", err)
+	}
+	check = user.CepCheck(NewChain(time.Second, nil, Provider{Name: ProviderViaCEP, Facade: failing}))
+	if err := check(context.Background()); err == nil {
+		t.Fatalf("expected an error while no provider answers")
+	}
+}
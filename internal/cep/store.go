@@ -0,0 +1,56 @@
+package cep
+
+import (
+	"context"
+	"database/sql"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/user"
+	"time"
+)
+
+// Store persists the cache so it survives restarts.
+type Store interface {
+	GetAddress(ctx context.Context, zipCode string) (*user.Address, time.Time, error)
+	SaveAddress(ctx context.Context, zipCode string, address user.Address, cachedAt time.Time) error
+}
+
+type repository struct {
+	db *dialect.DB
+}
+
+func (r *repository) GetAddress(ctx context.Context, zipCode string) (*user.Address, time.Time, error) {
+	ctx = dialect.Operation(ctx, "cep", "GetAddress")
+	var address user.Address
+	var cachedAt time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT ZipCode, Country, State, City, Neighborhood, Street, CachedAt FROM CepCache
+		WHERE ZipCode = ?`, zipCode).Scan(
+		&address.ZipCode,
+		&address.Country,
+		&address.State,
+		&address.City,
+		&address.Neighborhood,
+		&address.Street,
+		&cachedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return &address, cachedAt, nil
+}
+
+func (r *repository) SaveAddress(ctx context.Context, zipCode string, address user.Address, cachedAt time.Time) error {
+	ctx = dialect.Operation(ctx, "cep", "SaveAddress")
+	_, err := r.db.ExecContext(ctx, `INSERT INTO CepCache (ZipCode, Country, State, City, Neighborhood, Street, CachedAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (ZipCode) DO UPDATE SET Country = excluded.Country, State = excluded.State, City = excluded.City,
+		Neighborhood = excluded.Neighborhood, Street = excluded.Street, CachedAt = excluded.CachedAt`,
+		zipCode, address.Country, address.State, address.City, address.Neighborhood, address.Street, cachedAt)
+	return err
+}
+
+func NewRepository(db *dialect.DB) Store {
+	return &repository{db}
+}
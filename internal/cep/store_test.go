@@ -0,0 +1,46 @@
+package cep
+
+import (
+	"context"
+	"reflect"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/dialect/dialecttest"
+	"socialBuddy/internal/user"
+	"testing"
+	"time"
+)
+
+func TestStoreDialects(t *testing.T) {
+	dialecttest.Run(t, func(t *testing.T, db *dialect.DB) {
+		store := NewRepository(db)
+		address, _, err := store.GetAddress(context.Background(), "12246260")
+		if err != nil || address != nil {
+			t.Fatalf("expected no address, got %+v, err %v", address, err)
+		}
+
+		cachedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		saved := user.Address{ZipCode: "12246260", Country: "Brasil", State: "SP", City: "São José dos Campos",
+			Neighborhood: "Parque Residencial Aquarius", Street: "Avenida Salmão"}
+		err = store.SaveAddress(context.Background(), "12246260", saved, cachedAt)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		saved.Street = "Rua Nova"
+		err = store.SaveAddress(context.Background(), "12246260", saved, cachedAt.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("expected the address to be replaced, got %v", err)
+		}
+
+		address, at, err := store.GetAddress(context.Background(), "12246260")
+		if err != nil || !reflect.DeepEqual(*address, saved) || !at.Equal(cachedAt.Add(time.Hour)) {
+			t.Fatalf("expected %+v at %s, got %+v at %s, err %v", saved, cachedAt.Add(time.Hour), address, at, err)
+		}
+
+		c := newCache(1, time.Hour, store)
+		c.now = func() time.Time { return cachedAt.Add(90 * time.Minute) }
+		cached, ok := c.get(context.Background(), "12246260")
+		if !ok || cached.Street != "Rua Nova" {
+			t.Fatalf("expected the cache to read the store, got %+v", cached)
+		}
+	})
+}
@@ -1,38 +1,77 @@
 package comment
 
 import (
-	"errors"
+	"context"
+	"socialBuddy/internal/apperror"
 	"socialBuddy/internal/post"
 	"socialBuddy/internal/user"
+	"strings"
 	"time"
 )
 
+const (
+	DefaultDepth = 3
+	MaxDepth     = 10
+)
+
 type Comment struct {
 	ID          int
 	IDPost      int
 	IDUser      int
+	IDParent    *int
 	DateComment time.Time
 	Content     string
+	Reactions   map[string]int
+	DeletedAt   *time.Time `json:"-"`
 }
 
-func ValidateIDPost(idPost int, servicePost post.Service) error {
-	userPost, err := servicePost.GetPostByID(idPost)
-	if err != nil {
-		return err
+// Thread is a comment with its first page of replies, ReplyCount counts every direct reply.
+type Thread struct {
+	Comment
+	ReplyCount int      `json:"reply_count"`
+	Replies    []Thread `json:"replies,omitempty"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+func contentValidation(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return apperror.Validation("the content is required")
 	}
-	if userPost == nil {
-		return errors.New("the post is not in database")
+	return nil
+}
+
+func depthValidation(depth int) error {
+	if depth < 1 || depth > MaxDepth {
+		return apperror.Validation("the depth must be between 1 and 10")
 	}
 	return nil
 }
 
-func ValidateIDUser(idUser int, serviceUser user.Service) error {
-	userPost, err := serviceUser.GetUserByID(idUser)
+// newThreads wraps comments into threads whose replies are not read yet.
+func newThreads(comments []Comment) []Thread {
+	threads := make([]Thread, len(comments))
+	for i, com := range comments {
+		threads[i] = Thread{Comment: com}
+	}
+	return threads
+}
+
+func threadID(thread Thread) int {
+	return thread.ID
+}
+
+func ValidateIDPost(ctx context.Context, idPost int, servicePost post.Service) error {
+	_, err := servicePost.GetPostByID(ctx, idPost)
 	if err != nil {
 		return err
 	}
-	if userPost == nil {
-		return errors.New("the user is not in database")
+	return nil
+}
+
+func ValidateIDUser(ctx context.Context, idUser int, serviceUser user.Service) error {
+	_, err := serviceUser.GetUserByID(ctx, idUser)
+	if err != nil {
+		return err
 	}
 	return nil
 }
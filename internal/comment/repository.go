@@ -1,139 +1,282 @@
 package comment
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/pagination"
+	"strings"
 	"time"
 )
 
 type Repository interface {
-	CreateCom(com Comment, idPost int) (*Comment, error)
-	GetCom() ([]Comment, error)
-	GetComByID(idCom int) (*Comment, error)
-	GetComByPostID(idPost int) ([]Comment, error)
-	GetComByUserID(idUser int) ([]Comment, error)
-	GetComByDate(date time.Time, idPost int) ([]Comment, error)
-	EditCom(com Comment, idCom int, idPost int) (*Comment, error)
-	DeleteCom(idCom int) error
+	CreateCom(ctx context.Context, com Comment, idPost int) (*Comment, error)
+	GetCom(ctx context.Context, page pagination.Page) ([]Comment, error)
+	GetComByID(ctx context.Context, idCom int) (*Comment, error)
+	GetComByPostID(ctx context.Context, idPost int, page pagination.Page) ([]Comment, error)
+	GetComByUserID(ctx context.Context, idUser int, page pagination.Page) ([]Comment, error)
+	GetComByDate(ctx context.Context, date time.Time, idPost int, page pagination.Page) ([]Comment, error)
+	EditCom(ctx context.Context, com Comment, idCom int, idPost int) (*Comment, error)
+	DeleteCom(ctx context.Context, idCom int, deletedAt time.Time) error
+	GetDeletedCom(ctx context.Context, idCom int) (*Comment, error)
+	RestoreCom(ctx context.Context, idCom int) error
+	HasReplies(ctx context.Context, idCom int) (bool, error)
+	GetThreadLevel(ctx context.Context, idPost int, idParent *int, page pagination.Page) ([]Comment, error)
+	GetReplies(ctx context.Context, idParents []int, limit int) ([]Comment, error)
+	CountReplies(ctx context.Context, idParents []int) (map[int]int, error)
+	PurgeComs(ctx context.Context, before time.Time, restrict bool) (int64, error)
 }
 
 type repository struct {
-	db *sql.DB
+	db *dialect.DB
 }
 
-func (r *repository) CreateCom(com Comment, idPost int) (*Comment, error) {
-	res, err := r.db.Exec(`INSERT INTO Comment (IDPost, IDUser, DateComment, Content)
-VALUES (?, ?, ?, ?)`, idPost, com.IDUser, com.DateComment, com.Content)
+const selectCom = "SELECT ID, IDPost, IDUser, DateComment, Content, IDParent FROM Comment"
+
+// replyThread selects a comment and every reply below it.
+const replyThread = `WITH RECURSIVE thread (ID) AS (
+		SELECT ID FROM Comment WHERE ID = ?
+		UNION SELECT Comment.ID FROM Comment INNER JOIN thread ON Comment.IDParent = thread.ID)
+	SELECT ID FROM thread`
+
+func (r *repository) CreateCom(ctx context.Context, com Comment, idPost int) (*Comment, error) {
+	ctx = dialect.Operation(ctx, "comment", "CreateCom")
+	var newCom *Comment
+	err := r.db.Transact(ctx, func(ctx context.Context) error {
+		idCom, err := r.db.InsertContext(ctx, `INSERT INTO Comment (IDPost, IDUser, DateComment, Content, IDParent)
+VALUES (?, ?, ?, ?, ?)`, idPost, com.IDUser, com.DateComment, com.Content, com.IDParent)
+		if err != nil {
+			return err
+		}
+		newCom, err = r.GetComByID(ctx, int(idCom))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return newCom, nil
+}
 
-	idCom, err := res.LastInsertId()
-	newCom, err := r.GetComByID(int(idCom))
+func (r *repository) GetCom(ctx context.Context, page pagination.Page) ([]Comment, error) {
+	ctx = dialect.Operation(ctx, "comment", "GetCom")
+	rows, err := r.db.QueryContext(ctx, selectCom+" WHERE ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?", page.After, page.Fetch())
 	if err != nil {
 		return nil, err
 	}
-	return newCom, nil
+	return scanComs(rows)
 }
 
-func (r *repository) GetCom() ([]Comment, error) {
-	comments, err := r.db.Query("SELECT * FROM Comment")
+func (r *repository) GetComByID(ctx context.Context, idCom int) (*Comment, error) {
+	ctx = dialect.Operation(ctx, "comment", "GetComByID")
+	var com Comment
+	err := r.db.QueryRowContext(ctx, selectCom+" WHERE ID = ? AND DeletedAt IS NULL", idCom).Scan(
+		&com.ID,
+		&com.IDPost,
+		&com.IDUser,
+		&com.DateComment,
+		&com.Content,
+		&com.IDParent,
+	)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("the comment is not in database")
+	}
 	if err != nil {
 		return nil, err
 	}
-	var listCom []Comment
-	for comments.Next() {
-		var com Comment
-		err := comments.Scan(
-			&com.ID,
-			&com.IDPost,
-			&com.IDUser,
-			&com.DateComment,
-			&com.Content,
-		)
-		if err != nil {
-			return nil, err
-		}
-		listCom = append(listCom, com)
+	return &com, nil
+}
+
+func (r *repository) GetComByPostID(ctx context.Context, idPost int, page pagination.Page) ([]Comment, error) {
+	ctx = dialect.Operation(ctx, "comment", "GetComByPostID")
+	rows, err := r.db.QueryContext(ctx, selectCom+" WHERE IDPost = ? AND ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?", idPost, page.After, page.Fetch())
+	if err != nil {
+		return nil, err
 	}
-	return listCom, nil
+	return scanComs(rows)
 }
 
-func (r *repository) GetComByID(idCom int) (*Comment, error) {
-	rows, err := r.db.Query("SELECT * FROM Comment WHERE ID = ?", idCom)
+func (r *repository) GetComByUserID(ctx context.Context, idUser int, page pagination.Page) ([]Comment, error) {
+	ctx = dialect.Operation(ctx, "comment", "GetComByUserID")
+	rows, err := r.db.QueryContext(ctx, selectCom+" WHERE IDUser = ? AND ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?", idUser, page.After, page.Fetch())
 	if err != nil {
 		return nil, err
 	}
-	var com Comment
-	for rows.Next() {
-		err := rows.Scan(
-			&com.ID,
-			&com.IDPost,
-			&com.IDUser,
-			&com.DateComment,
-			&com.Content,
-		)
+	return scanComs(rows)
+}
+
+func (r *repository) GetComByDate(ctx context.Context, date time.Time, idPost int, page pagination.Page) ([]Comment, error) {
+	ctx = dialect.Operation(ctx, "comment", "GetComByDate")
+	rows, err := r.db.QueryContext(ctx, selectCom+` WHERE `+r.db.Date("DateComment")+` = ? AND IDPost = ? AND ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?`,
+		date.Format(time.DateOnly), idPost, page.After, page.Fetch())
+	if err != nil {
+		return nil, err
+	}
+	return scanComs(rows)
+}
+
+func (r *repository) EditCom(ctx context.Context, com Comment, idCom int, idPost int) (*Comment, error) {
+	ctx = dialect.Operation(ctx, "comment", "EditCom")
+	var editedCom *Comment
+	err := r.db.Transact(ctx, func(ctx context.Context) error {
+		_, err := r.db.ExecContext(ctx, `UPDATE Comment SET IDPost = ?, IDUser = ? , DateComment = ?, Content = ?
+WHERE ID = ?`, idPost, com.IDUser, com.DateComment, com.Content, idCom)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		return &com, nil
+		editedCom, err = r.GetComByID(ctx, idCom)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil
+	return editedCom, nil
 }
 
-func (r *repository) GetComByPostID(idPost int) ([]Comment, error) {
-	rows, err := r.db.Query("SELECT * FROM Comment WHERE IDPost = ?", idPost)
+// DeleteCom hides the comment and its replies, they share deletedAt so RestoreCom brings back exactly them.
+func (r *repository) DeleteCom(ctx context.Context, idCom int, deletedAt time.Time) error {
+	ctx = dialect.Operation(ctx, "comment", "DeleteCom")
+	return r.db.Transact(ctx, func(ctx context.Context) error {
+		res, err := r.db.ExecContext(ctx, "UPDATE Comment SET DeletedAt = ? WHERE ID = ? AND DeletedAt IS NULL", deletedAt, idCom)
+		if err != nil {
+			return err
+		}
+		deleted, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return apperror.NotFound("the comment is not in database")
+		}
+		_, err = r.db.ExecContext(ctx, "UPDATE Comment SET DeletedAt = ? WHERE DeletedAt IS NULL AND ID IN ("+replyThread+")", deletedAt, idCom)
+		return err
+	})
+}
+
+func (r *repository) GetDeletedCom(ctx context.Context, idCom int) (*Comment, error) {
+	ctx = dialect.Operation(ctx, "comment", "GetDeletedCom")
+	var com Comment
+	var deletedAt time.Time
+	err := r.db.QueryRowContext(ctx, "SELECT ID, IDPost, IDUser, DateComment, Content, IDParent, DeletedAt FROM Comment WHERE ID = ? AND DeletedAt IS NOT NULL", idCom).Scan(
+		&com.ID,
+		&com.IDPost,
+		&com.IDUser,
+		&com.DateComment,
+		&com.Content,
+		&com.IDParent,
+		&deletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("the deleted comment is not in database")
+	}
 	if err != nil {
 		return nil, err
 	}
-	var listCom []Comment
-	for rows.Next() {
-		var com Comment
-		err := rows.Scan(
-			&com.ID,
-			&com.IDPost,
-			&com.IDUser,
-			&com.DateComment,
-			&com.Content,
-		)
+	com.DeletedAt = &deletedAt
+	return &com, nil
+}
+
+func (r *repository) RestoreCom(ctx context.Context, idCom int) error {
+	ctx = dialect.Operation(ctx, "comment", "RestoreCom")
+	return r.db.Transact(ctx, func(ctx context.Context) error {
+		// the replies go first, the comment keeps the deletedAt they are matched against until the end
+		_, err := r.db.ExecContext(ctx, `UPDATE Comment SET DeletedAt = NULL
+			WHERE ID <> ? AND DeletedAt = (SELECT DeletedAt FROM Comment WHERE ID = ?) AND ID IN (`+replyThread+")", idCom, idCom, idCom)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		listCom = append(listCom, com)
+		res, err := r.db.ExecContext(ctx, "UPDATE Comment SET DeletedAt = NULL WHERE ID = ? AND DeletedAt IS NOT NULL", idCom)
+		if err != nil {
+			return err
+		}
+		restored, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if restored == 0 {
+			return apperror.NotFound("the deleted comment is not in database")
+		}
+		return nil
+	})
+}
+
+// HasReplies tells whether the comment still has replies that are not deleted.
+func (r *repository) HasReplies(ctx context.Context, idCom int) (bool, error) {
+	ctx = dialect.Operation(ctx, "comment", "HasReplies")
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM Comment WHERE IDParent = ? AND DeletedAt IS NULL", idCom).Scan(&count)
+	if err != nil {
+		return false, err
 	}
-	return listCom, nil
+	return count > 0, nil
 }
 
-func (r *repository) GetComByUserID(idUser int) ([]Comment, error) {
-	rows, err := r.db.Query("SELECT * FROM Comment WHERE IDUser = ?", idUser)
+// GetThreadLevel pages the top level comments of the post, or the direct replies of idParent.
+func (r *repository) GetThreadLevel(ctx context.Context, idPost int, idParent *int, page pagination.Page) ([]Comment, error) {
+	ctx = dialect.Operation(ctx, "comment", "GetThreadLevel")
+	parent, args := "IDParent IS NULL", []any{idPost}
+	if idParent != nil {
+		parent, args = "IDParent = ?", append(args, *idParent)
+	}
+	rows, err := r.db.QueryContext(ctx, selectCom+" WHERE IDPost = ? AND "+parent+" AND ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?",
+		append(args, page.After, page.Fetch())...)
 	if err != nil {
 		return nil, err
 	}
-	var listCom []Comment
+	return scanComs(rows)
+}
+
+// GetReplies reads the first limit direct replies of every parent, ordered by parent and then by ID.
+func (r *repository) GetReplies(ctx context.Context, idParents []int, limit int) ([]Comment, error) {
+	ctx = dialect.Operation(ctx, "comment", "GetReplies")
+	if len(idParents) == 0 {
+		return nil, nil
+	}
+	args := idArgs(idParents)
+	rows, err := r.db.QueryContext(ctx, `SELECT ID, IDPost, IDUser, DateComment, Content, IDParent FROM (
+		SELECT ID, IDPost, IDUser, DateComment, Content, IDParent, ROW_NUMBER() OVER (PARTITION BY IDParent ORDER BY ID) AS Position
+		FROM Comment WHERE IDParent IN (?`+strings.Repeat(", ?", len(idParents)-1)+`) AND DeletedAt IS NULL) AS Replies
+		WHERE Position <= ? ORDER BY IDParent, ID`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanComs(rows)
+}
+
+// CountReplies returns how many direct replies every parent has, parents without replies are left out.
+func (r *repository) CountReplies(ctx context.Context, idParents []int) (map[int]int, error) {
+	ctx = dialect.Operation(ctx, "comment", "CountReplies")
+	counts := make(map[int]int, len(idParents))
+	if len(idParents) == 0 {
+		return counts, nil
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT IDParent, COUNT(*) FROM Comment WHERE IDParent IN (?`+
+		strings.Repeat(", ?", len(idParents)-1)+`) AND DeletedAt IS NULL GROUP BY IDParent`, idArgs(idParents)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 	for rows.Next() {
-		var com Comment
-		err := rows.Scan(
-			&com.ID,
-			&com.IDPost,
-			&com.IDUser,
-			&com.DateComment,
-			&com.Content,
-		)
+		var idParent, count int
+		err := rows.Scan(&idParent, &count)
 		if err != nil {
 			return nil, err
 		}
-		listCom = append(listCom, com)
+		counts[idParent] = count
 	}
-	return listCom, nil
+	return counts, rows.Err()
 }
 
-func (r *repository) GetComByDate(date time.Time, idPost int) ([]Comment, error) {
-	dateFormat := date.Format("2006-01-02")
-	log.Println(dateFormat)
-	rows, err := r.db.Query(`SELECT * FROM Comment WHERE strftime('%Y-%m-%d', DateComment) = ? AND IDPost = ?`, date.Format("2006-01-02"), idPost)
-	if err != nil {
-		return nil, err
+func idArgs(ids []int) []any {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
 	}
+	return args
+}
+
+func scanComs(rows *sql.Rows) ([]Comment, error) {
+	defer rows.Close()
 	var listCom []Comment
 	for rows.Next() {
 		var com Comment
@@ -143,40 +286,39 @@ func (r *repository) GetComByDate(date time.Time, idPost int) ([]Comment, error)
 			&com.IDUser,
 			&com.DateComment,
 			&com.Content,
+			&com.IDParent,
 		)
 		if err != nil {
 			return nil, err
 		}
 		listCom = append(listCom, com)
 	}
-	return listCom, nil
+	return listCom, rows.Err()
 }
-func (r *repository) EditCom(com Comment, idCom int, idPost int) (*Comment, error) {
-	_, err := r.db.Exec(`UPDATE Comment SET IDPost = ?, IDUser = ? , DateComment = ?, Content = ?
-WHERE ID = ?`, idPost, com.IDUser, com.DateComment, com.Content, idCom)
-	if err != nil {
-		return nil, err
-	}
 
-	editedCom, err := r.GetComByID(idCom)
-	if err != nil {
-		return nil, err
+// PurgeComs deletes for good the comments deleted before the cutoff, with their replies and reactions.
+// With restrict the comments that still have replies, deleted ones included, are kept for a later purge.
+func (r *repository) PurgeComs(ctx context.Context, before time.Time, restrict bool) (int64, error) {
+	ctx = dialect.Operation(ctx, "comment", "PurgeComs")
+	query := "DELETE FROM Comment WHERE DeletedAt < ?"
+	if restrict {
+		query += " AND NOT EXISTS (SELECT 1 FROM Comment AS Reply WHERE Reply.IDParent = Comment.ID)"
 	}
-
-	return editedCom, nil
-}
-func (r *repository) DeleteCom(idCom int) error {
-	_, err := r.db.Exec("PRAGMA foreign_keys = ON")
-	if err != nil {
+	var purged int64
+	err := r.db.Transact(ctx, func(ctx context.Context) error {
+		res, err := r.db.ExecContext(ctx, query, before)
+		if err != nil {
+			return err
+		}
+		purged, err = res.RowsAffected()
 		return err
-	}
-	_, err = r.db.Exec("DELETE FROM Comment WHERE ID = ?", idCom)
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	return purged, nil
 }
 
-func NewRepository(db *sql.DB) Repository {
+func NewRepository(db *dialect.DB) Repository {
 	return &repository{db}
 }
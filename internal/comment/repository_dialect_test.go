@@ -0,0 +1,132 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/dialect/dialecttest"
+	"socialBuddy/internal/pagination"
+	"testing"
+	"time"
+)
+
+func TestRepositoryDialects(t *testing.T) {
+	dialecttest.Run(t, func(t *testing.T, db *dialect.DB) {
+		rep := NewRepository(db)
+		idUser, err := db.Insert("INSERT INTO Users (Name, Email) VALUES (?, ?)", "Name First", "first@gmail.com")
+		if err != nil {
+			t.Fatalf("the creation of user is failed %v", err)
+		}
+		idPost, err := db.Insert("INSERT INTO Posts (IDUser, DatePost, Title, Content) VALUES (?, ?, ?, ?)",
+			idUser, time.Now(), "title1", "content1")
+		if err != nil {
+			t.Fatalf("the creation of post is failed %v", err)
+		}
+		date := time.Date(2023, 11, 13, 10, 30, 0, 0, time.UTC)
+
+		parent, err := rep.CreateCom(context.Background(), Comment{IDUser: int(idUser), DateComment: date, Content: "content1"}, int(idPost))
+		if err != nil || parent.IDParent != nil {
+			t.Fatalf("unexpected comment %+v, err %v", parent, err)
+		}
+		reply, err := rep.CreateCom(context.Background(), Comment{IDUser: int(idUser), IDParent: &parent.ID, DateComment: date, Content: "content2"}, int(idPost))
+		if err != nil || reply.IDParent == nil || *reply.IDParent != parent.ID {
+			t.Fatalf("unexpected reply %+v, err %v", reply, err)
+		}
+		byDate, err := rep.GetComByDate(context.Background(), date, int(idPost), pagination.Page{Limit: 10})
+		if err != nil || len(byDate) != 2 {
+			t.Fatalf("unexpected comments by date %+v, err %v", byDate, err)
+		}
+
+		hasReplies, err := rep.HasReplies(context.Background(), parent.ID)
+		if err != nil || !hasReplies {
+			t.Fatalf("expected the comment to have replies, err %v", err)
+		}
+		deletedAt := time.Now().UTC()
+		err = rep.DeleteCom(context.Background(), parent.ID, deletedAt)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		_, err = rep.GetComByID(context.Background(), reply.ID)
+		if !errors.Is(err, apperror.ErrNotFound) {
+			t.Fatalf("expected the reply to be deleted with its parent, got %v", err)
+		}
+		deleted, err := rep.GetDeletedCom(context.Background(), parent.ID)
+		if err != nil || deleted.DeletedAt == nil {
+			t.Fatalf("unexpected deleted comment %+v, err %v", deleted, err)
+		}
+
+		err = rep.RestoreCom(context.Background(), parent.ID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		_, err = rep.GetComByID(context.Background(), reply.ID)
+		if err != nil {
+			t.Fatalf("expected the reply to be restored with its parent, got %v", err)
+		}
+
+		err = rep.DeleteCom(context.Background(), parent.ID, deletedAt)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		purged, err := rep.PurgeComs(context.Background(), deletedAt.Add(time.Minute), true)
+		if err != nil || purged != 1 {
+			t.Fatalf("expected only the reply to be purged under restrict, got %d, err %v", purged, err)
+		}
+		_, err = rep.GetDeletedCom(context.Background(), parent.ID)
+		if err != nil {
+			t.Fatalf("expected the parent to be kept until its reply was purged, got %v", err)
+		}
+		purged, err = rep.PurgeComs(context.Background(), deletedAt.Add(time.Minute), false)
+		if err != nil || purged == 0 {
+			t.Fatalf("expected the comment to be purged, got %d, err %v", purged, err)
+		}
+		var rows int
+		err = db.QueryRow("SELECT COUNT(*) FROM Comment").Scan(&rows)
+		if err != nil || rows != 0 {
+			t.Fatalf("expected the reply to be purged with its parent, got %d, err %v", rows, err)
+		}
+	})
+}
+
+func TestThreadsDialects(t *testing.T) {
+	dialecttest.Run(t, func(t *testing.T, db *dialect.DB) {
+		rep := NewRepository(db)
+		idUser, err := db.Insert("INSERT INTO Users (Name, Email) VALUES (?, ?)", "Name First", "first@gmail.com")
+		if err != nil {
+			t.Fatalf("the creation of user is failed %v", err)
+		}
+		idPost, err := db.Insert("INSERT INTO Posts (IDUser, DatePost, Title, Content) VALUES (?, ?, ?, ?)",
+			idUser, time.Now(), "title1", "content1")
+		if err != nil {
+			t.Fatalf("the creation of post is failed %v", err)
+		}
+		create := func(idParent *int) int {
+			com, err := rep.CreateCom(context.Background(), Comment{IDUser: int(idUser), IDParent: idParent, DateComment: time.Now(), Content: "content"}, int(idPost))
+			if err != nil {
+				t.Fatalf("the creation of comment is failed %v", err)
+			}
+			return com.ID
+		}
+		first, second := create(nil), create(nil)
+		replies := []int{create(&first), create(&first), create(&first), create(&second)}
+		create(&replies[0])
+
+		roots, err := rep.GetThreadLevel(context.Background(), int(idPost), nil, pagination.Page{Limit: 1})
+		if err != nil || len(roots) != 2 || roots[0].ID != first || roots[1].ID != second {
+			t.Fatalf("unexpected top level comments %+v, err %v", roots, err)
+		}
+		level, err := rep.GetThreadLevel(context.Background(), int(idPost), &first, pagination.Page{Limit: 10, After: replies[0]})
+		if err != nil || len(level) != 2 || level[0].ID != replies[1] {
+			t.Fatalf("unexpected replies %+v, err %v", level, err)
+		}
+		page, err := rep.GetReplies(context.Background(), []int{first, second}, 2)
+		if err != nil || len(page) != 3 || page[0].ID != replies[0] || page[1].ID != replies[1] || page[2].ID != replies[3] {
+			t.Fatalf("unexpected first replies %+v, err %v", page, err)
+		}
+		counts, err := rep.CountReplies(context.Background(), []int{first, second, replies[0], replies[1]})
+		if err != nil || counts[first] != 3 || counts[second] != 1 || counts[replies[0]] != 1 || counts[replies[1]] != 0 {
+			t.Fatalf("unexpected reply counts %v, err %v", counts, err)
+		}
+	})
+}
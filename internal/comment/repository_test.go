@@ -1,12 +1,16 @@
 package comment
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"log"
 	"reflect"
 	"regexp"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/pagination"
 	"testing"
 	"time"
 )
@@ -71,12 +75,14 @@ func TestCreateCom(t *testing.T) {
 		_ = mockDB.Close()
 	}(mockDB)
 	customDate := time.Now().In(time.Local)
-	rep := NewRepository(mockDB)
-	mock.ExpectExec("INSERT INTO Comment").WithArgs(2, 1, customDate, "content1").WillReturnResult(sqlmock.NewResult(1, 1))
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO Comment").WithArgs(2, 1, customDate, "content1", nil).WillReturnResult(sqlmock.NewResult(1, 1))
 	result := sqlmock.NewRows([]string{
-		"ID", "IDPost", "IDUser", "DateComment", "Content",
-	}).AddRow(1, 2, 1, customDate, "content1")
-	mock.ExpectQuery("SELECT \\* FROM Comment WHERE ID = ?").WithArgs(1).WillReturnRows(result)
+		"ID", "IDPost", "IDUser", "DateComment", "Content", "IDParent",
+	}).AddRow(1, 2, 1, customDate, "content1", nil)
+	mock.ExpectQuery("SELECT (.+) FROM Comment WHERE ID = ?").WithArgs(1).WillReturnRows(result)
+	mock.ExpectCommit()
 	test := []argCreate{
 		{
 			name:   "CreateCom() is succeed",
@@ -113,7 +119,7 @@ func TestCreateCom(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			comments, err := rep.CreateCom(tt.newCom, tt.idPost)
+			comments, err := rep.CreateCom(context.Background(), tt.newCom, tt.idPost)
 			log.Printf("comments: %+v, err: %+v", comments, err)
 			if !reflect.DeepEqual(comments, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, comments)
@@ -134,13 +140,13 @@ func TestGetCom(t *testing.T) {
 		_ = mockDB.Close()
 
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
 
 	result := sqlmock.NewRows([]string{
-		"ID", "IDPost", "IDUser", "DateComment", "Content",
-	}).AddRow(1, 2, 1, timeNow, "content1")
-	mock.ExpectQuery("SELECT \\* FROM Comment").WillReturnRows(result)
+		"ID", "IDPost", "IDUser", "DateComment", "Content", "IDParent",
+	}).AddRow(1, 2, 1, timeNow, "content1", nil)
+	mock.ExpectQuery("SELECT (.+) FROM Comment WHERE ID > \\? AND DeletedAt IS NULL ORDER BY ID LIMIT \\?").WithArgs(0, 11).WillReturnRows(result)
 	test := []argGet{
 		{
 			name: "GetComments() is succeed",
@@ -163,7 +169,7 @@ func TestGetCom(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			comments, err := rep.GetCom()
+			comments, err := rep.GetCom(context.Background(), pagination.Page{Limit: 10})
 			log.Printf("comments: %+v, err: %+v", comments, err)
 			if !reflect.DeepEqual(comments, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, comments)
@@ -183,13 +189,13 @@ func TestGetComByID(t *testing.T) {
 		_ = mockDB.Close()
 
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
 
 	result := sqlmock.NewRows([]string{
-		"ID", "IDPost", "IDUser", "DateComment", "Content",
-	}).AddRow(1, 2, 1, timeNow, "content1")
-	mock.ExpectQuery("SELECT \\* FROM Comment WHERE ID = ?").WithArgs(1).WillReturnRows(result)
+		"ID", "IDPost", "IDUser", "DateComment", "Content", "IDParent",
+	}).AddRow(1, 2, 1, timeNow, "content1", nil)
+	mock.ExpectQuery("SELECT (.+) FROM Comment WHERE ID = ?").WithArgs(1).WillReturnRows(result)
 	test := []argID{
 		{
 			name: "GetCommentByID() is succeed",
@@ -213,7 +219,7 @@ func TestGetComByID(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			comment, err := rep.GetComByID(tt.id)
+			comment, err := rep.GetComByID(context.Background(), tt.id)
 			log.Printf("comments: %+v, err: %+v", comment, err)
 			if !reflect.DeepEqual(comment, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, comment)
@@ -234,13 +240,13 @@ func TestGetComByPostID(t *testing.T) {
 		_ = mockDB.Close()
 
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
 
 	result := sqlmock.NewRows([]string{
-		"ID", "IDPost", "IDUser", "DateComment", "Content",
-	}).AddRow(1, 2, 1, timeNow, "content1")
-	mock.ExpectQuery("SELECT \\* FROM Comment WHERE IDPost = ?").WithArgs(2).WillReturnRows(result)
+		"ID", "IDPost", "IDUser", "DateComment", "Content", "IDParent",
+	}).AddRow(1, 2, 1, timeNow, "content1", nil)
+	mock.ExpectQuery("SELECT (.+) FROM Comment WHERE IDPost = \\? AND ID > \\? AND DeletedAt IS NULL ORDER BY ID LIMIT \\?").WithArgs(2, 0, 11).WillReturnRows(result)
 	test := []argIDList{
 		{
 			name: "GetComByPostID() is succeed",
@@ -265,7 +271,7 @@ func TestGetComByPostID(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			comments, err := rep.GetComByPostID(tt.id)
+			comments, err := rep.GetComByPostID(context.Background(), tt.id, pagination.Page{Limit: 10})
 			log.Printf("comments: %+v, err: %+v", comments, err)
 			if !reflect.DeepEqual(comments, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, comments)
@@ -286,13 +292,13 @@ func TestComByUserID(t *testing.T) {
 		_ = mockDB.Close()
 
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
 
 	result := sqlmock.NewRows([]string{
-		"ID", "IDPost", "IDUser", "DateComment", "Content",
-	}).AddRow(1, 2, 1, timeNow, "content1")
-	mock.ExpectQuery("SELECT \\* FROM Comment WHERE IDUser = ?").WithArgs(1).WillReturnRows(result)
+		"ID", "IDPost", "IDUser", "DateComment", "Content", "IDParent",
+	}).AddRow(1, 2, 1, timeNow, "content1", nil)
+	mock.ExpectQuery("SELECT (.+) FROM Comment WHERE IDUser = \\? AND ID > \\? AND DeletedAt IS NULL ORDER BY ID LIMIT \\?").WithArgs(1, 0, 11).WillReturnRows(result)
 	test := []argIDList{
 		{
 			name: "GetComByUserID() is succeed",
@@ -317,7 +323,7 @@ func TestComByUserID(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			comments, err := rep.GetComByUserID(tt.id)
+			comments, err := rep.GetComByUserID(context.Background(), tt.id, pagination.Page{Limit: 10})
 			log.Printf("comments: %+v, err: %+v", comments, err)
 			if !reflect.DeepEqual(comments, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, comments)
@@ -338,11 +344,11 @@ func TestGetComByDate(t *testing.T) {
 
 	}(mockDB)
 	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	result := sqlmock.NewRows([]string{
-		"ID", "IDPost", "IDUser", "DateComment", "Content",
-	}).AddRow(1, 2, 1, timeNow, "content1")
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Comment WHERE strftime('%Y-%m-%d', DateComment) = ? AND IDPost = ?")).WithArgs(time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local).Format("2006-01-02"), 2).WillReturnRows(result)
+		"ID", "IDPost", "IDUser", "DateComment", "Content", "IDParent",
+	}).AddRow(1, 2, 1, timeNow, "content1", nil)
+	mock.ExpectQuery("SELECT (.+) FROM Comment "+regexp.QuoteMeta("WHERE strftime('%Y-%m-%d', DateComment) = ? AND IDPost = ? AND ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?")).WithArgs(time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local).Format("2006-01-02"), 2, 0, 11).WillReturnRows(result)
 
 	tests := []argDate{
 		{name: "GetComByDate() is succeed",
@@ -369,7 +375,7 @@ func TestGetComByDate(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			comments, err := rep.GetComByDate(tt.date, tt.idPost)
+			comments, err := rep.GetComByDate(context.Background(), tt.date, tt.idPost, pagination.Page{Limit: 10})
 			log.Printf("comments: %+v, err: %+v", comments, err)
 			if !reflect.DeepEqual(comments, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, comments)
@@ -390,12 +396,14 @@ func TestEditCom(t *testing.T) {
 		_ = mockDB.Close()
 	}(mockDB)
 	customDate := time.Now().In(time.Local)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
 	mock.ExpectExec("UPDATE Comment SET IDPost = ?, IDUser = ? , DateComment = ?, Content = ? WHERE ID = ?").WithArgs(2, 1, customDate, "content1", 1).WillReturnResult(sqlmock.NewResult(1, 1))
 	result := sqlmock.NewRows([]string{
-		"ID", "IDPost", "IDUser", "DateComment", "Content",
-	}).AddRow(1, 2, 1, customDate, "content1")
-	mock.ExpectQuery("SELECT * FROM Comment WHERE ID = ?").WithArgs(1).WillReturnRows(result)
+		"ID", "IDPost", "IDUser", "DateComment", "Content", "IDParent",
+	}).AddRow(1, 2, 1, customDate, "content1", nil)
+	mock.ExpectQuery("SELECT ID, IDPost, IDUser, DateComment, Content, IDParent FROM Comment WHERE ID = ? AND DeletedAt IS NULL").WithArgs(1).WillReturnRows(result)
+	mock.ExpectCommit()
 	test := []argEdit{
 		{
 			name:   "EditComment() is succeed",
@@ -434,7 +442,7 @@ func TestEditCom(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			comment, err := rep.EditCom(tt.editedCom, tt.id, tt.idPost)
+			comment, err := rep.EditCom(context.Background(), tt.editedCom, tt.id, tt.idPost)
 			log.Printf("comments: %+v, err: %+v", comment, err)
 			if !reflect.DeepEqual(comment, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, comment)
@@ -455,9 +463,11 @@ func TestDeleteCom(t *testing.T) {
 		_ = mockDB.Close()
 
 	}(mockDB)
-	rep := NewRepository(mockDB)
-	mock.ExpectExec("PRAGMA foreign_keys = ON").WithoutArgs().WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectExec("DELETE FROM Comment WHERE ID = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE Comment SET DeletedAt = \\? WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE Comment SET DeletedAt = \\? WHERE DeletedAt IS NULL AND ID IN").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	test := []argDelete{
 		{
@@ -473,7 +483,7 @@ func TestDeleteCom(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			err := rep.DeleteCom(tt.id)
+			err := rep.DeleteCom(context.Background(), tt.id, time.Now().UTC())
 			log.Printf("err:%v", err)
 			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
 				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
@@ -481,3 +491,48 @@ func TestDeleteCom(t *testing.T) {
 		})
 	}
 }
+
+func TestGetComByIDNotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectQuery("SELECT (.+) FROM Comment WHERE ID = ?").WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{
+		"ID", "IDPost", "IDUser", "DateComment", "Content", "IDParent",
+	}))
+	comment, err := rep.GetComByID(context.Background(), 3)
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("expected not found error, got %+v", err)
+	}
+	if comment != nil {
+		t.Fatalf("expected no comment, got %+v", comment)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestDeleteComNotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE Comment SET DeletedAt = \\? WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(sqlmock.AnyArg(), 3).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = rep.DeleteCom(context.Background(), 3, time.Now().UTC())
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("expected not found error, got %+v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %v", err)
+	}
+}
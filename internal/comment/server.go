@@ -3,7 +3,11 @@ package comment
 import (
 	"encoding/json"
 	"github.com/go-chi/chi/v5"
+	"io"
 	"net/http"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/pagination"
 	"strconv"
 	"time"
 )
@@ -13,73 +17,83 @@ type Server struct {
 }
 
 func (s *Server) CreateCom(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
 	var newCom Comment
 	err := json.NewDecoder(r.Body).Decode(&newCom)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	err = r.Body.Close()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	postId := chi.URLParam(r, "id_post")
 	id, err := strconv.Atoi(postId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 
-	comment, err := s.comService.CreateCom(newCom, id)
+	comment, err := s.comService.CreateCom(r.Context(), newCom, id, identity.ID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	err = json.NewEncoder(w).Encode(comment)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 
 }
 
-func (s *Server) GetCom(w http.ResponseWriter, _ *http.Request) {
-	comment, err := s.comService.GetCom()
+func (s *Server) GetCom(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	comment, err := s.comService.GetCom(r.Context(), page)
+	if err != nil {
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(comment)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(comment, page, commentID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
 
 func (s *Server) GetComByID(w http.ResponseWriter, r *http.Request) {
 	postId := chi.URLParam(r, "id_post")
-	_, err := strconv.Atoi(postId)
+	idPost, err := strconv.Atoi(postId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	commentId := chi.URLParam(r, "id")
 	idCom, err := strconv.Atoi(commentId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	comment, err := s.comService.GetComByID(idCom)
+	comment, err := s.comService.GetComByID(r.Context(), idCom, idPost)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(comment)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
@@ -88,17 +102,65 @@ func (s *Server) GetComByPostID(w http.ResponseWriter, r *http.Request) {
 	postID := chi.URLParam(r, "id_post")
 	id, err := strconv.Atoi(postID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	comment, err := s.comService.GetComByPostID(id)
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	comment, err := s.comService.GetComByPostID(r.Context(), id, page)
+	if err != nil {
+		apperror.Write(w, err)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(comment)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(comment, page, commentID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func (s *Server) GetThreads(w http.ResponseWriter, r *http.Request) {
+	postID := chi.URLParam(r, "id_post")
+	id, err := strconv.Atoi(postID)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	depth := DefaultDepth
+	if value := r.URL.Query().Get("depth"); value != "" {
+		depth, err = strconv.Atoi(value)
+		if err != nil {
+			apperror.Write(w, apperror.Validation(err.Error()))
+			return
+		}
+	}
+	var idParent *int
+	if value := r.URL.Query().Get("parent"); value != "" {
+		parent, err := strconv.Atoi(value)
+		if err != nil {
+			apperror.Write(w, apperror.Validation(err.Error()))
+			return
+		}
+		idParent = &parent
+	}
+	threads, err := s.comService.GetThreads(r.Context(), id, idParent, depth, page)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(threads)
+	if err != nil {
+		apperror.Write(w, err)
 		return
 	}
 }
@@ -107,17 +169,23 @@ func (s *Server) GetComByUserID(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id_user")
 	id, err := strconv.Atoi(userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	comment, err := s.comService.GetComByUserID(id)
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	comment, err := s.comService.GetComByUserID(r.Context(), id, page)
+	if err != nil {
+		apperror.Write(w, err)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(comment)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(comment, page, commentID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
@@ -126,89 +194,180 @@ func (s *Server) GetComByDate(w http.ResponseWriter, r *http.Request) {
 	postId := chi.URLParam(r, "id_post")
 	id, err := strconv.Atoi(postId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	commentDate := chi.URLParam(r, "date")
 	date, err := time.Parse("2006-01-02", commentDate)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	comment, err := s.comService.GetComByDate(date, id)
+	comment, err := s.comService.GetComByDate(r.Context(), date, id, page)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(comment)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(comment, page, commentID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
 
 func (s *Server) EditCom(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
 	postId := chi.URLParam(r, "id_post")
 	idPost, err := strconv.Atoi(postId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	commentId := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(commentId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	var editedCom Comment
 	err = json.NewDecoder(r.Body).Decode(&editedCom)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	err = r.Body.Close()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	if editedCom.IDPost != idPost {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+
+	comment, err := s.comService.EditCom(r.Context(), editedCom, id, idPost, identity)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(comment)
+	if err != nil {
+		apperror.Write(w, err)
 		return
 	}
+}
 
-	comment, err := s.comService.EditCom(editedCom, id, idPost)
+func (s *Server) PatchCom(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
+	postId := chi.URLParam(r, "id_post")
+	idPost, err := strconv.Atoi(postId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	commentId := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(commentId)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	err = r.Body.Close()
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	comment, err := s.comService.PatchCom(r.Context(), patch, id, idPost, identity)
+	if err != nil {
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(comment)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
 
 func (s *Server) DeleteCom(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
 	postId := chi.URLParam(r, "id_post")
-	_, err := strconv.Atoi(postId)
+	idPost, err := strconv.Atoi(postId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	commentId := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(commentId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	err = s.comService.DeleteCom(id)
+	err = s.comService.DeleteCom(r.Context(), id, idPost, identity)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *Server) RestoreCom(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
+	postId := chi.URLParam(r, "id_post")
+	idPost, err := strconv.Atoi(postId)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	commentId := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(commentId)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	comment, err := s.comService.RestoreCom(r.Context(), id, idPost, identity)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(comment)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func commentID(comment Comment) int {
+	return comment.ID
+}
+
 func NewServer(comService Service) *Server { return &Server{comService} }
@@ -1,6 +1,14 @@
 package comment
 
 import (
+	"context"
+	"errors"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/deletion"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/mergepatch"
+	"socialBuddy/internal/pagination"
 	"socialBuddy/internal/post"
 	"socialBuddy/internal/user"
 	"time"
@@ -10,93 +18,381 @@ type service struct {
 	ComRepository  Repository
 	PostRepository post.Service
 	UserService    user.Service
+	ComPolicy      auth.Policy
+	Reactions      post.ReactionCounter
+	UnitOfWork     dialect.UnitOfWork
+	Deletion       deletion.Policy
 }
 
 type Service interface {
-	CreateCom(com Comment, idPost int) (*Comment, error)
-	GetCom() ([]Comment, error)
-	GetComByID(idCom int) (*Comment, error)
-	GetComByPostID(idPost int) ([]Comment, error)
-	GetComByUserID(idUser int) ([]Comment, error)
-	GetComByDate(date time.Time, idPost int) ([]Comment, error)
-	EditCom(com Comment, idCom int, idPost int) (*Comment, error)
-	DeleteCom(idCom int) error
+	CreateCom(ctx context.Context, com Comment, idPost int, idUser int) (*Comment, error)
+	GetCom(ctx context.Context, page pagination.Page) ([]Comment, error)
+	GetComByID(ctx context.Context, idCom int, idPost int) (*Comment, error)
+	GetComByPostID(ctx context.Context, idPost int, page pagination.Page) ([]Comment, error)
+	GetComByUserID(ctx context.Context, idUser int, page pagination.Page) ([]Comment, error)
+	GetComByDate(ctx context.Context, date time.Time, idPost int, page pagination.Page) ([]Comment, error)
+	GetThreads(ctx context.Context, idPost int, idParent *int, depth int, page pagination.Page) (pagination.Response[Thread], error)
+	EditCom(ctx context.Context, com Comment, idCom int, idPost int, actor auth.Identity) (*Comment, error)
+	PatchCom(ctx context.Context, patch []byte, idCom int, idPost int, actor auth.Identity) (*Comment, error)
+	DeleteCom(ctx context.Context, idCom int, idPost int, actor auth.Identity) error
+	RestoreCom(ctx context.Context, idCom int, idPost int, actor auth.Identity) (*Comment, error)
 }
 
-func (s *service) CreateCom(com Comment, idPost int) (*Comment, error) {
-	err := ValidateIDPost(idPost, s.PostRepository)
+func (s *service) CreateCom(ctx context.Context, com Comment, idPost int, idUser int) (*Comment, error) {
+	com.IDUser = idUser
+	err := contentValidation(com.Content)
 	if err != nil {
 		return nil, err
 	}
+	com.DateComment = time.Now()
+	var newCom *Comment
+	err = s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		err := ValidateIDPost(ctx, idPost, s.PostRepository)
+		if err != nil {
+			return err
+		}
+		err = ValidateIDUser(ctx, com.IDUser, s.UserService)
+		if err != nil {
+			return err
+		}
+		if com.IDParent != nil {
+			parent, err := s.ComRepository.GetComByID(ctx, *com.IDParent)
+			if err != nil {
+				return err
+			}
+			if parent.IDPost != idPost {
+				return apperror.Validation("the parent comment is not in the post")
+			}
+		}
+		newCom, err = s.ComRepository.CreateCom(ctx, com, idPost)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// as in GetComByID, a comment without reactions shows an empty map
+	if s.Reactions != nil {
+		newCom.Reactions = map[string]int{}
+	}
+	return newCom, nil
+}
 
-	err = ValidateIDUser(com.IDUser, s.UserService)
+func (s *service) GetCom(ctx context.Context, page pagination.Page) ([]Comment, error) {
+	comments, err := s.ComRepository.GetCom(ctx, page)
 	if err != nil {
 		return nil, err
 	}
+	return s.withReactions(ctx, comments)
+}
 
-	com.DateComment = time.Now()
-	newPost, err := s.ComRepository.CreateCom(com, idPost)
+func (s *service) GetComByID(ctx context.Context, idCom int, idPost int) (*Comment, error) {
+	comment, err := s.ComRepository.GetComByID(ctx, idCom)
 	if err != nil {
 		return nil, err
 	}
-	return newPost, nil
+	if comment.IDPost != idPost {
+		return nil, apperror.NotFound("the comment is not in the post")
+	}
+	return s.withComReactions(ctx, comment)
 }
 
-func (s *service) GetCom() ([]Comment, error) {
-	comments, err := s.ComRepository.GetCom()
+func (s *service) GetComByPostID(ctx context.Context, idPost int, page pagination.Page) ([]Comment, error) {
+	comments, err := s.ComRepository.GetComByPostID(ctx, idPost, page)
 	if err != nil {
 		return nil, err
 	}
-	return comments, nil
+	return s.withReactions(ctx, comments)
+}
+func (s *service) GetComByUserID(ctx context.Context, idUser int, page pagination.Page) ([]Comment, error) {
+	comments, err := s.ComRepository.GetComByUserID(ctx, idUser, page)
+	if err != nil {
+		return nil, err
+	}
+	return s.withReactions(ctx, comments)
+}
+
+func (s *service) GetComByDate(ctx context.Context, date time.Time, idPost int, page pagination.Page) ([]Comment, error) {
+	comments, err := s.ComRepository.GetComByDate(ctx, date, idPost, page)
+	if err != nil {
+		return nil, err
+	}
+	return s.withReactions(ctx, comments)
+}
+
+func (s *service) GetThreads(ctx context.Context, idPost int, idParent *int, depth int, page pagination.Page) (pagination.Response[Thread], error) {
+	err := depthValidation(depth)
+	if err != nil {
+		return pagination.Response[Thread]{}, err
+	}
+	err = ValidateIDPost(ctx, idPost, s.PostRepository)
+	if err != nil {
+		return pagination.Response[Thread]{}, err
+	}
+	if idParent != nil {
+		parent, err := s.ComRepository.GetComByID(ctx, *idParent)
+		if err != nil {
+			return pagination.Response[Thread]{}, err
+		}
+		if parent.IDPost != idPost {
+			return pagination.Response[Thread]{}, apperror.NotFound("the comment is not in the post")
+		}
+	}
+	comments, err := s.ComRepository.GetThreadLevel(ctx, idPost, idParent, page)
+	if err != nil {
+		return pagination.Response[Thread]{}, err
+	}
+	threads := pagination.NewResponse(newThreads(comments), page, threadID)
+	err = s.nestReplies(ctx, threads.Data, depth, page.Limit)
+	if err != nil {
+		return pagination.Response[Thread]{}, err
+	}
+	return threads, nil
+}
+
+// nestReplies reads the replies of threads level by level until depth levels are nested, every
+// comment keeps its first limit replies. Only the comments returned have their reactions counted.
+func (s *service) nestReplies(ctx context.Context, threads []Thread, depth int, limit int) error {
+	var nested []*Thread
+	level := make([]*Thread, len(threads))
+	for i := range threads {
+		level[i] = &threads[i]
+	}
+	for ; len(level) > 0; depth-- {
+		nested = append(nested, level...)
+		ids := make([]int, len(level))
+		for i, thread := range level {
+			ids[i] = thread.ID
+		}
+		counts, err := s.ComRepository.CountReplies(ctx, ids)
+		if err != nil {
+			return err
+		}
+		var parents []int
+		for _, thread := range level {
+			thread.ReplyCount = counts[thread.ID]
+			if thread.ReplyCount > 0 {
+				parents = append(parents, thread.ID)
+			}
+		}
+		if depth == 1 || len(parents) == 0 {
+			break
+		}
+		page := pagination.Page{Limit: limit}
+		replies, err := s.ComRepository.GetReplies(ctx, parents, page.Fetch())
+		if err != nil {
+			return err
+		}
+		byParent := make(map[int][]Comment)
+		for _, reply := range replies {
+			byParent[*reply.IDParent] = append(byParent[*reply.IDParent], reply)
+		}
+		var next []*Thread
+		for _, thread := range level {
+			if len(byParent[thread.ID]) == 0 {
+				continue
+			}
+			response := pagination.NewResponse(newThreads(byParent[thread.ID]), page, threadID)
+			thread.Replies = response.Data
+			thread.NextCursor = response.NextCursor
+			for i := range thread.Replies {
+				next = append(next, &thread.Replies[i])
+			}
+		}
+		level = next
+	}
+	comments := make([]Comment, len(nested))
+	for i, thread := range nested {
+		comments[i] = thread.Comment
+	}
+	comments, err := s.withReactions(ctx, comments)
+	if err != nil {
+		return err
+	}
+	for i, thread := range nested {
+		thread.Reactions = comments[i].Reactions
+	}
+	return nil
 }
 
-func (s *service) GetComByID(idCom int) (*Comment, error) {
-	comment, err := s.ComRepository.GetComByID(idCom)
+func (s *service) EditCom(ctx context.Context, com Comment, idCom int, idPost int, actor auth.Identity) (*Comment, error) {
+	var comment *Comment
+	err := s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		storedCom, err := s.authorize(ctx, actor, auth.ActionEdit, idCom)
+		if err != nil {
+			return err
+		}
+		if storedCom.IDPost != idPost {
+			return apperror.NotFound("the comment is not in the post")
+		}
+		comment, err = s.edit(ctx, com, storedCom)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return comment, nil
 }
 
-func (s *service) GetComByPostID(idPost int) ([]Comment, error) {
-	comments, err := s.ComRepository.GetComByPostID(idPost)
+// PatchCom applies a JSON Merge Patch to the stored comment, only the content can change.
+func (s *service) PatchCom(ctx context.Context, patch []byte, idCom int, idPost int, actor auth.Identity) (*Comment, error) {
+	var comment *Comment
+	err := s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		storedCom, err := s.authorize(ctx, actor, auth.ActionEdit, idCom)
+		if err != nil {
+			return err
+		}
+		if storedCom.IDPost != idPost {
+			return apperror.NotFound("the comment is not in the post")
+		}
+		com := *storedCom
+		err = mergepatch.Apply(&com, patch)
+		if err != nil {
+			return err
+		}
+		comment, err = s.edit(ctx, com, storedCom)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	return comments, nil
+	return comment, nil
 }
-func (s *service) GetComByUserID(idUser int) ([]Comment, error) {
-	comments, err := s.ComRepository.GetComByUserID(idUser)
+
+func (s *service) edit(ctx context.Context, com Comment, storedCom *Comment) (*Comment, error) {
+	err := contentValidation(com.Content)
 	if err != nil {
 		return nil, err
 	}
-	return comments, nil
+	com.IDUser = storedCom.IDUser
+	com.IDParent = storedCom.IDParent
+	com.DateComment = time.Now()
+	comment, err := s.ComRepository.EditCom(ctx, com, storedCom.ID, storedCom.IDPost)
+	if err != nil {
+		return nil, err
+	}
+	return s.withComReactions(ctx, comment)
+}
+
+func (s *service) DeleteCom(ctx context.Context, idCom int, idPost int, actor auth.Identity) error {
+	return s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		storedCom, err := s.authorize(ctx, actor, auth.ActionDelete, idCom)
+		if err != nil {
+			return err
+		}
+		if storedCom.IDPost != idPost {
+			return apperror.NotFound("the comment is not in the post")
+		}
+		if s.Deletion.Restricted() {
+			hasReplies, err := s.ComRepository.HasReplies(ctx, idCom)
+			if err != nil {
+				return err
+			}
+			if hasReplies {
+				return apperror.Conflict("the comment still has replies")
+			}
+		}
+		return s.ComRepository.DeleteCom(ctx, idCom, time.Now().UTC())
+	})
 }
 
-func (s *service) GetComByDate(date time.Time, idPost int) ([]Comment, error) {
-	comments, err := s.ComRepository.GetComByDate(date, idPost)
+// RestoreCom brings back a deleted comment and the replies deleted with it, until the grace period is over.
+// The post, the author and the parent comment must not be deleted.
+func (s *service) RestoreCom(ctx context.Context, idCom int, idPost int, actor auth.Identity) (*Comment, error) {
+	var comment *Comment
+	err := s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		deletedCom, err := s.ComRepository.GetDeletedCom(ctx, idCom)
+		if err != nil {
+			return err
+		}
+		if deletedCom.IDPost != idPost {
+			return apperror.NotFound("the comment is not in the post")
+		}
+		err = s.ComPolicy.Authorize(actor, auth.ActionRestore, auth.Resource{Kind: auth.ResourceComment, ID: idCom, IDOwner: deletedCom.IDUser})
+		if err != nil {
+			return err
+		}
+		if !s.Deletion.Restorable(*deletedCom.DeletedAt) {
+			return apperror.Gone("the comment can no longer be restored")
+		}
+		err = ValidateIDPost(ctx, idPost, s.PostRepository)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return apperror.Conflict("the post of the comment is deleted")
+		}
+		if err != nil {
+			return err
+		}
+		err = ValidateIDUser(ctx, deletedCom.IDUser, s.UserService)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return apperror.Conflict("the author of the comment is deleted")
+		}
+		if err != nil {
+			return err
+		}
+		if deletedCom.IDParent != nil {
+			_, err = s.ComRepository.GetComByID(ctx, *deletedCom.IDParent)
+			if errors.Is(err, apperror.ErrNotFound) {
+				return apperror.Conflict("the parent comment is deleted")
+			}
+			if err != nil {
+				return err
+			}
+		}
+		err = s.ComRepository.RestoreCom(ctx, idCom)
+		if err != nil {
+			return err
+		}
+		comment, err = s.ComRepository.GetComByID(ctx, idCom)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	return comments, nil
+	return s.withComReactions(ctx, comment)
 }
 
-func (s *service) EditCom(com Comment, idCom int, idPost int) (*Comment, error) {
-	com.DateComment = time.Now()
-	comment, err := s.ComRepository.EditCom(com, idCom, idPost)
+// authorize loads the comment and checks that the actor is its author or an admin.
+func (s *service) authorize(ctx context.Context, actor auth.Identity, action string, idCom int) (*Comment, error) {
+	comment, err := s.ComRepository.GetComByID(ctx, idCom)
+	if err != nil {
+		return nil, err
+	}
+	err = s.ComPolicy.Authorize(actor, action, auth.Resource{Kind: auth.ResourceComment, ID: idCom, IDOwner: comment.IDUser})
 	if err != nil {
 		return nil, err
 	}
 	return comment, nil
 }
 
-func (s *service) DeleteCom(idCom int) error {
-	err := s.ComRepository.DeleteCom(idCom)
+// withReactions fills the reaction counts of every comment, it does nothing when no counter is configured.
+func (s *service) withReactions(ctx context.Context, comments []Comment) ([]Comment, error) {
+	if s.Reactions == nil || len(comments) == 0 {
+		return comments, nil
+	}
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	counts, err := s.Reactions.CountReactions(ctx, "comment", ids)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	for i := range comments {
+		comments[i].Reactions = counts[comments[i].ID]
+		if comments[i].Reactions == nil {
+			comments[i].Reactions = map[string]int{}
+		}
+	}
+	return comments, nil
 }
-func NewService(comRepository Repository, postService post.Service, userService user.Service) Service {
-	return &service{comRepository, postService, userService}
+
+func (s *service) withComReactions(ctx context.Context, comment *Comment) (*Comment, error) {
+	comments, err := s.withReactions(ctx, []Comment{*comment})
+	if err != nil {
+		return nil, err
+	}
+	return &comments[0], nil
+}
+
+func NewService(comRepository Repository, postService post.Service, userService user.Service, comPolicy auth.Policy, reactions post.ReactionCounter, unitOfWork dialect.UnitOfWork, deletionPolicy deletion.Policy) Service {
+	return &service{comRepository, postService, userService, comPolicy, reactions, unitOfWork, deletionPolicy}
 }
@@ -1,10 +1,16 @@
 package comment
 
 import (
+	"context"
 	"errors"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/stretchr/testify/mock"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/deletion"
+	"socialBuddy/internal/dialect/dialecttest"
+	"socialBuddy/internal/pagination"
 	"socialBuddy/internal/post"
 	"socialBuddy/internal/user"
 	"time"
@@ -24,32 +30,41 @@ type mockUserService struct {
 	user.Service
 }
 
-func (m *mockRepository) GetCom() ([]Comment, error) {
-	args := m.Called()
+type mockReactionCounter struct {
+	mock.Mock
+}
+
+func (m *mockReactionCounter) CountReactions(ctx context.Context, targetType string, ids []int) (map[int]map[string]int, error) {
+	args := m.Called(targetType, ids)
+	return args.Get(0).(map[int]map[string]int), args.Error(1)
+}
+
+func (m *mockRepository) GetCom(ctx context.Context, page pagination.Page) ([]Comment, error) {
+	args := m.Called(page)
 	return args.Get(0).([]Comment), args.Error(1)
 }
 
-func (m *mockRepository) GetComByID(idCom int) (*Comment, error) {
+func (m *mockRepository) GetComByID(ctx context.Context, idCom int) (*Comment, error) {
 	args := m.Called(idCom)
 	return args.Get(0).(*Comment), args.Error(1)
 }
 
-func (m *mockRepository) GetComByPostID(idPost int) ([]Comment, error) {
-	args := m.Called(idPost)
+func (m *mockRepository) GetComByPostID(ctx context.Context, idPost int, page pagination.Page) ([]Comment, error) {
+	args := m.Called(idPost, page)
 	return args.Get(0).([]Comment), args.Error(1)
 }
 
-func (m *mockRepository) GetComByUserID(idUser int) ([]Comment, error) {
-	args := m.Called(idUser)
+func (m *mockRepository) GetComByUserID(ctx context.Context, idUser int, page pagination.Page) ([]Comment, error) {
+	args := m.Called(idUser, page)
 	return args.Get(0).([]Comment), args.Error(1)
 }
 
-func (m *mockRepository) GetComByDate(date time.Time, idPost int) ([]Comment, error) {
-	args := m.Called(date, idPost)
+func (m *mockRepository) GetComByDate(ctx context.Context, date time.Time, idPost int, page pagination.Page) ([]Comment, error) {
+	args := m.Called(date, idPost, page)
 	return args.Get(0).([]Comment), args.Error(1)
 }
 
-func (m *mockRepository) EditCom(com Comment, idCom int, idPost int) (*Comment, error) {
+func (m *mockRepository) EditCom(ctx context.Context, com Comment, idCom int, idPost int) (*Comment, error) {
 	args := m.Called(com, idCom, idPost)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -57,22 +72,60 @@ func (m *mockRepository) EditCom(com Comment, idCom int, idPost int) (*Comment,
 	return args.Get(0).(*Comment), args.Error(1)
 }
 
-func (m *mockRepository) DeleteCom(idCom int) error {
+func (m *mockRepository) DeleteCom(ctx context.Context, idCom int, deletedAt time.Time) error {
+	args := m.Called(idCom, deletedAt)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetDeletedCom(ctx context.Context, idCom int) (*Comment, error) {
+	args := m.Called(idCom)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Comment), args.Error(1)
+}
+
+func (m *mockRepository) RestoreCom(ctx context.Context, idCom int) error {
 	args := m.Called(idCom)
 	return args.Error(0)
 }
 
-func (m *mockUserService) GetUserByID(idUser int) (*user.User, error) {
+func (m *mockRepository) HasReplies(ctx context.Context, idCom int) (bool, error) {
+	args := m.Called(idCom)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) GetThreadLevel(ctx context.Context, idPost int, idParent *int, page pagination.Page) ([]Comment, error) {
+	args := m.Called(idPost, idParent, page)
+	return args.Get(0).([]Comment), args.Error(1)
+}
+
+func (m *mockRepository) GetReplies(ctx context.Context, idParents []int, limit int) ([]Comment, error) {
+	args := m.Called(idParents, limit)
+	return args.Get(0).([]Comment), args.Error(1)
+}
+
+func (m *mockRepository) CountReplies(ctx context.Context, idParents []int) (map[int]int, error) {
+	args := m.Called(idParents)
+	return args.Get(0).(map[int]int), args.Error(1)
+}
+
+func (m *mockRepository) PurgeComs(ctx context.Context, before time.Time, restrict bool) (int64, error) {
+	args := m.Called(before, restrict)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockUserService) GetUserByID(ctx context.Context, idUser int) (*user.User, error) {
 	args := m.Called(idUser)
 	return args.Get(0).(*user.User), args.Error(1)
 }
 
-func (m *mockPostService) GetPostByID(idPost int) (*post.Post, error) {
+func (m *mockPostService) GetPostByID(ctx context.Context, idPost int) (*post.Post, error) {
 	args := m.Called(idPost)
 	return args.Get(0).(*post.Post), args.Error(1)
 }
 
-func (m *mockRepository) CreateCom(com Comment, idPost int) (*Comment, error) {
+func (m *mockRepository) CreateCom(ctx context.Context, com Comment, idPost int) (*Comment, error) {
 	args := m.Called(com, idPost)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -80,6 +133,8 @@ func (m *mockRepository) CreateCom(com Comment, idPost int) (*Comment, error) {
 	return args.Get(0).(*Comment), args.Error(1)
 }
 
+var deletions = deletion.Policy{Cascade: deletion.Cascade, GracePeriod: time.Hour}
+
 var _ = Describe("The Service Test", func() {
 	var (
 		mockComRepository *mockRepository
@@ -93,7 +148,7 @@ var _ = Describe("The Service Test", func() {
 	})
 	It("should CreateCom successfully", func() {
 		customDate := time.Now().In(time.Local)
-		mockComRepository.On("CreateCom", mock.AnythingOfType("Comment"), 2).Return(&Comment{
+		mockComRepository.On("CreateCom", mock.MatchedBy(func(com Comment) bool { return com.IDUser == 1 }), 2).Return(&Comment{
 			ID:          1,
 			IDPost:      2,
 			IDUser:      1,
@@ -128,37 +183,91 @@ var _ = Describe("The Service Test", func() {
 			},
 		}, nil)
 
-		newService := NewService(mockComRepository, mockServicePost, mockServiceUser)
-		comment, err := newService.CreateCom(Comment{
+		mockCounter := new(mockReactionCounter)
+		newService := NewService(mockComRepository, mockServicePost, mockServiceUser, auth.NewPolicy(), mockCounter, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.CreateCom(context.Background(), Comment{
 			ID:          1,
 			IDPost:      2,
 			IDUser:      1,
 			DateComment: customDate,
 			Content:     "content1",
-		}, 2)
+		}, 2, 1)
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(comment.ID).Should(Equal(1))
 		Expect(comment.IDPost).Should(Equal(2))
+		Expect(comment.Reactions).Should(Equal(map[string]int{}))
+		mockCounter.AssertNotCalled(GinkgoT(), "CountReactions", mock.Anything, mock.Anything)
 	})
 	It("should CreateCom unsuccessfully", func() {
 		mockComRepository.On("CreateCom", mock.AnythingOfType("Comment"), 2).Return(nil, errors.New("error while CreateCom()"))
 		mockServicePost.On("GetPostByID", 2).Return(&post.Post{}, nil)
 		mockServiceUser.On("GetUserByID", 1).Return(&user.User{}, nil)
 		customDate := time.Now().In(time.Local)
-		newService := NewService(mockComRepository, mockServicePost, mockServiceUser)
-		comment, err := newService.CreateCom(Comment{
+		newService := NewService(mockComRepository, mockServicePost, mockServiceUser, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.CreateCom(context.Background(), Comment{
 			ID:          1,
 			IDPost:      2,
 			IDUser:      1,
 			DateComment: customDate,
 			Content:     "content1",
-		}, 2)
+		}, 2, 1)
 		Expect(err).Should(HaveOccurred())
 		Expect(comment).Should(BeNil())
 	})
+	It("should not CreateCom replying to a comment of another post", func() {
+		idParent := 5
+		mockServicePost.On("GetPostByID", 2).Return(&post.Post{ID: 2, IDUser: 1}, nil)
+		mockServiceUser.On("GetUserByID", 1).Return(&user.User{ID: 1}, nil)
+		mockComRepository.On("GetComByID", 5).Return(&Comment{ID: 5, IDPost: 3, IDUser: 1}, nil)
+		newService := NewService(mockComRepository, mockServicePost, mockServiceUser, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.CreateCom(context.Background(), Comment{IDParent: &idParent, Content: "content1"}, 2, 1)
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+		Expect(comment).Should(BeNil())
+		mockComRepository.AssertNotCalled(GinkgoT(), "CreateCom", mock.Anything, mock.Anything)
+	})
+	It("should GetThreads successfully", func() {
+		one := 1
+		mockServicePost.On("GetPostByID", 2).Return(&post.Post{ID: 2, IDUser: 1}, nil)
+		mockComRepository.On("GetThreadLevel", 2, (*int)(nil), pagination.Page{Limit: 1}).Return([]Comment{
+			{ID: 1, IDPost: 2, IDUser: 1},
+			{ID: 5, IDPost: 2, IDUser: 4},
+		}, nil)
+		mockComRepository.On("CountReplies", []int{1}).Return(map[int]int{1: 2}, nil)
+		mockComRepository.On("GetReplies", []int{1}, 2).Return([]Comment{
+			{ID: 2, IDPost: 2, IDUser: 3, IDParent: &one},
+			{ID: 4, IDPost: 2, IDUser: 4, IDParent: &one},
+		}, nil)
+		mockComRepository.On("CountReplies", []int{2}).Return(map[int]int{2: 1}, nil)
+		newService := NewService(mockComRepository, mockServicePost, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		threads, err := newService.GetThreads(context.Background(), 2, nil, 2, pagination.Page{Limit: 1})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(threads.Data).Should(HaveLen(1))
+		Expect(threads.NextCursor).ShouldNot(BeEmpty())
+		Expect(threads.Data[0].ID).Should(Equal(1))
+		Expect(threads.Data[0].ReplyCount).Should(Equal(2))
+		Expect(threads.Data[0].Replies).Should(HaveLen(1))
+		Expect(threads.Data[0].Replies[0].ID).Should(Equal(2))
+		Expect(threads.Data[0].Replies[0].ReplyCount).Should(Equal(1))
+		Expect(threads.Data[0].Replies[0].Replies).Should(BeEmpty())
+		Expect(threads.Data[0].NextCursor).Should(Equal(pagination.EncodeCursor(2)))
+		mockComRepository.AssertNotCalled(GinkgoT(), "GetReplies", []int{2}, mock.Anything)
+	})
+	It("should not GetThreads deeper than the limit", func() {
+		newService := NewService(mockComRepository, mockServicePost, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		_, err := newService.GetThreads(context.Background(), 2, nil, MaxDepth+1, pagination.Page{Limit: 10})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+	})
+	It("should not GetThreads of a comment of another post", func() {
+		idParent := 5
+		mockServicePost.On("GetPostByID", 2).Return(&post.Post{ID: 2, IDUser: 1}, nil)
+		mockComRepository.On("GetComByID", 5).Return(&Comment{ID: 5, IDPost: 3, IDUser: 1}, nil)
+		newService := NewService(mockComRepository, mockServicePost, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		_, err := newService.GetThreads(context.Background(), 2, &idParent, 2, pagination.Page{Limit: 10})
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+	})
 	It("should GetCom successfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockComRepository.On("GetCom").Return([]Comment{
+		mockComRepository.On("GetCom", pagination.Page{Limit: 10}).Return([]Comment{
 			{ID: 1,
 				IDPost:      2,
 				IDUser:      1,
@@ -166,16 +275,16 @@ var _ = Describe("The Service Test", func() {
 				Content:     "content1",
 			},
 		}, nil)
-		newService := NewService(mockComRepository, nil, nil)
-		comments, err := newService.GetCom()
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comments, err := newService.GetCom(context.Background(), pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(comments[0].ID).Should(Equal(1))
 		Expect(comments[0].IDPost).Should(Equal(2))
 	})
 	It("should GetCom unsuccessfully", func() {
-		mockComRepository.On("GetCom").Return([]Comment{}, errors.New("error while GetCom()"))
-		newService := NewService(mockComRepository, nil, nil)
-		comments, err := newService.GetCom()
+		mockComRepository.On("GetCom", pagination.Page{Limit: 10}).Return([]Comment{}, errors.New("error while GetCom()"))
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comments, err := newService.GetCom(context.Background(), pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 		Expect(len(comments)).Should(Equal(0))
 	})
@@ -188,21 +297,28 @@ var _ = Describe("The Service Test", func() {
 			DateComment: timeNow,
 			Content:     "content1",
 		}, nil)
-		newService := NewService(mockComRepository, nil, nil)
-		comment, err := newService.GetComByID(1)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.GetComByID(context.Background(), 1, 2)
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(comment.ID).Should(Equal(1))
 		Expect(comment.IDPost).Should(Equal(2))
 	})
 	It("should GetComByID unsuccessfully", func() {
 		mockComRepository.On("GetComByID", 2).Return(&Comment{}, errors.New("error while GetComByID()"))
-		newService := NewService(mockComRepository, nil, nil)
-		_, err := newService.GetComByID(2)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		_, err := newService.GetComByID(context.Background(), 2, 1)
 		Expect(err).Should(HaveOccurred())
 	})
+	It("should not GetComByID of another post", func() {
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.GetComByID(context.Background(), 1, 999)
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+		Expect(comment).Should(BeNil())
+	})
 	It("should GetComByPostID successfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockComRepository.On("GetComByPostID", 2).Return([]Comment{
+		mockComRepository.On("GetComByPostID", 2, pagination.Page{Limit: 10}).Return([]Comment{
 			{ID: 1,
 				IDPost:      2,
 				IDUser:      1,
@@ -210,21 +326,21 @@ var _ = Describe("The Service Test", func() {
 				Content:     "content1",
 			},
 		}, nil)
-		newService := NewService(mockComRepository, nil, nil)
-		comments, err := newService.GetComByPostID(2)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comments, err := newService.GetComByPostID(context.Background(), 2, pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(comments[0].ID).Should(Equal(1))
 		Expect(comments[0].IDUser).Should(Equal(1))
 	})
 	It("should GetComByPostID unsuccessfully", func() {
-		mockComRepository.On("GetComByPostID", 3).Return([]Comment{}, errors.New("error while GetComByPostID()"))
-		newService := NewService(mockComRepository, nil, nil)
-		_, err := newService.GetComByPostID(3)
+		mockComRepository.On("GetComByPostID", 3, pagination.Page{Limit: 10}).Return([]Comment{}, errors.New("error while GetComByPostID()"))
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		_, err := newService.GetComByPostID(context.Background(), 3, pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 	})
 	It("should GetComByUserID successfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockComRepository.On("GetComByUserID", 1).Return([]Comment{
+		mockComRepository.On("GetComByUserID", 1, pagination.Page{Limit: 10}).Return([]Comment{
 			{ID: 1,
 				IDPost:      2,
 				IDUser:      1,
@@ -232,21 +348,21 @@ var _ = Describe("The Service Test", func() {
 				Content:     "content1",
 			},
 		}, nil)
-		newService := NewService(mockComRepository, nil, nil)
-		comments, err := newService.GetComByUserID(1)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comments, err := newService.GetComByUserID(context.Background(), 1, pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(comments[0].ID).Should(Equal(1))
 		Expect(comments[0].IDPost).Should(Equal(2))
 	})
 	It("should GetComByUserID unsuccessfully", func() {
-		mockComRepository.On("GetComByUserID", 2).Return([]Comment{}, errors.New("error while GetComByUserID()"))
-		newService := NewService(mockComRepository, nil, nil)
-		_, err := newService.GetComByUserID(2)
+		mockComRepository.On("GetComByUserID", 2, pagination.Page{Limit: 10}).Return([]Comment{}, errors.New("error while GetComByUserID()"))
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		_, err := newService.GetComByUserID(context.Background(), 2, pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 	})
 	It("should GetComByDate successfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockComRepository.On("GetComByDate", timeNow, 2).Return([]Comment{
+		mockComRepository.On("GetComByDate", timeNow, 2, pagination.Page{Limit: 10}).Return([]Comment{
 			{ID: 1,
 				IDPost:      2,
 				IDUser:      1,
@@ -254,64 +370,169 @@ var _ = Describe("The Service Test", func() {
 				Content:     "content1",
 			},
 		}, nil)
-		newService := NewService(mockComRepository, nil, nil)
-		comments, err := newService.GetComByDate(timeNow, 2)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comments, err := newService.GetComByDate(context.Background(), timeNow, 2, pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(comments[0].ID).Should(Equal(1))
 		Expect(comments[0].IDPost).Should(Equal(2))
 	})
 	It("should GetComByDate unsuccessfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockComRepository.On("GetComByDate", timeNow, 1).Return([]Comment{}, errors.New("error while GetComByDate()"))
-		newService := NewService(mockComRepository, nil, nil)
-		_, err := newService.GetComByDate(timeNow, 1)
+		mockComRepository.On("GetComByDate", timeNow, 1, pagination.Page{Limit: 10}).Return([]Comment{}, errors.New("error while GetComByDate()"))
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		_, err := newService.GetComByDate(context.Background(), timeNow, 1, pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 	})
 	It("should EditCom successfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockComRepository.On("EditCom", mock.AnythingOfType("Comment"), 1, 2).Return(&Comment{
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		mockComRepository.On("EditCom", mock.MatchedBy(func(com Comment) bool { return com.IDUser == 1 }), 1, 2).Return(&Comment{
 			ID:          1,
 			IDPost:      2,
 			IDUser:      1,
 			DateComment: timeNow,
 			Content:     "content1",
 		}, nil)
-		newService := NewService(mockComRepository, nil, nil)
-		comment, err := newService.EditCom(Comment{
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.EditCom(context.Background(), Comment{
 			ID:          1,
 			IDPost:      2,
-			IDUser:      1,
+			IDUser:      3,
 			DateComment: timeNow,
 			Content:     "content1",
-		}, 1, 2)
+		}, 1, 2, auth.Identity{ID: 1})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(comment.ID).Should(Equal(1))
 		Expect(comment.IDPost).Should(Equal(2))
 	})
 	It("should EditCom unsuccessfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
+		mockComRepository.On("GetComByID", 2).Return(&Comment{ID: 2, IDPost: 1, IDUser: 1}, nil)
 		mockComRepository.On("EditCom", mock.AnythingOfType("Comment"), 2, 1).Return(&Comment{}, errors.New("error while EditCom()"))
-		newService := NewService(mockComRepository, nil, nil)
-		comment, err := newService.EditCom(Comment{
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.EditCom(context.Background(), Comment{
 			ID:          1,
 			IDPost:      2,
 			IDUser:      1,
 			DateComment: timeNow,
 			Content:     "content1",
-		}, 2, 1)
+		}, 2, 1, auth.Identity{ID: 1})
 		Expect(err).Should(HaveOccurred())
 		Expect(comment).Should(BeNil())
 	})
+	It("should EditCom of another user as admin", func() {
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		mockComRepository.On("EditCom", mock.MatchedBy(func(com Comment) bool { return com.IDUser == 1 }), 1, 2).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.EditCom(context.Background(), Comment{Content: "content1"}, 1, 2, auth.Identity{ID: 5, Role: auth.RoleAdmin})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(comment.IDUser).Should(Equal(1))
+	})
+	It("should not EditCom of another user", func() {
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.EditCom(context.Background(), Comment{Content: "content1"}, 1, 2, auth.Identity{ID: 3})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+		Expect(comment).Should(BeNil())
+		mockComRepository.AssertNotCalled(GinkgoT(), "EditCom", mock.Anything, mock.Anything, mock.Anything)
+	})
+	It("should not EditCom of another post", func() {
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.EditCom(context.Background(), Comment{Content: "content1"}, 1, 3, auth.Identity{ID: 1})
+		Expect(err).Should(HaveOccurred())
+		Expect(comment).Should(BeNil())
+	})
+	It("should PatchCom successfully", func() {
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1, Content: "content1"}, nil)
+		mockComRepository.On("EditCom", mock.MatchedBy(func(com Comment) bool {
+			return com.IDUser == 1 && com.IDParent == nil && com.Content == "content2"
+		}), 1, 2).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1, Content: "content2"}, nil)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.PatchCom(context.Background(), []byte(`{"Content":"content2","IDParent":4}`), 1, 2, auth.Identity{ID: 1})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(comment.Content).Should(Equal("content2"))
+	})
+	It("should not PatchCom with empty content", func() {
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1, Content: "content1"}, nil)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.PatchCom(context.Background(), []byte(`{"Content":" "}`), 1, 2, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+		Expect(comment).Should(BeNil())
+		mockComRepository.AssertNotCalled(GinkgoT(), "EditCom", mock.Anything, mock.Anything, mock.Anything)
+	})
+	It("should not PatchCom of another post", func() {
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.PatchCom(context.Background(), []byte(`{"Content":"content2"}`), 1, 3, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+		Expect(comment).Should(BeNil())
+	})
 	It("should DeleteCom successfully", func() {
-		mockComRepository.On("DeleteCom", 1).Return(nil)
-		newService := NewService(mockComRepository, nil, nil)
-		err := newService.DeleteCom(1)
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		mockComRepository.On("DeleteCom", 1, mock.AnythingOfType("time.Time")).Return(nil)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		err := newService.DeleteCom(context.Background(), 1, 2, auth.Identity{ID: 1})
 		Expect(err).ShouldNot(HaveOccurred())
 	})
 	It("should DeleteCom unsuccessfully", func() {
-		mockComRepository.On("DeleteCom", 1).Return(errors.New("error while DeleteCom()"))
-		newService := NewService(mockComRepository, nil, nil)
-		err := newService.DeleteCom(1)
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		mockComRepository.On("DeleteCom", 1, mock.AnythingOfType("time.Time")).Return(errors.New("error while DeleteCom()"))
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		err := newService.DeleteCom(context.Background(), 1, 2, auth.Identity{ID: 1})
 		Expect(err).Should(HaveOccurred())
 	})
+	It("should not DeleteCom of another user", func() {
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		err := newService.DeleteCom(context.Background(), 1, 2, auth.Identity{ID: 3})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+		mockComRepository.AssertNotCalled(GinkgoT(), "DeleteCom", mock.Anything, mock.Anything)
+	})
+	It("should not DeleteCom of another post", func() {
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		err := newService.DeleteCom(context.Background(), 1, 999, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+		mockComRepository.AssertNotCalled(GinkgoT(), "DeleteCom", mock.Anything, mock.Anything)
+	})
+	It("should not DeleteCom with replies when the policy restricts it", func() {
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1}, nil)
+		mockComRepository.On("HasReplies", 1).Return(true, nil)
+		restrict := deletion.Policy{Cascade: deletion.Restrict, GracePeriod: time.Hour}
+		newService := NewService(mockComRepository, nil, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, restrict)
+		err := newService.DeleteCom(context.Background(), 1, 2, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrConflict)).Should(BeTrue())
+		mockComRepository.AssertNotCalled(GinkgoT(), "DeleteCom", mock.Anything, mock.Anything)
+	})
+	It("should RestoreCom successfully", func() {
+		deletedAt := time.Now().UTC()
+		mockComRepository.On("GetDeletedCom", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1, DeletedAt: &deletedAt}, nil)
+		mockServicePost.On("GetPostByID", 2).Return(&post.Post{ID: 2}, nil)
+		mockServiceUser.On("GetUserByID", 1).Return(&user.User{ID: 1}, nil)
+		mockComRepository.On("RestoreCom", 1).Return(nil)
+		mockComRepository.On("GetComByID", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1, Content: "content1"}, nil)
+		newService := NewService(mockComRepository, mockServicePost, mockServiceUser, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.RestoreCom(context.Background(), 1, 2, auth.Identity{ID: 1})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(comment.Content).Should(Equal("content1"))
+	})
+	It("should not RestoreCom after the grace period", func() {
+		deletedAt := time.Now().UTC().Add(-2 * time.Hour)
+		mockComRepository.On("GetDeletedCom", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1, DeletedAt: &deletedAt}, nil)
+		newService := NewService(mockComRepository, mockServicePost, mockServiceUser, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.RestoreCom(context.Background(), 1, 2, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrGone)).Should(BeTrue())
+		Expect(comment).Should(BeNil())
+		mockComRepository.AssertNotCalled(GinkgoT(), "RestoreCom", mock.Anything)
+	})
+	It("should not RestoreCom when the post is deleted", func() {
+		deletedAt := time.Now().UTC()
+		mockComRepository.On("GetDeletedCom", 1).Return(&Comment{ID: 1, IDPost: 2, IDUser: 1, DeletedAt: &deletedAt}, nil)
+		mockServicePost.On("GetPostByID", 2).Return((*post.Post)(nil), apperror.NotFound("the post is not in database"))
+		newService := NewService(mockComRepository, mockServicePost, mockServiceUser, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		comment, err := newService.RestoreCom(context.Background(), 1, 2, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrConflict)).Should(BeTrue())
+		Expect(comment).Should(BeNil())
+	})
 })
@@ -0,0 +1,425 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"socialBuddy/internal/deletion"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/pagination"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvConfig names the file to load when the -config flag is not given.
+const EnvConfig = "SOCIALBUDDY_CONFIG"
+
+type Config struct {
+	Server      Server      `yaml:"server"`
+	Database    Database    `yaml:"database"`
+	Auth        Auth        `yaml:"auth"`
+	CEP         CEP         `yaml:"cep"`
+	Log         Log         `yaml:"log"`
+	Features    Features    `yaml:"features"`
+	Deletion    Deletion    `yaml:"deletion"`
+	Suggestions Suggestions `yaml:"suggestions"`
+}
+
+type Server struct {
+	Addr         string        `yaml:"addr"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// RequestTimeout is the deadline of the context of every request, it cancels the queries and the CEP lookups.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// DrainDelay is how long the server keeps answering after it reports not ready, before it stops accepting connections.
+	DrainDelay      time.Duration `yaml:"drain_delay"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type Database struct {
+	Dialect string `yaml:"dialect"`
+	DSN     string `yaml:"dsn"`
+}
+
+type Auth struct {
+	// Secret signs the access tokens, it is required so the tokens stay valid across restarts and replicas.
+	Secret     string        `yaml:"secret"`
+	AccessTTL  time.Duration `yaml:"access_ttl"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+}
+
+type CEP struct {
+	// Providers are asked in order, among viacep, brasilapi, opencep and offline.
+	Providers    []string `yaml:"providers"`
+	URL          string   `yaml:"url"`
+	BrasilAPIURL string   `yaml:"brasilapi_url"`
+	OpenCEPURL   string   `yaml:"opencep_url"`
+	// OfflineCSV is the dataset of the offline provider, with the header cep,state,city,neighborhood,street.
+	OfflineCSV string `yaml:"offline_csv"`
+	// Timeout bounds the call to every provider, a provider that takes longer hands over to the next one.
+	Timeout   time.Duration `yaml:"timeout"`
+	Retries   int           `yaml:"retries"`
+	Backoff   time.Duration `yaml:"backoff"`
+	CacheSize int           `yaml:"cache_size"`
+	CacheTTL  time.Duration `yaml:"cache_ttl"`
+	// CachePersist keeps the cached addresses in the database across restarts.
+	CachePersist    bool          `yaml:"cache_persist"`
+	BreakerFailures int           `yaml:"breaker_failures"`
+	BreakerCooldown time.Duration `yaml:"breaker_cooldown"`
+	// CheckReadiness makes /readyz fail while none of the configured CEP providers answers.
+	CheckReadiness bool `yaml:"check_readiness"`
+}
+
+type Log struct {
+	Level string `yaml:"level"`
+}
+
+type Deletion struct {
+	// Cascade is cascade or restrict, it decides whether the posts, comments and replies go with what is deleted,
+	// and whether the purge keeps what still has them.
+	Cascade string `yaml:"cascade"`
+	// GracePeriod is how long a deleted user, post or comment can be restored before it is purged.
+	GracePeriod   time.Duration `yaml:"grace_period"`
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+type Suggestions struct {
+	// Limit is how many users are suggested, CacheSize how many users keep their suggestions cached.
+	Limit     int           `yaml:"limit"`
+	CacheSize int           `yaml:"cache_size"`
+	CacheTTL  time.Duration `yaml:"cache_ttl"`
+}
+
+type Features struct {
+	Search    bool `yaml:"search"`
+	Reactions bool `yaml:"reactions"`
+	Threads   bool `yaml:"threads"`
+}
+
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:            ":8081",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			RequestTimeout:  15 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: Database{Dialect: dialect.NameSQLite, DSN: "../internal/database/socialbuddy.db"},
+		Auth:     Auth{AccessTTL: 15 * time.Minute, RefreshTTL: 30 * 24 * time.Hour},
+		CEP: CEP{
+			Providers:       []string{"viacep", "brasilapi", "opencep"},
+			URL:             "https://viacep.com.br",
+			BrasilAPIURL:    "https://brasilapi.com.br",
+			OpenCEPURL:      "https://opencep.com",
+			Timeout:         5 * time.Second,
+			Retries:         2,
+			Backoff:         200 * time.Millisecond,
+			CacheSize:       1024,
+			CacheTTL:        24 * time.Hour,
+			BreakerFailures: 5,
+			BreakerCooldown: 30 * time.Second,
+		},
+		Log:         Log{Level: "info"},
+		Features:    Features{Search: true, Reactions: true, Threads: true},
+		Deletion:    Deletion{Cascade: deletion.Cascade, GracePeriod: 30 * 24 * time.Hour, PurgeInterval: time.Hour},
+		Suggestions: Suggestions{Limit: 20, CacheSize: 1024, CacheTTL: 10 * time.Minute},
+	}
+}
+
+// Load builds the configuration from the defaults, then the YAML file, the environment and the flags,
+// each one overriding the previous. It returns the arguments left after the flags.
+func Load(args []string) (Config, []string, error) {
+	cfg := Default()
+	flags := flag.NewFlagSet("socialBuddy", flag.ContinueOnError)
+	file := flags.String("config", os.Getenv(EnvConfig), "path of the YAML configuration file")
+	addr := flags.String("addr", "", "listen address, host:port")
+	dbDialect := flags.String("db-dialect", "", "database dialect, sqlite or postgres")
+	dsn := flags.String("db-dsn", "", "database DSN")
+	cepURL := flags.String("cep-url", "", "base URL of the CEP provider")
+	cepTimeout := flags.Duration("cep-timeout", 0, "timeout of a CEP lookup")
+	logLevel := flags.String("log-level", "", "log level, debug, info, warn or error")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 0, "how long in-flight requests get to finish on shutdown")
+	err := flags.Parse(args)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	if *file != "" {
+		err = cfg.loadFile(*file)
+		if err != nil {
+			return Config{}, nil, err
+		}
+	}
+	err = cfg.loadEnv()
+	if err != nil {
+		return Config{}, nil, err
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Server.Addr = *addr
+		case "db-dialect":
+			cfg.Database.Dialect = *dbDialect
+		case "db-dsn":
+			cfg.Database.DSN = *dsn
+		case "cep-url":
+			cfg.CEP.URL = *cepURL
+		case "cep-timeout":
+			cfg.CEP.Timeout = *cepTimeout
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "shutdown-timeout":
+			cfg.Server.ShutdownTimeout = *shutdownTimeout
+		}
+	})
+
+	err = cfg.Validate()
+	if err != nil {
+		return Config{}, nil, err
+	}
+	return cfg, flags.Args(), nil
+}
+
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("the configuration file cannot be read: %w", err)
+	}
+	defer file.Close()
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	err = decoder.Decode(c)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("the configuration file %s is not valid: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	texts := map[string]*string{
+		"SOCIALBUDDY_ADDR":              &c.Server.Addr,
+		"SOCIALBUDDY_DB_DIALECT":        &c.Database.Dialect,
+		"SOCIALBUDDY_DB_DSN":            &c.Database.DSN,
+		"SOCIALBUDDY_JWT_SECRET":        &c.Auth.Secret,
+		"SOCIALBUDDY_CEP_URL":           &c.CEP.URL,
+		"SOCIALBUDDY_CEP_BRASILAPI_URL": &c.CEP.BrasilAPIURL,
+		"SOCIALBUDDY_CEP_OPENCEP_URL":   &c.CEP.OpenCEPURL,
+		"SOCIALBUDDY_CEP_OFFLINE_CSV":   &c.CEP.OfflineCSV,
+		"SOCIALBUDDY_LOG_LEVEL":         &c.Log.Level,
+		"SOCIALBUDDY_DELETION_CASCADE":  &c.Deletion.Cascade,
+	}
+	for name, field := range texts {
+		if value, ok := os.LookupEnv(name); ok {
+			*field = value
+		}
+	}
+	if value, ok := os.LookupEnv("SOCIALBUDDY_CEP_PROVIDERS"); ok {
+		c.CEP.Providers = strings.Split(value, ",")
+	}
+	durations := map[string]*time.Duration{
+		"SOCIALBUDDY_READ_TIMEOUT":     &c.Server.ReadTimeout,
+		"SOCIALBUDDY_WRITE_TIMEOUT":    &c.Server.WriteTimeout,
+		"SOCIALBUDDY_IDLE_TIMEOUT":     &c.Server.IdleTimeout,
+		"SOCIALBUDDY_REQUEST_TIMEOUT":  &c.Server.RequestTimeout,
+		"SOCIALBUDDY_DRAIN_DELAY":      &c.Server.DrainDelay,
+		"SOCIALBUDDY_SHUTDOWN_TIMEOUT": &c.Server.ShutdownTimeout,
+		"SOCIALBUDDY_ACCESS_TTL":       &c.Auth.AccessTTL,
+		"SOCIALBUDDY_REFRESH_TTL":      &c.Auth.RefreshTTL,
+		"SOCIALBUDDY_CEP_TIMEOUT":      &c.CEP.Timeout,
+		"SOCIALBUDDY_CEP_BACKOFF":      &c.CEP.Backoff,
+		"SOCIALBUDDY_CEP_CACHE_TTL":    &c.CEP.CacheTTL,
+		"SOCIALBUDDY_CEP_COOLDOWN":     &c.CEP.BreakerCooldown,
+		"SOCIALBUDDY_GRACE_PERIOD":     &c.Deletion.GracePeriod,
+		"SOCIALBUDDY_PURGE_INTERVAL":   &c.Deletion.PurgeInterval,
+		"SOCIALBUDDY_SUGGESTIONS_TTL":  &c.Suggestions.CacheTTL,
+	}
+	for name, field := range durations {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*field = duration
+	}
+	numbers := map[string]*int{
+		"SOCIALBUDDY_CEP_RETRIES":            &c.CEP.Retries,
+		"SOCIALBUDDY_CEP_CACHE_SIZE":         &c.CEP.CacheSize,
+		"SOCIALBUDDY_CEP_FAILURES":           &c.CEP.BreakerFailures,
+		"SOCIALBUDDY_SUGGESTIONS":            &c.Suggestions.Limit,
+		"SOCIALBUDDY_SUGGESTIONS_CACHE_SIZE": &c.Suggestions.CacheSize,
+	}
+	for name, field := range numbers {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*field = number
+	}
+	toggles := map[string]*bool{
+		"SOCIALBUDDY_FEATURE_SEARCH":    &c.Features.Search,
+		"SOCIALBUDDY_FEATURE_REACTIONS": &c.Features.Reactions,
+		"SOCIALBUDDY_FEATURE_THREADS":   &c.Features.Threads,
+		"SOCIALBUDDY_CEP_CHECK":         &c.CEP.CheckReadiness,
+		"SOCIALBUDDY_CEP_CACHE_PERSIST": &c.CEP.CachePersist,
+	}
+	for name, field := range toggles {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*field = enabled
+	}
+	return nil
+}
+
+// ValidateServe reports the settings only serving needs, the migrations run without them.
+func (c Config) ValidateServe() error {
+	if c.Auth.Secret == "" {
+		return errors.New("auth.secret: the secret is required, set it with SOCIALBUDDY_JWT_SECRET")
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once, each error names its YAML key.
+func (c Config) Validate() error {
+	var errs []error
+	_, _, err := net.SplitHostPort(c.Server.Addr)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("server.addr: %q is not a host:port address", c.Server.Addr))
+	}
+	timeouts := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.request_timeout", c.Server.RequestTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: %s is not a positive duration", timeout.key, timeout.value))
+		}
+	}
+	if c.Server.DrainDelay < 0 {
+		errs = append(errs, fmt.Errorf("server.drain_delay: %s is a negative duration", c.Server.DrainDelay))
+	}
+	_, err = dialect.ByName(c.Database.Dialect)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("database.dialect: %w", err))
+	}
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("database.dsn: the DSN is required"))
+	}
+	if c.Auth.RefreshTTL <= c.Auth.AccessTTL {
+		errs = append(errs, fmt.Errorf("auth.refresh_ttl: %s is not longer than auth.access_ttl", c.Auth.RefreshTTL))
+	}
+	if len(c.CEP.Providers) == 0 {
+		errs = append(errs, errors.New("cep.providers: at least one provider is required"))
+	}
+	for _, provider := range c.CEP.Providers {
+		switch provider {
+		case "viacep":
+			errs = append(errs, urlValidation("cep.url", c.CEP.URL))
+		case "brasilapi":
+			errs = append(errs, urlValidation("cep.brasilapi_url", c.CEP.BrasilAPIURL))
+		case "opencep":
+			errs = append(errs, urlValidation("cep.opencep_url", c.CEP.OpenCEPURL))
+		case "offline":
+			if c.CEP.OfflineCSV == "" {
+				errs = append(errs, errors.New("cep.offline_csv: the offline provider needs a dataset"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("cep.providers: %q is not one of viacep, brasilapi, opencep or offline", provider))
+		}
+	}
+	positives := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"auth.access_ttl", c.Auth.AccessTTL},
+		{"cep.timeout", c.CEP.Timeout},
+		{"cep.cache_ttl", c.CEP.CacheTTL},
+		{"cep.breaker_cooldown", c.CEP.BreakerCooldown},
+		{"deletion.grace_period", c.Deletion.GracePeriod},
+		{"deletion.purge_interval", c.Deletion.PurgeInterval},
+		{"suggestions.cache_ttl", c.Suggestions.CacheTTL},
+	}
+	for _, positive := range positives {
+		if positive.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: %s is not a positive duration", positive.key, positive.value))
+		}
+	}
+	if c.CEP.Retries < 0 {
+		errs = append(errs, fmt.Errorf("cep.retries: %d is negative", c.CEP.Retries))
+	}
+	if c.CEP.Backoff < 0 {
+		errs = append(errs, fmt.Errorf("cep.backoff: %s is a negative duration", c.CEP.Backoff))
+	}
+	if c.CEP.CacheSize <= 0 {
+		errs = append(errs, fmt.Errorf("cep.cache_size: %d is not positive", c.CEP.CacheSize))
+	}
+	if c.CEP.BreakerFailures <= 0 {
+		errs = append(errs, fmt.Errorf("cep.breaker_failures: %d is not positive", c.CEP.BreakerFailures))
+	}
+	if c.Suggestions.Limit <= 0 || c.Suggestions.Limit > pagination.MaxLimit {
+		errs = append(errs, fmt.Errorf("suggestions.limit: %d is not between 1 and %d", c.Suggestions.Limit, pagination.MaxLimit))
+	}
+	if c.Suggestions.CacheSize <= 0 {
+		errs = append(errs, fmt.Errorf("suggestions.cache_size: %d is not positive", c.Suggestions.CacheSize))
+	}
+	err = deletion.Validation(c.Deletion.Cascade)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("deletion.cascade: %w", err))
+	}
+	_, err = c.Log.SlogLevel()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func urlValidation(key string, value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%s: %q is not an http or https URL", key, value)
+	}
+	return nil
+}
+
+func (l Log) SlogLevel() (slog.Level, error) {
+	switch l.Level {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("%q is not one of debug, info, warn or error", l.Level)
+	}
+}
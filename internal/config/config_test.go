@@ -0,0 +1,221 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type argLoad struct {
+	name     string
+	file     string
+	env      map[string]string
+	args     []string
+	output   func(cfg *Config)
+	rest     []string
+	hasError string
+}
+
+func TestLoad(t *testing.T) {
+	test := []argLoad{
+		{
+			name:   "Load() the defaults",
+			output: func(cfg *Config) {},
+		},
+		{
+			name: "Load() the file",
+			file: `server:
+  addr: "127.0.0.1:9090"
+cep:
+  timeout: 2s
+features:
+  search: false
+`,
+			output: func(cfg *Config) {
+				cfg.Server.Addr = "127.0.0.1:9090"
+				cfg.CEP.Timeout = 2 * time.Second
+				cfg.Features.Search = false
+			},
+		},
+		{
+			name: "Load() the environment over the file",
+			file: "log:\n  level: warn\n",
+			env:  map[string]string{"SOCIALBUDDY_LOG_LEVEL": "debug", "SOCIALBUDDY_FEATURE_REACTIONS": "false"},
+			output: func(cfg *Config) {
+				cfg.Log.Level = "debug"
+				cfg.Features.Reactions = false
+			},
+		},
+		{
+			name: "Load() the flags over the environment",
+			env:  map[string]string{"SOCIALBUDDY_DB_DIALECT": "postgres", "SOCIALBUDDY_DB_DSN": "postgres://localhost/env"},
+			args: []string{"-db-dsn", "postgres://localhost/flag", "-cep-timeout", "1s", "migrate", "up"},
+			output: func(cfg *Config) {
+				cfg.Database.Dialect = "postgres"
+				cfg.Database.DSN = "postgres://localhost/flag"
+				cfg.CEP.Timeout = time.Second
+			},
+			rest: []string{"migrate", "up"},
+		},
+		{
+			name: "Load() the server timeouts",
+			file: "server:\n  write_timeout: 1m\n  drain_delay: 5s\n",
+			env:  map[string]string{"SOCIALBUDDY_IDLE_TIMEOUT": "30s", "SOCIALBUDDY_REQUEST_TIMEOUT": "5s"},
+			args: []string{"-shutdown-timeout", "40s"},
+			output: func(cfg *Config) {
+				cfg.Server.RequestTimeout = 5 * time.Second
+				cfg.Server.WriteTimeout = time.Minute
+				cfg.Server.DrainDelay = 5 * time.Second
+				cfg.Server.IdleTimeout = 30 * time.Second
+				cfg.Server.ShutdownTimeout = 40 * time.Second
+			},
+		},
+		{
+			name: "Load() the CEP providers",
+			env:  map[string]string{"SOCIALBUDDY_CEP_PROVIDERS": "offline,viacep", "SOCIALBUDDY_CEP_OFFLINE_CSV": "ceps.csv"},
+			output: func(cfg *Config) {
+				cfg.CEP.Providers = []string{"offline", "viacep"}
+				cfg.CEP.OfflineCSV = "ceps.csv"
+			},
+		},
+		{
+			name: "Load() the deletion policy",
+			file: "deletion:\n  cascade: restrict\n  grace_period: 168h\n",
+			env:  map[string]string{"SOCIALBUDDY_PURGE_INTERVAL": "10m"},
+			output: func(cfg *Config) {
+				cfg.Deletion.Cascade = "restrict"
+				cfg.Deletion.GracePeriod = 7 * 24 * time.Hour
+				cfg.Deletion.PurgeInterval = 10 * time.Minute
+			},
+		},
+		{
+			name: "Load() the suggestions",
+			file: "suggestions:\n  limit: 5\n",
+			env:  map[string]string{"SOCIALBUDDY_SUGGESTIONS_TTL": "1m", "SOCIALBUDDY_SUGGESTIONS_CACHE_SIZE": "64"},
+			output: func(cfg *Config) {
+				cfg.Suggestions.Limit = 5
+				cfg.Suggestions.CacheSize = 64
+				cfg.Suggestions.CacheTTL = time.Minute
+			},
+		},
+		{
+			name: "Load() the auth",
+			file: "auth:\n  access_ttl: 5m\n",
+			env:  map[string]string{"SOCIALBUDDY_JWT_SECRET": "other", "SOCIALBUDDY_REFRESH_TTL": "24h"},
+			output: func(cfg *Config) {
+				cfg.Auth.Secret = "other"
+				cfg.Auth.AccessTTL = 5 * time.Minute
+				cfg.Auth.RefreshTTL = 24 * time.Hour
+			},
+		},
+		{
+			name: "Load() the migrations without a JWT secret",
+			env:  map[string]string{"SOCIALBUDDY_JWT_SECRET": ""},
+			args: []string{"migrate", "status"},
+			output: func(cfg *Config) {
+				cfg.Auth.Secret = ""
+			},
+			rest: []string{"migrate", "status"},
+		},
+		{
+			name:     "Load() a refresh TTL shorter than the access one",
+			env:      map[string]string{"SOCIALBUDDY_ACCESS_TTL": "1h", "SOCIALBUDDY_REFRESH_TTL": "30m"},
+			hasError: "auth.refresh_ttl",
+		},
+		{
+			name:     "Load() an invalid timeout",
+			env:      map[string]string{"SOCIALBUDDY_SHUTDOWN_TIMEOUT": "soon"},
+			hasError: "SOCIALBUDDY_SHUTDOWN_TIMEOUT",
+		},
+		{
+			name:     "Load() an unknown key",
+			file:     "server:\n  port: 8081\n",
+			hasError: "field port not found",
+		},
+		{
+			name:     "Load() an invalid toggle",
+			env:      map[string]string{"SOCIALBUDDY_FEATURE_SEARCH": "maybe"},
+			hasError: "SOCIALBUDDY_FEATURE_SEARCH",
+		},
+		{
+			name:     "Load() an invalid dialect",
+			args:     []string{"-db-dialect", "mysql"},
+			hasError: "database.dialect",
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "config.yaml")
+				err := os.WriteFile(path, []byte(tt.file), 0o600)
+				if err != nil {
+					t.Fatalf("the creation of file is failed %v", err)
+				}
+				args = append([]string{"-config", path}, args...)
+			}
+			t.Setenv("SOCIALBUDDY_JWT_SECRET", "secret")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			cfg, rest, err := Load(args)
+			if tt.hasError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.hasError) {
+					t.Fatalf("expected error containing %q, got %v", tt.hasError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			expected := Default()
+			expected.Auth.Secret = "secret"
+			tt.output(&expected)
+			if !reflect.DeepEqual(cfg, expected) {
+				t.Fatalf("expected %+v, got %+v", expected, cfg)
+			}
+			if len(rest) != len(tt.rest) || (len(rest) > 0 && !reflect.DeepEqual(rest, tt.rest)) {
+				t.Fatalf("expected arguments %v, got %v", tt.rest, rest)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Config{
+		Server:      Server{Addr: "8081", DrainDelay: -time.Second},
+		Database:    Database{Dialect: "sqlite"},
+		CEP:         CEP{Providers: []string{"viacep", "offline", "correios"}, URL: "viacep.com.br"},
+		Log:         Log{Level: "verbose"},
+		Deletion:    Deletion{Cascade: "orphan"},
+		Suggestions: Suggestions{Limit: 500},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, key := range []string{"server.addr", "server.read_timeout", "server.shutdown_timeout", "server.drain_delay", "database.dsn", "auth.access_ttl", "cep.url", "cep.offline_csv", "cep.providers", "cep.timeout", "log.level", "deletion.cascade", "deletion.purge_interval", "suggestions.limit", "suggestions.cache_ttl"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Fatalf("expected the error to name %s, got %v", key, err)
+		}
+	}
+	if strings.Contains(err.Error(), "database.dialect") {
+		t.Fatalf("expected the dialect to be valid, got %v", err)
+	}
+}
+
+func TestValidateServe(t *testing.T) {
+	cfg := Default()
+	err := cfg.ValidateServe()
+	if err == nil || !strings.Contains(err.Error(), "auth.secret") {
+		t.Fatalf("expected the error to name auth.secret, got %v", err)
+	}
+	cfg.Auth.Secret = "secret"
+	err = cfg.ValidateServe()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
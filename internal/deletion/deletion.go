@@ -0,0 +1,84 @@
+// Package deletion holds the rules shared by the soft deletes of users, posts and comments, and the job
+// that deletes them for good once their grace period is over.
+package deletion
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// Cascade deletes the posts and comments of a user, the comments of a post and the replies of a
+	// comment along with it, a restore brings them back together.
+	Cascade = "cascade"
+	// Restrict refuses to delete a user, post or comment while it still has posts, comments or replies,
+	// and the purge keeps a deleted one until they are gone.
+	Restrict = "restrict"
+)
+
+type Policy struct {
+	Cascade string
+	// GracePeriod is how long a deleted row can be restored before the purge removes it.
+	GracePeriod time.Duration
+}
+
+func (p Policy) Restricted() bool {
+	return p.Cascade == Restrict
+}
+
+// Restorable tells whether a row deleted at deletedAt is still in its grace period.
+func (p Policy) Restorable(deletedAt time.Time) bool {
+	return time.Since(deletedAt) <= p.GracePeriod
+}
+
+func Validation(cascade string) error {
+	if cascade != Cascade && cascade != Restrict {
+		return fmt.Errorf("%q is not one of cascade or restrict", cascade)
+	}
+	return nil
+}
+
+// Purge deletes for good the rows deleted before the cutoff and returns how many were removed.
+type Purge func(ctx context.Context, before time.Time) (int64, error)
+
+// Purge binds a repository purge to the policy, under Restrict it keeps the rows that still have children.
+func (p Policy) Purge(purge func(ctx context.Context, before time.Time, restrict bool) (int64, error)) Purge {
+	return func(ctx context.Context, before time.Time) (int64, error) {
+		return purge(ctx, before, p.Restricted())
+	}
+}
+
+type Target struct {
+	Name  string
+	Purge Purge
+}
+
+// PurgeOnce runs every target in order, a failed target is logged and does not stop the next ones.
+func PurgeOnce(ctx context.Context, before time.Time, targets []Target) {
+	for _, target := range targets {
+		purged, err := target.Purge(ctx, before)
+		if err != nil {
+			slog.Error("purge failed", "target", target.Name, "error", err)
+			continue
+		}
+		if purged > 0 {
+			slog.Info("purged deleted rows", "target", target.Name, "count", purged)
+		}
+	}
+}
+
+// Run purges what was deleted more than gracePeriod ago right away and then every interval, until ctx is done.
+func Run(ctx context.Context, interval time.Duration, gracePeriod time.Duration, targets ...Target) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		PurgeOnce(ctx, time.Now().UTC().Add(-gracePeriod), targets)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package deletion
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRestorable(t *testing.T) {
+	policy := Policy{Cascade: Cascade, GracePeriod: time.Hour}
+	if !policy.Restorable(time.Now().Add(-time.Minute)) {
+		t.Fatalf("expected a row deleted a minute ago to be restorable")
+	}
+	if policy.Restorable(time.Now().Add(-2 * time.Hour)) {
+		t.Fatalf("expected a row deleted two hours ago not to be restorable")
+	}
+}
+
+func TestValidation(t *testing.T) {
+	if err := Validation(Restrict); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := Validation("orphan"); err == nil {
+		t.Fatalf("expected an error for an unknown policy")
+	}
+}
+
+func TestPurgeOnce(t *testing.T) {
+	before := time.Date(2023, 11, 13, 0, 0, 0, 0, time.UTC)
+	var purged []string
+	target := func(name string, err error) Target {
+		return Target{Name: name, Purge: func(ctx context.Context, cutoff time.Time) (int64, error) {
+			if !cutoff.Equal(before) {
+				t.Fatalf("expected the cutoff %s, got %s", before, cutoff)
+			}
+			purged = append(purged, name)
+			return 1, err
+		}}
+	}
+	PurgeOnce(context.Background(), before, []Target{
+		target("comment", nil),
+		target("post", errors.New("the purge is failed")),
+		target("user", nil),
+	})
+	if !reflect.DeepEqual(purged, []string{"comment", "post", "user"}) {
+		t.Fatalf("expected every target in order, got %v", purged)
+	}
+}
+
+func TestPolicyPurge(t *testing.T) {
+	for _, cascade := range []string{Cascade, Restrict} {
+		purge := Policy{Cascade: cascade}.Purge(func(ctx context.Context, before time.Time, restrict bool) (int64, error) {
+			if restrict != (cascade == Restrict) {
+				t.Fatalf("expected restrict to be %t under %s", cascade == Restrict, cascade)
+			}
+			return 1, nil
+		})
+		purged, err := purge(context.Background(), time.Now())
+		if err != nil || purged != 1 {
+			t.Fatalf("expected one purged row, got %d, err %v", purged, err)
+		}
+	}
+}
+
+func TestRunStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, time.Hour, time.Hour, Target{Name: "user", Purge: func(ctx context.Context, before time.Time) (int64, error) {
+			runs <- struct{}{}
+			return 0, nil
+		}})
+		close(done)
+	}()
+	<-runs
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected Run to stop once the context is cancelled")
+	}
+}
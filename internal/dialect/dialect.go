@@ -0,0 +1,144 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	NameSQLite   = "sqlite"
+	NamePostgres = "postgres"
+)
+
+// Dialect hides the SQL differences between the databases the repositories can run on.
+// Repositories always write ? placeholders, DB rewrites them with Placeholder.
+type Dialect interface {
+	Name() string
+	DriverName() string
+	Placeholder(n int) string
+	// Date formats a timestamp column as YYYY-MM-DD.
+	Date(column string) string
+	// ReturningID tells whether new IDs are read with INSERT ... RETURNING ID instead of LastInsertId.
+	ReturningID() bool
+	// DSN adds the connection options the repositories rely on to dsn, leaving the ones already set.
+	DSN(dsn string) string
+	// UniqueViolation tells whether err broke a unique constraint and describes the constraint,
+	// "UNIQUE constraint failed: Users.Email" on SQLite and the index name on PostgreSQL.
+	UniqueViolation(err error) (string, bool)
+}
+
+type DB struct {
+	*sql.DB
+	dialect  Dialect
+	observer Observer
+}
+
+func New(db *sql.DB, dialect Dialect) *DB {
+	return &DB{DB: db, dialect: dialect}
+}
+
+func Open(name string, dsn string) (*DB, error) {
+	dialect, err := ByName(name)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(dialect.DriverName(), dialect.DSN(dsn))
+	if err != nil {
+		return nil, err
+	}
+	return New(db, dialect), nil
+}
+
+func ByName(name string) (Dialect, error) {
+	switch name {
+	case NameSQLite:
+		return SQLite(), nil
+	case NamePostgres:
+		return Postgres(), nil
+	default:
+		return nil, fmt.Errorf("the database dialect %q is not supported", name)
+	}
+}
+
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+func (db *DB) QueryRow(query string, args ...any) *sql.Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+func (db *DB) Insert(query string, args ...any) (int64, error) {
+	return db.InsertContext(context.Background(), query, args...)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	defer db.observe(ctx, time.Now())
+	return db.querier(ctx).QueryContext(ctx, db.Rebind(query), args...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	defer db.observe(ctx, time.Now())
+	return db.querier(ctx).QueryRowContext(ctx, db.Rebind(query), args...)
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	defer db.observe(ctx, time.Now())
+	return db.querier(ctx).ExecContext(ctx, db.Rebind(query), args...)
+}
+
+// InsertContext runs an INSERT and returns the ID of the new row.
+func (db *DB) InsertContext(ctx context.Context, query string, args ...any) (int64, error) {
+	defer db.observe(ctx, time.Now())
+	if db.dialect.ReturningID() {
+		var id int64
+		err := db.querier(ctx).QueryRowContext(ctx, db.Rebind(query+" RETURNING ID"), args...).Scan(&id)
+		return id, err
+	}
+	res, err := db.querier(ctx).ExecContext(ctx, db.Rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (db *DB) UniqueViolation(err error) (string, bool) {
+	return db.dialect.UniqueViolation(err)
+}
+
+func (db *DB) Date(column string) string {
+	return db.dialect.Date(column)
+}
+
+// Rebind replaces the ? placeholders outside of string literals with the ones of the dialect.
+func (db *DB) Rebind(query string) string {
+	if db.dialect.Placeholder(1) == "?" {
+		return query
+	}
+	var rebound strings.Builder
+	n := 0
+	quoted := false
+	for _, char := range query {
+		switch {
+		case char == '\'':
+			quoted = !quoted
+		case char == '?' && !quoted:
+			n++
+			rebound.WriteString(db.dialect.Placeholder(n))
+			continue
+		}
+		rebound.WriteRune(char)
+	}
+	return rebound.String()
+}
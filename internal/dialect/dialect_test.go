@@ -0,0 +1,257 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type argRebind struct {
+	name    string
+	dialect Dialect
+	query   string
+	output  string
+}
+
+type argInsert struct {
+	name    string
+	dialect Dialect
+	expect  func(mock sqlmock.Sqlmock)
+	output  int64
+}
+
+func TestRebind(t *testing.T) {
+	test := []argRebind{
+		{
+			name:    "Rebind() keeps the placeholders of SQLite",
+			dialect: SQLite(),
+			query:   "SELECT * FROM Posts WHERE IDUser = ? AND ID > ?",
+			output:  "SELECT * FROM Posts WHERE IDUser = ? AND ID > ?",
+		},
+		{
+			name:    "Rebind() numbers the placeholders of Postgres",
+			dialect: Postgres(),
+			query:   "SELECT * FROM Posts WHERE IDUser = ? AND ID > ?",
+			output:  "SELECT * FROM Posts WHERE IDUser = $1 AND ID > $2",
+		},
+		{
+			name:    "Rebind() skips question marks in string literals",
+			dialect: Postgres(),
+			query:   "SELECT * FROM Reaction WHERE (? = '' OR Type = ?) AND Content <> 'why?'",
+			output:  "SELECT * FROM Reaction WHERE ($1 = '' OR Type = $2) AND Content <> 'why?'",
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			db := New(nil, tt.dialect)
+			query := db.Rebind(tt.query)
+			if query != tt.output {
+				t.Fatalf("expected %s, got %s", tt.output, query)
+			}
+		})
+	}
+}
+
+func TestDate(t *testing.T) {
+	if date := SQLite().Date("DatePost"); date != "strftime('%Y-%m-%d', DatePost)" {
+		t.Fatalf("unexpected SQLite date %s", date)
+	}
+	if date := Postgres().Date("DatePost"); date != "to_char(DatePost, 'YYYY-MM-DD')" {
+		t.Fatalf("unexpected Postgres date %s", date)
+	}
+}
+
+func TestInsert(t *testing.T) {
+	test := []argInsert{
+		{
+			name:    "Insert() reads LastInsertId on SQLite",
+			dialect: SQLite(),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO Posts \\(Title\\) VALUES \\(\\?\\)").WithArgs("title1").
+					WillReturnResult(sqlmock.NewResult(7, 1))
+			},
+			output: 7,
+		},
+		{
+			name:    "Insert() returns the ID on Postgres",
+			dialect: Postgres(),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO Posts \\(Title\\) VALUES \\(\\$1\\) RETURNING ID").WithArgs("title1").
+					WillReturnRows(sqlmock.NewRows([]string{"ID"}).AddRow(7))
+			},
+			output: 7,
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("the creation of mock is failed %v", err)
+			}
+			defer mockDB.Close()
+			tt.expect(mock)
+			id, err := New(mockDB, tt.dialect).Insert("INSERT INTO Posts (Title) VALUES (?)", "title1")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if id != tt.output {
+				t.Fatalf("expected %d, got %d", tt.output, id)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("there were unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestDSN(t *testing.T) {
+	test := map[string]string{
+		"socialbuddy.db":                                    "socialbuddy.db?_txlock=immediate&_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=1",
+		"file:socialbuddy.db?cache=shared":                  "file:socialbuddy.db?cache=shared&_txlock=immediate&_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=1",
+		"socialbuddy.db?_busy_timeout=100&_txlock=deferred": "socialbuddy.db?_busy_timeout=100&_txlock=deferred&_journal_mode=WAL&_foreign_keys=1",
+	}
+	for dsn, expected := range test {
+		if output := SQLite().DSN(dsn); output != expected {
+			t.Fatalf("expected %s, got %s", expected, output)
+		}
+	}
+	if output := Postgres().DSN("postgres://localhost/socialbuddy"); output != "postgres://localhost/socialbuddy" {
+		t.Fatalf("expected the Postgres DSN to be kept, got %s", output)
+	}
+}
+
+// TestForeignKeys deletes outside of Transact on several connections of the pool, every one of them must cascade.
+func TestForeignKeys(t *testing.T) {
+	db, err := Open(NameSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("the database cannot be opened %v", err)
+	}
+	defer db.Close()
+	db.SetMaxIdleConns(4)
+	_, err = db.Exec(`CREATE TABLE Users (ID INTEGER PRIMARY KEY AUTOINCREMENT);
+		CREATE TABLE Posts (ID INTEGER PRIMARY KEY AUTOINCREMENT, IDUser INTEGER NOT NULL,
+		FOREIGN KEY (IDUser) REFERENCES Users (ID) ON DELETE CASCADE)`)
+	if err != nil {
+		t.Fatalf("the creation of tables is failed %v", err)
+	}
+	conns := make([]*sql.Conn, 4)
+	for i := range conns {
+		conns[i], err = db.Conn(context.Background())
+		if err != nil {
+			t.Fatalf("the connection cannot be opened %v", err)
+		}
+	}
+	for i, conn := range conns {
+		var enabled int
+		err = conn.QueryRowContext(context.Background(), "PRAGMA foreign_keys").Scan(&enabled)
+		if err != nil || enabled != 1 {
+			t.Fatalf("expected the connection %d to enforce foreign keys, got %d, err %v", i, enabled, err)
+		}
+		_ = conn.Close()
+	}
+	_, err = db.Exec("INSERT INTO Users (ID) VALUES (1)")
+	if err != nil {
+		t.Fatalf("the creation of user is failed %v", err)
+	}
+	_, err = db.Exec("INSERT INTO Posts (IDUser) VALUES (1)")
+	if err != nil {
+		t.Fatalf("the creation of post is failed %v", err)
+	}
+	_, err = db.Exec("DELETE FROM Users WHERE ID = 1")
+	if err != nil {
+		t.Fatalf("the deletion of user is failed %v", err)
+	}
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM Posts").Scan(&count)
+	if err != nil || count != 0 {
+		t.Fatalf("expected the posts to be deleted with the user, got %d, err %v", count, err)
+	}
+}
+
+func TestByName(t *testing.T) {
+	for _, name := range []string{NameSQLite, NamePostgres} {
+		dialect, err := ByName(name)
+		if err != nil || dialect.Name() != name {
+			t.Fatalf("expected the dialect %s, got %v, err %v", name, dialect, err)
+		}
+	}
+	_, err := ByName("mysql")
+	if err == nil {
+		t.Fatalf("expected an error for an unknown dialect")
+	}
+}
+
+func TestObserver(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer mockDB.Close()
+	mock.ExpectExec("INSERT INTO Posts").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO Posts").WillReturnResult(sqlmock.NewResult(2, 1))
+	db := New(mockDB, SQLite())
+	var observed []string
+	db.SetObserver(func(repository string, method string, elapsed time.Duration) {
+		observed = append(observed, repository+" "+method)
+	})
+	_, err = db.InsertContext(Operation(context.Background(), "post", "CreatePost"), "INSERT INTO Posts (Title) VALUES (?)", "title")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, err = db.Insert("INSERT INTO Posts (Title) VALUES (?)", "title")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(observed) != 2 || observed[0] != "post CreatePost" || observed[1] != "unknown unknown" {
+		t.Fatalf("expected the named and the unknown operation, got %v", observed)
+	}
+}
+
+func TestUniqueViolation(t *testing.T) {
+	constraint, ok := Postgres().UniqueViolation(fmt.Errorf("insert: %w", &pq.Error{Code: "23505", Constraint: "idx_users_email"}))
+	if !ok || constraint != "idx_users_email" {
+		t.Fatalf("expected the PostgreSQL unique violation, got %q %v", constraint, ok)
+	}
+	_, ok = Postgres().UniqueViolation(&pq.Error{Code: "23503"})
+	if ok {
+		t.Fatalf("expected a foreign key violation not to be a unique violation")
+	}
+	_, ok = SQLite().UniqueViolation(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique})
+	if !ok {
+		t.Fatalf("expected the SQLite unique violation")
+	}
+	_, ok = SQLite().UniqueViolation(errors.New("database is locked"))
+	if ok {
+		t.Fatalf("expected an unknown error not to be a unique violation")
+	}
+}
+
+func TestQueryContext(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("the database cannot be opened %v", err)
+	}
+	defer sqlDB.Close()
+	db := New(sqlDB, SQLite())
+	// the recursive query never ends on its own, only the deadline stops it
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	rows, err := db.QueryContext(ctx, `WITH RECURSIVE Counter(N) AS (SELECT 1 UNION ALL SELECT N + 1 FROM Counter)
+		SELECT COUNT(*) FROM Counter WHERE N > ?`, 0)
+	if err == nil {
+		for rows.Next() {
+		}
+		err = rows.Err()
+		_ = rows.Close()
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to cancel the query, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package dialect
+
+import (
+	"context"
+	"time"
+)
+
+// Observer receives the duration of every statement run through DB, with the repository and method
+// named by Operation, e.g. "post" and "GetPosts".
+type Observer func(repository string, method string, elapsed time.Duration)
+
+type operationKey struct{}
+
+type operation struct {
+	repository string
+	method     string
+}
+
+// Operation names the repository method that runs the statements of ctx, every repository method starts with it.
+func Operation(ctx context.Context, repository string, method string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation{repository: repository, method: method})
+}
+
+func (db *DB) SetObserver(observer Observer) {
+	db.observer = observer
+}
+
+// observe reports the statements run outside of a repository method as "unknown".
+func (db *DB) observe(ctx context.Context, start time.Time) {
+	if db.observer == nil {
+		return
+	}
+	op, ok := ctx.Value(operationKey{}).(operation)
+	if !ok {
+		op = operation{repository: "unknown", method: "unknown"}
+	}
+	db.observer(op.repository, op.method, time.Since(start))
+}
@@ -0,0 +1,45 @@
+package dialect
+
+import (
+	"errors"
+	"github.com/lib/pq"
+	"strconv"
+)
+
+type postgres struct{}
+
+func Postgres() Dialect {
+	return postgres{}
+}
+
+func (postgres) Name() string {
+	return NamePostgres
+}
+
+func (postgres) DriverName() string {
+	return "postgres"
+}
+
+func (postgres) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (postgres) Date(column string) string {
+	return "to_char(" + column + ", 'YYYY-MM-DD')"
+}
+
+func (postgres) ReturningID() bool {
+	return true
+}
+
+func (postgres) DSN(dsn string) string {
+	return dsn
+}
+
+func (postgres) UniqueViolation(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return pqErr.Constraint, true
+	}
+	return "", false
+}
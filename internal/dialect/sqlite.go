@@ -0,0 +1,62 @@
+package dialect
+
+import (
+	"errors"
+	"github.com/mattn/go-sqlite3"
+	"strings"
+)
+
+// sqliteOptions make concurrent writers wait for each other instead of failing with "database is locked".
+// Transactions take the write lock on BEGIN, as a deferred transaction that reads first cannot
+// upgrade to a writer while another connection holds the lock, and the busy timeout does not help it.
+// Foreign keys are turned on for every connection of the pool, the purges rely on their cascades.
+var sqliteOptions = []string{"_txlock=immediate", "_busy_timeout=5000", "_journal_mode=WAL", "_foreign_keys=1"}
+
+type sqlite struct{}
+
+func SQLite() Dialect {
+	return sqlite{}
+}
+
+func (sqlite) Name() string {
+	return NameSQLite
+}
+
+func (sqlite) DriverName() string {
+	return "sqlite3"
+}
+
+func (sqlite) Placeholder(int) string {
+	return "?"
+}
+
+func (sqlite) Date(column string) string {
+	return "strftime('%Y-%m-%d', " + column + ")"
+}
+
+func (sqlite) ReturningID() bool {
+	return false
+}
+
+func (sqlite) DSN(dsn string) string {
+	for _, option := range sqliteOptions {
+		key := option[:strings.Index(option, "=")+1]
+		if strings.Contains(dsn, "?"+key) || strings.Contains(dsn, "&"+key) {
+			continue
+		}
+		if strings.Contains(dsn, "?") {
+			dsn += "&" + option
+		} else {
+			dsn += "?" + option
+		}
+	}
+	return dsn
+}
+
+func (sqlite) UniqueViolation(err error) (string, bool) {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return sqliteErr.Error(), true
+	}
+	return "", false
+}
@@ -0,0 +1,54 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+)
+
+// UnitOfWork groups the calls a service makes to one or more repositories so they commit or
+// roll back together.
+type UnitOfWork interface {
+	Transact(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+// querier is what *sql.DB and *sql.Tx have in common.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Transact runs fn in a transaction, every statement run through db with the context given to fn
+// joins it. The transaction commits when fn returns nil and rolls back otherwise, a Transact
+// inside fn joins the outer transaction.
+func (db *DB) Transact(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_ = tx.Rollback()
+			panic(recovered)
+		}
+	}()
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// querier returns the transaction of ctx, or the pool outside of one.
+func (db *DB) querier(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db.DB
+}
@@ -0,0 +1,158 @@
+package dialect
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+type argTransact struct {
+	name     string
+	dialect  Dialect
+	expect   func(mock sqlmock.Sqlmock)
+	fn       func(ctx context.Context, db *DB) error
+	hasError error
+}
+
+func TestTransact(t *testing.T) {
+	test := []argTransact{
+		{
+			name:    "Transact() commits the statements of fn",
+			dialect: SQLite(),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM Posts WHERE ID = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM Users WHERE ID = \\?").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			fn: func(ctx context.Context, db *DB) error {
+				_, err := db.ExecContext(ctx, "DELETE FROM Posts WHERE ID = ?", 1)
+				if err != nil {
+					return err
+				}
+				_, err = db.ExecContext(ctx, "DELETE FROM Users WHERE ID = ?", 2)
+				return err
+			},
+			hasError: nil,
+		},
+		{
+			name:    "Transact() rolls back when fn fails",
+			dialect: SQLite(),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM Posts WHERE ID = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectRollback()
+			},
+			fn: func(ctx context.Context, db *DB) error {
+				_, err := db.ExecContext(ctx, "DELETE FROM Posts WHERE ID = ?", 1)
+				if err != nil {
+					return err
+				}
+				return errors.New("the check is failed")
+			},
+			hasError: errors.New("the check is failed"),
+		},
+		{
+			name:    "Transact() inside fn joins the outer transaction",
+			dialect: Postgres(),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM Posts WHERE ID = \\$1").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM Users WHERE ID = \\$1").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			fn: func(ctx context.Context, db *DB) error {
+				_, err := db.ExecContext(ctx, "DELETE FROM Posts WHERE ID = ?", 1)
+				if err != nil {
+					return err
+				}
+				return db.Transact(ctx, func(ctx context.Context) error {
+					_, err := db.ExecContext(ctx, "DELETE FROM Users WHERE ID = ?", 2)
+					return err
+				})
+			},
+			hasError: nil,
+		},
+		{
+			name:    "Transact() rolls back when the begin succeeds but a statement fails",
+			dialect: Postgres(),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM Posts WHERE ID = \\$1").WithArgs(1).WillReturnError(errors.New("the delete is failed"))
+				mock.ExpectRollback()
+			},
+			fn: func(ctx context.Context, db *DB) error {
+				_, err := db.ExecContext(ctx, "DELETE FROM Posts WHERE ID = ?", 1)
+				return err
+			},
+			hasError: errors.New("the delete is failed"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("the creation of mock is failed %v", err)
+			}
+			defer mockDB.Close()
+			tt.expect(mock)
+			db := New(mockDB, tt.dialect)
+			err = db.Transact(context.Background(), func(ctx context.Context) error {
+				return tt.fn(ctx, db)
+			})
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("there were unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+// TestTransactConcurrentWrites reads then writes from many transactions at once, as the services do,
+// a deferred SQLite transaction would fail them with "database is locked".
+func TestTransactConcurrentWrites(t *testing.T) {
+	db, err := Open(NameSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("the database cannot be opened %v", err)
+	}
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE Posts (ID INTEGER PRIMARY KEY AUTOINCREMENT, Title TEXT NOT NULL)")
+	if err != nil {
+		t.Fatalf("the creation of table is failed %v", err)
+	}
+	const writers = 60
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- db.Transact(context.Background(), func(ctx context.Context) error {
+				var count int
+				err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM Posts").Scan(&count)
+				if err != nil {
+					return err
+				}
+				_, err = db.ExecContext(ctx, "INSERT INTO Posts (Title) VALUES (?)", "title")
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected every transaction to commit, got %v", err)
+		}
+	}
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM Posts").Scan(&count)
+	if err != nil || count != writers {
+		t.Fatalf("expected %d posts, got %d, err %v", writers, count, err)
+	}
+}
@@ -0,0 +1,137 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckTimeout bounds every readiness check.
+const CheckTimeout = 2 * time.Second
+
+// Check tells whether a dependency can serve requests, e.g. (*sql.DB).PingContext.
+type Check func(ctx context.Context) error
+
+// Readiness tells load balancers whether new requests should be routed to this instance.
+// Add the checks before the server starts.
+type Readiness struct {
+	ready  atomic.Bool
+	names  []string
+	checks []Check
+}
+
+type status struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+func (r *Readiness) Ready() bool {
+	return r.ready.Load()
+}
+
+func (r *Readiness) AddCheck(name string, check Check) {
+	r.names = append(r.names, name)
+	r.checks = append(r.checks, check)
+}
+
+// Handler answers 503 while the server starts or drains, or when a check fails.
+func (r *Readiness) Handler(w http.ResponseWriter, req *http.Request) {
+	if !r.Ready() {
+		writeStatus(w, http.StatusServiceUnavailable, status{Status: "unavailable"})
+		return
+	}
+	code := http.StatusOK
+	body := status{Status: "ready", Checks: map[string]string{}}
+	for i, check := range r.checks {
+		ctx, cancel := context.WithTimeout(req.Context(), CheckTimeout)
+		err := check(ctx)
+		cancel()
+		if err != nil {
+			slog.Warn("readiness check failed", "check", r.names[i], "error", err)
+			code = http.StatusServiceUnavailable
+			body.Status = "unavailable"
+			body.Checks[r.names[i]] = err.Error()
+			continue
+		}
+		body.Checks[r.names[i]] = "ok"
+	}
+	writeStatus(w, code, body)
+}
+
+// Healthz answers while the process is alive, it does not look at the dependencies.
+func Healthz(w http.ResponseWriter, _ *http.Request) {
+	writeStatus(w, http.StatusOK, status{Status: "ok"})
+}
+
+func writeStatus(w http.ResponseWriter, code int, body status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+type Options struct {
+	// DrainDelay keeps serving after the instance is marked unready, so load balancers stop routing to it first.
+	DrainDelay      time.Duration
+	ShutdownTimeout time.Duration
+	// Closers are closed in order once every request is done, the database goes here.
+	Closers []io.Closer
+	// Workers run in the background next to the server, e.g. the purge of deleted rows. Their context is
+	// cancelled on shutdown and the closers wait for them to return.
+	Workers []func(ctx context.Context)
+}
+
+// Run serves on listener until ctx is cancelled, then drains the in-flight requests, waits for the workers
+// and closes the resources.
+func Run(ctx context.Context, srv *http.Server, listener net.Listener, readiness *Readiness, opts Options) error {
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	for _, worker := range opts.Workers {
+		workers.Add(1)
+		go func(worker func(ctx context.Context)) {
+			defer workers.Done()
+			worker(workerCtx)
+		}(worker)
+	}
+
+	serveErr := make(chan error, 1)
+	readiness.SetReady(true)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		readiness.SetReady(false)
+	case <-ctx.Done():
+		readiness.SetReady(false)
+		slog.Info("shutting down", "drain_delay", opts.DrainDelay, "timeout", opts.ShutdownTimeout)
+		time.Sleep(opts.DrainDelay)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+		defer cancel()
+		err = srv.Shutdown(shutdownCtx)
+		if err != nil {
+			err = errors.Join(err, srv.Close())
+		}
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	stopWorkers()
+	workers.Wait()
+	for _, closer := range opts.Closers {
+		err = errors.Join(err, closer.Close())
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type closer struct {
+	closed bool
+	err    error
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+	return c.err
+}
+
+func start(t *testing.T, handler http.Handler, opts Options) (string, *Readiness, context.CancelFunc, chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("the listener is failed %v", err)
+	}
+	readiness := &Readiness{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, &http.Server{Handler: handler}, listener, readiness, opts)
+	}()
+	return "http://" + listener.Addr().String(), readiness, cancel, done
+}
+
+func TestRunDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	db := &closer{}
+	url, readiness, cancel, done := start(t, handler, Options{ShutdownTimeout: time.Second, Closers: []io.Closer{db}})
+
+	status := make(chan int, 1)
+	go func() {
+		res, err := http.Get(url)
+		if err != nil {
+			status <- 0
+			return
+		}
+		_ = res.Body.Close()
+		status <- res.StatusCode
+	}()
+	<-started
+	if !readiness.Ready() {
+		t.Fatalf("expected the server to be ready")
+	}
+	cancel()
+
+	err := <-done
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if code := <-status; code != http.StatusNoContent {
+		t.Fatalf("expected the in-flight request to finish with %d, got %d", http.StatusNoContent, code)
+	}
+	if readiness.Ready() {
+		t.Fatalf("expected the server not to be ready")
+	}
+	if !db.closed {
+		t.Fatalf("expected the database to be closed")
+	}
+	_, err = http.Get(url)
+	if err == nil {
+		t.Fatalf("expected the listener to be closed")
+	}
+}
+
+func TestRunShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	db := &closer{err: errors.New("close failed")}
+	url, _, cancel, done := start(t, handler, Options{ShutdownTimeout: 50 * time.Millisecond, Closers: []io.Closer{db}})
+
+	go func() {
+		res, err := http.Get(url)
+		if err == nil {
+			_ = res.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	err := <-done
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline error, got %v", err)
+	}
+	if !db.closed || !errors.Is(err, db.err) {
+		t.Fatalf("expected the database to be closed and its error returned, got %v", err)
+	}
+}
+
+func TestRunWaitsForWorkers(t *testing.T) {
+	db := &closer{}
+	started := make(chan struct{})
+	stopped := false
+	worker := func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		stopped = !db.closed
+	}
+	_, _, cancel, done := start(t, http.NotFoundHandler(), Options{ShutdownTimeout: time.Second, Closers: []io.Closer{db},
+		Workers: []func(ctx context.Context){worker}})
+	<-started
+	cancel()
+
+	err := <-done
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !stopped || !db.closed {
+		t.Fatalf("expected the worker to stop before the database is closed")
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	readiness := &Readiness{}
+	for _, tt := range []struct {
+		ready  bool
+		status int
+	}{
+		{false, http.StatusServiceUnavailable},
+		{true, http.StatusOK},
+	} {
+		readiness.SetReady(tt.ready)
+		rec := httptest.NewRecorder()
+		readiness.Handler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if rec.Code != tt.status {
+			t.Fatalf("expected %d, got %d", tt.status, rec.Code)
+		}
+	}
+}
+
+func TestReadinessChecks(t *testing.T) {
+	readiness := &Readiness{}
+	readiness.SetReady(true)
+	readiness.AddCheck("database", func(ctx context.Context) error { return nil })
+	readiness.AddCheck("cep", func(ctx context.Context) error { return errors.New("the CEP provider answered 502") })
+	rec := httptest.NewRecorder()
+	readiness.Handler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+	expected := `{"status":"unavailable","checks":{"cep":"the CEP provider answered 502","database":"ok"}}` + "\n"
+	if rec.Body.String() != expected {
+		t.Fatalf("expected %s, got %s", expected, rec.Body.String())
+	}
+}
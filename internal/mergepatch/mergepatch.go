@@ -0,0 +1,83 @@
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"reflect"
+	"socialBuddy/internal/apperror"
+)
+
+const ContentType = "application/merge-patch+json"
+
+// Apply merges patch into target following RFC 7396: the members of the patch replace the ones of
+// target, null removes them and nested objects are merged. target is a pointer to the resource,
+// it is read and written through its JSON representation.
+func Apply(target any, patch []byte) error {
+	var patchValue any
+	err := json.Unmarshal(patch, &patchValue)
+	if err != nil {
+		return apperror.Validation("the patch is not valid JSON")
+	}
+	patchObject, ok := patchValue.(map[string]any)
+	if !ok {
+		return apperror.Validation("the patch must be a JSON object")
+	}
+	document, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	var targetObject map[string]any
+	err = json.Unmarshal(document, &targetObject)
+	if err != nil {
+		return err
+	}
+	merged, err := json.Marshal(Merge(targetObject, patchObject))
+	if err != nil {
+		return err
+	}
+	// Members removed by the patch must end up as zero values, not keep the ones of target.
+	value := reflect.ValueOf(target).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	err = decoder.Decode(target)
+	if err != nil {
+		return apperror.Validation(err.Error())
+	}
+	return nil
+}
+
+// Merge is the MergePatch function of RFC 7396 on decoded JSON values.
+func Merge(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = Merge(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// Require answers 415 to a PATCH whose body is not a merge patch, every answer names the accepted
+// type in Accept-Patch.
+func Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Patch", ContentType)
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != ContentType {
+			apperror.Write(w, apperror.Unsupported("the patch must be "+ContentType))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
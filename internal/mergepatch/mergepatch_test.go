@@ -0,0 +1,112 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"socialBuddy/internal/apperror"
+	"testing"
+)
+
+type argMerge struct {
+	target string
+	patch  string
+	output string
+}
+
+// The examples of RFC 7396, appendix A.
+func TestMerge(t *testing.T) {
+	test := []argMerge{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range test {
+		t.Run(tt.patch, func(t *testing.T) {
+			var target, patch, output any
+			_ = json.Unmarshal([]byte(tt.target), &target)
+			_ = json.Unmarshal([]byte(tt.patch), &patch)
+			_ = json.Unmarshal([]byte(tt.output), &output)
+			merged := Merge(target, patch)
+			if !reflect.DeepEqual(merged, output) {
+				t.Fatalf("expected %v, got %v", output, merged)
+			}
+		})
+	}
+}
+
+type address struct {
+	City   string `json:"city"`
+	Street string `json:"street"`
+}
+
+type resource struct {
+	Name    string  `json:"name"`
+	Age     int     `json:"age"`
+	Address address `json:"address"`
+}
+
+func TestApply(t *testing.T) {
+	target := resource{Name: "Name First", Age: 30, Address: address{City: "São Paulo", Street: "Praça da Sé"}}
+	err := Apply(&target, []byte(`{"age": 31, "address": {"street": null}}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := resource{Name: "Name First", Age: 31, Address: address{City: "São Paulo"}}
+	if !reflect.DeepEqual(target, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, target)
+	}
+
+	for _, patch := range []string{`{"age": "old"}`, `["age"]`, `{"age": 31`} {
+		err = Apply(&target, []byte(patch))
+		if !errors.Is(err, apperror.ErrValidation) {
+			t.Fatalf("expected a validation error for %s, got %v", patch, err)
+		}
+	}
+}
+
+func TestRequire(t *testing.T) {
+	handler := Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	test := []struct {
+		name        string
+		contentType string
+		status      int
+	}{
+		{name: "Require() a merge patch", contentType: ContentType, status: http.StatusOK},
+		{name: "Require() a merge patch with a charset", contentType: ContentType + "; charset=utf-8", status: http.StatusOK},
+		{name: "Require() a JSON body", contentType: "application/json", status: http.StatusUnsupportedMediaType},
+		{name: "Require() without a content type", status: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/v1/post/1", nil)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if rec.Header().Get("Accept-Patch") != ContentType {
+				t.Fatalf("expected Accept-Patch %s, got %q", ContentType, rec.Header().Get("Accept-Patch"))
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics holds the collectors of the service in their own registry, served by Handler.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	cepLookups      *prometheus.CounterVec
+	cepAnswers      *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "socialbuddy_http_requests_total",
+			Help: "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "socialbuddy_http_request_duration_seconds",
+			Help:    "HTTP request latency by method and route pattern.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "socialbuddy_db_query_duration_seconds",
+			Help:    "Database statement latency by repository and method.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"repository", "method"}),
+		cepLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "socialbuddy_cep_lookups_total",
+			Help: "CEP lookups by provider and result, a failure is a lookup the provider could not answer.",
+		}, []string{"provider", "result"}),
+		cepAnswers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "socialbuddy_cep_answers_total",
+			Help: "CEP lookups by the provider whose address was returned once the chain fell back.",
+		}, []string{"provider"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		m.cepLookups,
+		m.cepAnswers,
+	)
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records the requests under their chi route pattern, so /v1/post/{id} is one series whatever the id.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil && routeCtx.RoutePattern() != "" {
+			route = routeCtx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		m.requests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		m.requestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveQuery matches dialect.Observer.
+func (m *Metrics) ObserveQuery(repository string, method string, elapsed time.Duration) {
+	m.queryDuration.WithLabelValues(repository, method).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) ObserveCEP(provider string, success bool) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	m.cepLookups.WithLabelValues(provider, result).Inc()
+}
+
+// ObserveCEPAnswer counts the provider that answered a lookup of the CEP chain.
+func (m *Metrics) ObserveCEPAnswer(provider string) {
+	m.cepAnswers.WithLabelValues(provider).Inc()
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"github.com/go-chi/chi/v5"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("the scrape is failed %v", err)
+	}
+	return string(body)
+}
+
+func TestMiddleware(t *testing.T) {
+	m := New()
+	router := chi.NewRouter()
+	router.Use(m.Middleware)
+	router.Get("/v1/post/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	for _, path := range []string{"/v1/post/1", "/v1/post/2", "/nothing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t, m)
+	for _, series := range []string{
+		`socialbuddy_http_requests_total{method="GET",route="/v1/post/{id}",status="404"} 2`,
+		`socialbuddy_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`socialbuddy_http_request_duration_seconds_count{method="GET",route="/v1/post/{id}"} 2`,
+	} {
+		if !strings.Contains(body, series) {
+			t.Fatalf("expected the series %s in\n%s", series, body)
+		}
+	}
+}
+
+func TestObserve(t *testing.T) {
+	m := New()
+	m.ObserveQuery("post", "GetPosts", 3*time.Millisecond)
+	m.ObserveCEP("viacep", true)
+	m.ObserveCEP("viacep", false)
+	m.ObserveCEP("brasilapi", false)
+	m.ObserveCEPAnswer("brasilapi")
+
+	body := scrape(t, m)
+	for _, series := range []string{
+		`socialbuddy_db_query_duration_seconds_count{method="GetPosts",repository="post"} 1`,
+		`socialbuddy_cep_lookups_total{provider="viacep",result="success"} 1`,
+		`socialbuddy_cep_lookups_total{provider="viacep",result="failure"} 1`,
+		`socialbuddy_cep_lookups_total{provider="brasilapi",result="failure"} 1`,
+		`socialbuddy_cep_answers_total{provider="brasilapi"} 1`,
+	} {
+		if !strings.Contains(body, series) {
+			t.Fatalf("expected the series %s in\n%s", series, body)
+		}
+	}
+}
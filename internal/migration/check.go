@@ -0,0 +1,88 @@
+package migration
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// checks run before the up script of the migration with the same name, in its transaction, so a
+// migration that cannot apply to the existing rows stops with an error that lists them.
+var checks = map[string]func(tx *sql.Tx) error{
+	"add_user_unique_indexes": checkUniqueUsers,
+	"normalize_user_email":    checkUniqueEmails,
+}
+
+// checkUniqueUsers lists the users that share an email or a document number, the unique indexes
+// cannot be created until they are merged or changed by hand.
+func checkUniqueUsers(tx *sql.Tx) error {
+	var conflicts []string
+	for _, unique := range [][2]string{{"Email", ""}, {"DocumentNumber", " WHERE DocumentNumber <> ''"}} {
+		found, err := findDuplicates(tx, unique[0], unique[0], unique[1])
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, found...)
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("the users must have a unique email and document number, fix them before migrating: %s",
+			strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// checkUniqueEmails lists the users whose emails only differ by case or surrounding spaces, they
+// would share the same email once it is lowercased and trimmed.
+func checkUniqueEmails(tx *sql.Tx) error {
+	conflicts, err := findDuplicates(tx, "LOWER(TRIM(Email))", "Email", "")
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("the users must have an email that is unique regardless of case, fix them before migrating: %s",
+			strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// findDuplicates describes every value of expression shared by more than one user, label names it.
+func findDuplicates(tx *sql.Tx, expression string, label string, filter string) ([]string, error) {
+	rows, err := tx.Query(`SELECT ` + expression + `, ID FROM Users WHERE ` + expression + ` IN (
+		SELECT ` + expression + ` FROM Users` + filter + ` GROUP BY ` + expression + ` HAVING COUNT(*) > 1)
+		ORDER BY ` + expression + `, ID`)
+	if err != nil {
+		return nil, err
+	}
+	duplicated, err := scanDuplicates(rows)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []string
+	for _, duplicate := range duplicated {
+		conflicts = append(conflicts, fmt.Sprintf("the users %s share the %s %q",
+			strings.Join(duplicate.ids, ", "), label, duplicate.value))
+	}
+	return conflicts, nil
+}
+
+type duplicate struct {
+	value string
+	ids   []string
+}
+
+func scanDuplicates(rows *sql.Rows) ([]duplicate, error) {
+	defer rows.Close()
+	var duplicated []duplicate
+	for rows.Next() {
+		var value, id string
+		err := rows.Scan(&value, &id)
+		if err != nil {
+			return nil, err
+		}
+		if len(duplicated) == 0 || duplicated[len(duplicated)-1].value != value {
+			duplicated = append(duplicated, duplicate{value: value})
+		}
+		duplicated[len(duplicated)-1].ids = append(duplicated[len(duplicated)-1].ids, id)
+	}
+	return duplicated, rows.Err()
+}
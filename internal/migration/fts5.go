@@ -0,0 +1,19 @@
+//go:build sqlite_fts5 || fts5
+
+package migration
+
+import (
+	"embed"
+)
+
+// The full-text search index needs go-sqlite3 built with FTS5, so its migrations are only
+// compiled in with the same build tag (go build -tags sqlite_fts5). Versions in sql_fts5
+// share the numbering of sql and must not be reused there. Once applied, the triggers on Posts
+// and Comment need FTS5, so the binary must keep being built with the tag.
+
+//go:embed sql_fts5/*.sql
+var fts5Files embed.FS
+
+func init() {
+	sources = append(sources, source{fts5Files, "sql_fts5"})
+}
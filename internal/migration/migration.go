@@ -0,0 +1,303 @@
+package migration
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"socialBuddy/internal/dialect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed sql/*.sql
+var sqliteFiles embed.FS
+
+//go:embed sql_postgres/*.sql
+var postgresFiles embed.FS
+
+type source struct {
+	fsys fs.FS
+	dir  string
+}
+
+// sources lists the embedded migration directories, build tagged files may add their own.
+var sources = []source{{sqliteFiles, "sql"}}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Migrator interface {
+	Up() (int, error)
+	Down(steps int) (int, error)
+	Status() ([]Status, error)
+	Version() (int, error)
+}
+
+type migrator struct {
+	db         *dialect.DB
+	migrations []Migration
+}
+
+// SQLite returns the embedded migrations, ordered by version.
+func SQLite() ([]Migration, error) {
+	var migrations []Migration
+	for _, src := range sources {
+		loaded, err := Load(src.fsys, src.dir)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, loaded...)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Postgres returns the embedded PostgreSQL migrations, they keep the versions of the SQLite ones.
+func Postgres() ([]Migration, error) {
+	return Load(postgresFiles, "sql_postgres")
+}
+
+// For returns the migrations written for the dialect.
+func For(d dialect.Dialect) ([]Migration, error) {
+	switch d.Name() {
+	case dialect.NameSQLite:
+		return SQLite()
+	case dialect.NamePostgres:
+		return Postgres()
+	default:
+		return nil, fmt.Errorf("there are no migrations for the dialect %q", d.Name())
+	}
+}
+
+// Load reads every NNNN_name.up.sql / NNNN_name.down.sql pair found in dir.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, match[2])
+		}
+		if match[3] == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func (m *migrator) Up() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		err = m.run(checks[mig.Name], mig.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(m.db.Rebind("INSERT INTO schema_version (Version, Name, AppliedAt) VALUES (?, ?, ?)"),
+				mig.Version, mig.Name, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (m *migrator) Down(steps int) (int, error) {
+	if steps < 1 {
+		return 0, errors.New("the number of steps must be positive")
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if mig.Down == "" {
+			return count, fmt.Errorf("migration %d_%s cannot be reverted", mig.Version, mig.Name)
+		}
+		err = m.run(nil, mig.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(m.db.Rebind("DELETE FROM schema_version WHERE Version = ?"), mig.Version)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (m *migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var listStatus []Status
+	for _, mig := range m.migrations {
+		status := Status{Version: mig.Version, Name: mig.Name}
+		if appliedAt, ok := applied[mig.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		listStatus = append(listStatus, status)
+	}
+	return listStatus, nil
+}
+
+func (m *migrator) Version() (int, error) {
+	err := m.createTable()
+	if err != nil {
+		return 0, err
+	}
+	var version sql.NullInt64
+	err = m.db.QueryRow("SELECT MAX(Version) FROM schema_version").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// run applies script in a transaction, after check when there is one and before record.
+func (m *migrator) run(check func(tx *sql.Tx) error, script string, record func(tx *sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	if check != nil {
+		err = check(tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	_, err = tx.Exec(script)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	err = record(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *migrator) applied() (map[int]time.Time, error) {
+	err := m.validate()
+	if err != nil {
+		return nil, err
+	}
+	err = m.createTable()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := m.db.Query("SELECT Version, AppliedAt FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		err := rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func (m *migrator) createTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+    Version   INTEGER PRIMARY KEY,
+    Name      TEXT NOT NULL,
+    AppliedAt TIMESTAMP NOT NULL
+)`)
+	return err
+}
+
+func (m *migrator) validate() error {
+	for i, mig := range m.migrations {
+		if mig.Version < 1 {
+			return fmt.Errorf("migration %s has an invalid version %d", mig.Name, mig.Version)
+		}
+		if i > 0 && m.migrations[i-1].Version == mig.Version {
+			return fmt.Errorf("migration version %d is duplicated", mig.Version)
+		}
+	}
+	return nil
+}
+
+func NewMigrator(db *dialect.DB, migrations []Migration) Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return &migrator{db, sorted}
+}
@@ -0,0 +1,317 @@
+package migration
+
+import (
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"path/filepath"
+	"socialBuddy/internal/dialect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type argLoad struct {
+	name     string
+	files    fstest.MapFS
+	versions []int
+	hasError bool
+}
+
+func openDB(t *testing.T) *dialect.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("the creation of database is failed %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return dialect.New(db, dialect.SQLite())
+}
+
+func tableExists(t *testing.T, db *dialect.DB, table string) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+	if err != nil {
+		t.Fatalf("the query of sqlite_master is failed %v", err)
+	}
+	return count > 0
+}
+
+func TestLoad(t *testing.T) {
+	test := []argLoad{
+		{
+			name: "Load() orders the migrations by version",
+			files: fstest.MapFS{
+				"sql/0002_second.up.sql":   {Data: []byte("CREATE TABLE B (ID INTEGER)")},
+				"sql/0002_second.down.sql": {Data: []byte("DROP TABLE B")},
+				"sql/0001_first.up.sql":    {Data: []byte("CREATE TABLE A (ID INTEGER)")},
+				"sql/README.md":            {Data: []byte("ignored")},
+			},
+			versions: []int{1, 2},
+			hasError: false,
+		},
+		{
+			name: "Load() without up script",
+			files: fstest.MapFS{
+				"sql/0001_first.down.sql": {Data: []byte("DROP TABLE A")},
+			},
+			hasError: true,
+		},
+		{
+			name: "Load() with two names for the same version",
+			files: fstest.MapFS{
+				"sql/0001_first.up.sql":   {Data: []byte("CREATE TABLE A (ID INTEGER)")},
+				"sql/0001_other.down.sql": {Data: []byte("DROP TABLE A")},
+			},
+			hasError: true,
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files, "sql")
+			if (err != nil) != tt.hasError {
+				t.Fatalf("expected error %v, got %v", tt.hasError, err)
+			}
+			if len(migrations) != len(tt.versions) {
+				t.Fatalf("expected %d migrations, got %d", len(tt.versions), len(migrations))
+			}
+			for i, version := range tt.versions {
+				if migrations[i].Version != version {
+					t.Fatalf("expected version %d at %d, got %d", version, i, migrations[i].Version)
+				}
+			}
+		})
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := openDB(t)
+	migrator := NewMigrator(db, []Migration{
+		{Version: 2, Name: "second", Up: "CREATE TABLE B (ID INTEGER)", Down: "DROP TABLE B"},
+		{Version: 1, Name: "first", Up: "CREATE TABLE A (ID INTEGER)", Down: "DROP TABLE A"},
+	})
+
+	applied, err := migrator.Up()
+	if err != nil || applied != 2 {
+		t.Fatalf("expected 2 applied migrations, got %d, err %v", applied, err)
+	}
+	applied, err = migrator.Up()
+	if err != nil || applied != 0 {
+		t.Fatalf("expected no pending migrations, got %d, err %v", applied, err)
+	}
+	version, err := migrator.Version()
+	if err != nil || version != 2 {
+		t.Fatalf("expected version 2, got %d, err %v", version, err)
+	}
+
+	reverted, err := migrator.Down(1)
+	if err != nil || reverted != 1 {
+		t.Fatalf("expected 1 reverted migration, got %d, err %v", reverted, err)
+	}
+	if tableExists(t, db, "B") || !tableExists(t, db, "A") {
+		t.Fatalf("expected only table A after reverting one migration")
+	}
+
+	listStatus, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !listStatus[0].Applied || listStatus[1].Applied {
+		t.Fatalf("expected first applied and second pending, got %+v", listStatus)
+	}
+}
+
+func TestUpRollsBackFailedMigration(t *testing.T) {
+	db := openDB(t)
+	migrator := NewMigrator(db, []Migration{
+		{Version: 1, Name: "first", Up: "CREATE TABLE A (ID INTEGER)"},
+		{Version: 2, Name: "broken", Up: "CREATE TABLE B (ID INTEGER); CREATE TABLE"},
+	})
+
+	applied, err := migrator.Up()
+	if err == nil || applied != 1 {
+		t.Fatalf("expected the second migration to fail, got %d, err %v", applied, err)
+	}
+	if tableExists(t, db, "B") {
+		t.Fatalf("expected the broken migration to be rolled back")
+	}
+	version, err := migrator.Version()
+	if err != nil || version != 1 {
+		t.Fatalf("expected version 1, got %d, err %v", version, err)
+	}
+}
+
+func TestDownWithoutScript(t *testing.T) {
+	db := openDB(t)
+	migrator := NewMigrator(db, []Migration{
+		{Version: 1, Name: "first", Up: "CREATE TABLE A (ID INTEGER)"},
+	})
+	_, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, err = migrator.Down(1)
+	if err == nil {
+		t.Fatalf("expected error reverting a migration without down script")
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	db := openDB(t)
+	migrations, err := SQLite()
+	if err != nil {
+		t.Fatalf("the load of migrations is failed %v", err)
+	}
+	migrator := NewMigrator(db, migrations)
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, table := range []string{"Users", "Posts", "Comment", "Connection"} {
+		if !tableExists(t, db, table) {
+			t.Fatalf("expected table %s to exist", table)
+		}
+	}
+	_, err = migrator.Down(len(migrations))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if tableExists(t, db, "Users") {
+		t.Fatalf("expected table Users to be dropped")
+	}
+}
+
+func TestSQLiteMigrationsOnExistingDatabase(t *testing.T) {
+	db := openDB(t)
+	_, err := db.Exec(`CREATE TABLE Users (ID INTEGER PRIMARY KEY AUTOINCREMENT, Name TEXT, DocumentNumber TEXT, Email TEXT);
+INSERT INTO Users (Name) VALUES ('Name First')`)
+	if err != nil {
+		t.Fatalf("the creation of legacy table is failed %v", err)
+	}
+	migrations, err := SQLite()
+	if err != nil {
+		t.Fatalf("the load of migrations is failed %v", err)
+	}
+	_, err = NewMigrator(db, migrations).Up()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM Users").Scan(&count)
+	if err != nil || count != 1 {
+		t.Fatalf("expected the existing user to be kept, got %d, err %v", count, err)
+	}
+}
+
+func TestSQLiteMigrationsWithDuplicatedUsers(t *testing.T) {
+	db := openDB(t)
+	migrations, err := SQLite()
+	if err != nil {
+		t.Fatalf("the load of migrations is failed %v", err)
+	}
+	var before []Migration
+	for _, mig := range migrations {
+		if mig.Name == "add_user_unique_indexes" {
+			break
+		}
+		before = append(before, mig)
+	}
+	_, err = NewMigrator(db, before).Up()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO Users (Name, Age, DocumentNumber, Email, Phone, ZipCode, Country, State, City,
+		Neighborhood, Street, Number, Complement, PasswordHash) VALUES
+		('first', 30, '11144477735', 'a@gmail.com', '', '', '', '', '', '', '', '', '', ''),
+		('second', 30, '11144477735', 'a@gmail.com', '', '', '', '', '', '', '', '', '', ''),
+		('third', 30, '', 'b@gmail.com', '', '', '', '', '', '', '', '', '', ''),
+		('fourth', 30, '', 'c@gmail.com', '', '', '', '', '', '', '', '', '', '')`)
+	if err != nil {
+		t.Fatalf("the creation of users is failed %v", err)
+	}
+
+	_, err = NewMigrator(db, migrations).Up()
+	if err == nil || !strings.Contains(err.Error(), `the users 1, 2 share the Email "a@gmail.com"; the users 1, 2 share the DocumentNumber "11144477735"`) {
+		t.Fatalf("expected the duplicated users to be listed, got %v", err)
+	}
+	version, err := NewMigrator(db, migrations).Version()
+	if err != nil || version != before[len(before)-1].Version {
+		t.Fatalf("expected version %d, got %d, err %v", before[len(before)-1].Version, version, err)
+	}
+
+	_, err = db.Exec("UPDATE Users SET Email = 'd@gmail.com', DocumentNumber = '' WHERE ID = 2")
+	if err != nil {
+		t.Fatalf("the update of user is failed %v", err)
+	}
+	_, err = NewMigrator(db, migrations).Up()
+	if err != nil {
+		t.Fatalf("expected no error once the users are unique, got %v", err)
+	}
+}
+
+func TestSQLiteMigrationsWithEmailsInAnotherCase(t *testing.T) {
+	db := openDB(t)
+	migrations, err := SQLite()
+	if err != nil {
+		t.Fatalf("the load of migrations is failed %v", err)
+	}
+	var before []Migration
+	for _, mig := range migrations {
+		if mig.Name == "normalize_user_email" {
+			break
+		}
+		before = append(before, mig)
+	}
+	_, err = NewMigrator(db, before).Up()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO Users (Name, Age, DocumentNumber, Email, Phone, ZipCode, Country, State, City,
+		Neighborhood, Street, Number, Complement, PasswordHash) VALUES
+		('first', 30, '', 'a@gmail.com', '', '', '', '', '', '', '', '', '', ''),
+		('second', 30, '', 'A@Gmail.com', '', '', '', '', '', '', '', '', '', ''),
+		('third', 30, '', ' B@gmail.com', '', '', '', '', '', '', '', '', '', '')`)
+	if err != nil {
+		t.Fatalf("the creation of users is failed %v", err)
+	}
+
+	_, err = NewMigrator(db, migrations).Up()
+	if err == nil || !strings.Contains(err.Error(), `the users 1, 2 share the Email "a@gmail.com"`) {
+		t.Fatalf("expected the users with the same email to be listed, got %v", err)
+	}
+
+	_, err = db.Exec("UPDATE Users SET Email = 'd@gmail.com' WHERE ID = 2")
+	if err != nil {
+		t.Fatalf("the update of user is failed %v", err)
+	}
+	_, err = NewMigrator(db, migrations).Up()
+	if err != nil {
+		t.Fatalf("expected no error once the emails are unique, got %v", err)
+	}
+	var email string
+	err = db.QueryRow("SELECT Email FROM Users WHERE ID = 3").Scan(&email)
+	if err != nil || email != "b@gmail.com" {
+		t.Fatalf("expected the email to be lowercased and trimmed, got %q, err %v", email, err)
+	}
+}
+
+func TestPostgresMigrationsMatchSQLite(t *testing.T) {
+	// the FTS5 migrations only exist on SQLite, the ones of the sql directory must all be ported
+	sqliteMigrations, err := Load(sqliteFiles, "sql")
+	if err != nil {
+		t.Fatalf("the load of migrations is failed %v", err)
+	}
+	postgresMigrations, err := Postgres()
+	if err != nil {
+		t.Fatalf("the load of migrations is failed %v", err)
+	}
+	if len(postgresMigrations) != len(sqliteMigrations) {
+		t.Fatalf("expected %d Postgres migrations, got %d", len(sqliteMigrations), len(postgresMigrations))
+	}
+	for i, mig := range postgresMigrations {
+		if mig.Version != sqliteMigrations[i].Version || mig.Name != sqliteMigrations[i].Name {
+			t.Fatalf("expected migration %d_%s, got %d_%s",
+				sqliteMigrations[i].Version, sqliteMigrations[i].Name, mig.Version, mig.Name)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS Connection;
+DROP TABLE IF EXISTS Comment;
+DROP TABLE IF EXISTS Posts;
+DROP TABLE IF EXISTS Users;
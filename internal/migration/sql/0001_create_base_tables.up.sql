@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS Users (
+    ID             INTEGER PRIMARY KEY AUTOINCREMENT,
+    Name           TEXT,
+    Age            INTEGER,
+    DocumentNumber TEXT,
+    Email          TEXT,
+    Phone          TEXT,
+    ZipCode        TEXT,
+    Country        TEXT,
+    State          TEXT,
+    City           TEXT,
+    Neighborhood   TEXT,
+    Street         TEXT,
+    Number         TEXT,
+    Complement     TEXT
+);
+
+CREATE TABLE IF NOT EXISTS Posts (
+    ID       INTEGER PRIMARY KEY AUTOINCREMENT,
+    IDUser   INTEGER,
+    DatePost DATE,
+    Title    TEXT,
+    Content  TEXT,
+    FOREIGN KEY (IDUser) REFERENCES Users (ID) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS Comment (
+    ID          INTEGER PRIMARY KEY AUTOINCREMENT,
+    IDPost      INTEGER,
+    IDUser      INTEGER,
+    DateComment DATE,
+    Content     TEXT,
+    FOREIGN KEY (IDPost) REFERENCES Posts (ID) ON DELETE CASCADE,
+    FOREIGN KEY (IDUser) REFERENCES Users (ID) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS Connection (
+    ID          INTEGER PRIMARY KEY AUTOINCREMENT,
+    IdFollower  INTEGER,
+    IdFollowing INTEGER,
+    FOREIGN KEY (IdFollower) REFERENCES Users (ID) ON DELETE CASCADE,
+    FOREIGN KEY (IdFollowing) REFERENCES Users (ID) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_comment_iduser;
+DROP INDEX IF EXISTS idx_comment_idpost;
+DROP INDEX IF EXISTS idx_posts_iduser;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_refreshtoken_iduser;
+DROP TABLE IF EXISTS RefreshToken;
+ALTER TABLE Users DROP COLUMN PasswordHash;
@@ -0,0 +1,11 @@
+ALTER TABLE Users ADD COLUMN PasswordHash TEXT;
+
+CREATE TABLE IF NOT EXISTS RefreshToken (
+    ID        INTEGER PRIMARY KEY AUTOINCREMENT,
+    IDUser    INTEGER NOT NULL,
+    TokenHash TEXT NOT NULL UNIQUE,
+    ExpiresAt TIMESTAMP NOT NULL,
+    FOREIGN KEY (IDUser) REFERENCES Users (ID) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refreshtoken_iduser ON RefreshToken (IDUser);
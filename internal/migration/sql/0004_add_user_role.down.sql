@@ -0,0 +1 @@
+ALTER TABLE Users DROP COLUMN Role;
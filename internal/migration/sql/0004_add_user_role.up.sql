@@ -0,0 +1 @@
+ALTER TABLE Users ADD COLUMN Role TEXT NOT NULL DEFAULT 'user';
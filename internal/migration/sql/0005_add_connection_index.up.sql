@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_connection_idfollower ON Connection (IdFollower, IdFollowing);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS comment_reaction_delete;
+DROP TRIGGER IF EXISTS posts_reaction_delete;
+DROP INDEX IF EXISTS idx_reaction_target;
+DROP TABLE IF EXISTS Reaction;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS Reaction (
+    ID           INTEGER PRIMARY KEY AUTOINCREMENT,
+    IDUser       INTEGER NOT NULL,
+    TargetType   TEXT NOT NULL,
+    IDTarget     INTEGER NOT NULL,
+    Type         TEXT NOT NULL,
+    DateReaction TIMESTAMP NOT NULL,
+    UNIQUE (IDUser, TargetType, IDTarget),
+    FOREIGN KEY (IDUser) REFERENCES Users (ID) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_reaction_target ON Reaction (TargetType, IDTarget, Type);
+
+CREATE TRIGGER IF NOT EXISTS posts_reaction_delete AFTER DELETE ON Posts BEGIN
+    DELETE FROM Reaction WHERE TargetType = 'post' AND IDTarget = old.ID;
+END;
+CREATE TRIGGER IF NOT EXISTS comment_reaction_delete AFTER DELETE ON Comment BEGIN
+    DELETE FROM Reaction WHERE TargetType = 'comment' AND IDTarget = old.ID;
+END;
@@ -0,0 +1,3 @@
+ALTER TABLE Comment ADD COLUMN IDParent INTEGER REFERENCES Comment (ID) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_comment_idparent ON Comment (IDParent);
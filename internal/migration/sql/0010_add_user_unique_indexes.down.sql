@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_documentnumber;
+DROP INDEX IF EXISTS idx_users_email;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_connection_idfollowing;
+DROP INDEX IF EXISTS idx_connection_idfollower;
+CREATE INDEX IF NOT EXISTS idx_connection_idfollower ON Connection (IdFollower, IdFollowing);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS comment_search_update;
+DROP TRIGGER IF EXISTS comment_search_delete;
+DROP TRIGGER IF EXISTS comment_search_insert;
+DROP TRIGGER IF EXISTS posts_search_update;
+DROP TRIGGER IF EXISTS posts_search_delete;
+DROP TRIGGER IF EXISTS posts_search_insert;
+DROP TABLE IF EXISTS CommentSearch;
+DROP TABLE IF EXISTS PostSearch;
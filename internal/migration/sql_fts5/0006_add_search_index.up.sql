@@ -0,0 +1,27 @@
+CREATE VIRTUAL TABLE IF NOT EXISTS PostSearch USING fts5(Title, Content, content='Posts', content_rowid='ID');
+CREATE VIRTUAL TABLE IF NOT EXISTS CommentSearch USING fts5(Content, content='Comment', content_rowid='ID');
+
+CREATE TRIGGER IF NOT EXISTS posts_search_insert AFTER INSERT ON Posts BEGIN
+    INSERT INTO PostSearch (rowid, Title, Content) VALUES (new.ID, new.Title, new.Content);
+END;
+CREATE TRIGGER IF NOT EXISTS posts_search_delete AFTER DELETE ON Posts BEGIN
+    INSERT INTO PostSearch (PostSearch, rowid, Title, Content) VALUES ('delete', old.ID, old.Title, old.Content);
+END;
+CREATE TRIGGER IF NOT EXISTS posts_search_update AFTER UPDATE ON Posts BEGIN
+    INSERT INTO PostSearch (PostSearch, rowid, Title, Content) VALUES ('delete', old.ID, old.Title, old.Content);
+    INSERT INTO PostSearch (rowid, Title, Content) VALUES (new.ID, new.Title, new.Content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS comment_search_insert AFTER INSERT ON Comment BEGIN
+    INSERT INTO CommentSearch (rowid, Content) VALUES (new.ID, new.Content);
+END;
+CREATE TRIGGER IF NOT EXISTS comment_search_delete AFTER DELETE ON Comment BEGIN
+    INSERT INTO CommentSearch (CommentSearch, rowid, Content) VALUES ('delete', old.ID, old.Content);
+END;
+CREATE TRIGGER IF NOT EXISTS comment_search_update AFTER UPDATE ON Comment BEGIN
+    INSERT INTO CommentSearch (CommentSearch, rowid, Content) VALUES ('delete', old.ID, old.Content);
+    INSERT INTO CommentSearch (rowid, Content) VALUES (new.ID, new.Content);
+END;
+
+INSERT INTO PostSearch (PostSearch) VALUES ('rebuild');
+INSERT INTO CommentSearch (CommentSearch) VALUES ('rebuild');
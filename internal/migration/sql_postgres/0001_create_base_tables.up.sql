@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS Users (
+    ID             SERIAL PRIMARY KEY,
+    Name           TEXT,
+    Age            INTEGER,
+    DocumentNumber TEXT,
+    Email          TEXT,
+    Phone          TEXT,
+    ZipCode        TEXT,
+    Country        TEXT,
+    State          TEXT,
+    City           TEXT,
+    Neighborhood   TEXT,
+    Street         TEXT,
+    Number         TEXT,
+    Complement     TEXT
+);
+
+CREATE TABLE IF NOT EXISTS Posts (
+    ID       SERIAL PRIMARY KEY,
+    IDUser   INTEGER REFERENCES Users (ID) ON DELETE CASCADE,
+    DatePost TIMESTAMPTZ,
+    Title    TEXT,
+    Content  TEXT
+);
+
+CREATE TABLE IF NOT EXISTS Comment (
+    ID          SERIAL PRIMARY KEY,
+    IDPost      INTEGER REFERENCES Posts (ID) ON DELETE CASCADE,
+    IDUser      INTEGER REFERENCES Users (ID) ON DELETE CASCADE,
+    DateComment TIMESTAMPTZ,
+    Content     TEXT
+);
+
+CREATE TABLE IF NOT EXISTS Connection (
+    ID          SERIAL PRIMARY KEY,
+    IdFollower  INTEGER REFERENCES Users (ID) ON DELETE CASCADE,
+    IdFollowing INTEGER REFERENCES Users (ID) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_posts_iduser ON Posts (IDUser, ID);
+CREATE INDEX IF NOT EXISTS idx_comment_idpost ON Comment (IDPost, ID);
+CREATE INDEX IF NOT EXISTS idx_comment_iduser ON Comment (IDUser, ID);
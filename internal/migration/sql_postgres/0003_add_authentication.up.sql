@@ -0,0 +1,10 @@
+ALTER TABLE Users ADD COLUMN PasswordHash TEXT;
+
+CREATE TABLE IF NOT EXISTS RefreshToken (
+    ID        SERIAL PRIMARY KEY,
+    IDUser    INTEGER NOT NULL REFERENCES Users (ID) ON DELETE CASCADE,
+    TokenHash TEXT NOT NULL UNIQUE,
+    ExpiresAt TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_refreshtoken_iduser ON RefreshToken (IDUser);
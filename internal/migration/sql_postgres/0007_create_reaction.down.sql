@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS comment_reaction_delete ON Comment;
+DROP TRIGGER IF EXISTS posts_reaction_delete ON Posts;
+DROP FUNCTION IF EXISTS delete_target_reactions();
+DROP INDEX IF EXISTS idx_reaction_target;
+DROP TABLE IF EXISTS Reaction;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS Reaction (
+    ID           SERIAL PRIMARY KEY,
+    IDUser       INTEGER NOT NULL REFERENCES Users (ID) ON DELETE CASCADE,
+    TargetType   TEXT NOT NULL,
+    IDTarget     INTEGER NOT NULL,
+    Type         TEXT NOT NULL,
+    DateReaction TIMESTAMPTZ NOT NULL,
+    UNIQUE (IDUser, TargetType, IDTarget)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reaction_target ON Reaction (TargetType, IDTarget, Type);
+
+CREATE OR REPLACE FUNCTION delete_target_reactions() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM Reaction WHERE TargetType = TG_ARGV[0] AND IDTarget = OLD.ID;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_reaction_delete AFTER DELETE ON Posts
+    FOR EACH ROW EXECUTE FUNCTION delete_target_reactions('post');
+CREATE TRIGGER comment_reaction_delete AFTER DELETE ON Comment
+    FOR EACH ROW EXECUTE FUNCTION delete_target_reactions('comment');
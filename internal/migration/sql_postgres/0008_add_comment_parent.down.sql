@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_comment_idparent;
+
+ALTER TABLE Comment DROP COLUMN IDParent;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS CepCache (
+    ZipCode      TEXT PRIMARY KEY,
+    Country      TEXT NOT NULL,
+    State        TEXT NOT NULL,
+    City         TEXT NOT NULL,
+    Neighborhood TEXT NOT NULL,
+    Street       TEXT NOT NULL,
+    CachedAt     TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON Users (Email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_documentnumber ON Users (DocumentNumber) WHERE DocumentNumber <> '';
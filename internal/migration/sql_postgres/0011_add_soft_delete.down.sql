@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_comment_deletedat;
+DROP INDEX IF EXISTS idx_posts_deletedat;
+DROP INDEX IF EXISTS idx_users_deletedat;
+
+ALTER TABLE Comment DROP COLUMN DeletedAt;
+ALTER TABLE Posts DROP COLUMN DeletedAt;
+ALTER TABLE Users DROP COLUMN DeletedAt;
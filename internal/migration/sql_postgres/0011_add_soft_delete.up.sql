@@ -0,0 +1,7 @@
+ALTER TABLE Users ADD COLUMN DeletedAt TIMESTAMPTZ;
+ALTER TABLE Posts ADD COLUMN DeletedAt TIMESTAMPTZ;
+ALTER TABLE Comment ADD COLUMN DeletedAt TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletedat ON Users (DeletedAt) WHERE DeletedAt IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_deletedat ON Posts (DeletedAt) WHERE DeletedAt IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_comment_deletedat ON Comment (DeletedAt) WHERE DeletedAt IS NOT NULL;
@@ -0,0 +1,4 @@
+DELETE FROM Connection WHERE ID NOT IN (SELECT MIN(ID) FROM Connection GROUP BY IdFollower, IdFollowing);
+DROP INDEX IF EXISTS idx_connection_idfollower;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_connection_idfollower ON Connection (IdFollower, IdFollowing);
+CREATE INDEX IF NOT EXISTS idx_connection_idfollowing ON Connection (IdFollowing);
@@ -0,0 +1,3 @@
+UPDATE Users SET Email = LOWER(TRIM(Email));
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON Users (LOWER(Email));
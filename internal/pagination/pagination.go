@@ -0,0 +1,85 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+	cursorPrefix = "id:"
+)
+
+// Page selects up to Limit rows whose ID comes after the one encoded in the cursor.
+type Page struct {
+	Limit int
+	After int
+}
+
+type Response[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Fetch is the number of rows a repository should read: one extra row tells whether there is a next page.
+func (p Page) Fetch() int {
+	return p.Limit + 1
+}
+
+func FromRequest(r *http.Request) (Page, error) {
+	page := Page{Limit: DefaultLimit}
+	query := r.URL.Query()
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			return Page{}, errors.New("the limit is not valid")
+		}
+		if value > MaxLimit {
+			value = MaxLimit
+		}
+		page.Limit = value
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := DecodeCursor(cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		page.After = after
+	}
+	return page, nil
+}
+
+func EncodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(id)))
+}
+
+func DecodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, errors.New("the cursor is not valid")
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || id < 1 {
+		return 0, errors.New("the cursor is not valid")
+	}
+	return id, nil
+}
+
+// NewResponse trims the extra row read through Fetch and points the cursor at the last returned item.
+func NewResponse[T any](items []T, page Page, id func(T) int) Response[T] {
+	res := Response[T]{Data: items}
+	if len(items) > page.Limit {
+		res.Data = items[:page.Limit]
+		res.NextCursor = EncodeCursor(id(res.Data[len(res.Data)-1]))
+	}
+	if res.Data == nil {
+		res.Data = []T{}
+	}
+	return res
+}
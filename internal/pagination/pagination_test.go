@@ -0,0 +1,109 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type argRequest struct {
+	name     string
+	url      string
+	output   Page
+	hasError bool
+}
+
+type argResponse struct {
+	name   string
+	items  []int
+	page   Page
+	output Response[int]
+}
+
+func TestFromRequest(t *testing.T) {
+	test := []argRequest{
+		{
+			name:   "FromRequest() without parameters",
+			url:    "/v1/user",
+			output: Page{Limit: DefaultLimit},
+		},
+		{
+			name:   "FromRequest() with limit and cursor",
+			url:    "/v1/user?limit=5&cursor=" + EncodeCursor(42),
+			output: Page{Limit: 5, After: 42},
+		},
+		{
+			name:   "FromRequest() with limit above the maximum",
+			url:    "/v1/user?limit=1000",
+			output: Page{Limit: MaxLimit},
+		},
+		{
+			name:     "FromRequest() with invalid limit",
+			url:      "/v1/user?limit=zero",
+			hasError: true,
+		},
+		{
+			name:     "FromRequest() with negative limit",
+			url:      "/v1/user?limit=-1",
+			hasError: true,
+		},
+		{
+			name:     "FromRequest() with invalid cursor",
+			url:      "/v1/user?cursor=42",
+			hasError: true,
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := FromRequest(httptest.NewRequest("GET", tt.url, nil))
+			if (err != nil) != tt.hasError {
+				t.Fatalf("expected error %v, got %v", tt.hasError, err)
+			}
+			if page != tt.output {
+				t.Fatalf("expected %+v, got %+v", tt.output, page)
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	id, err := DecodeCursor(EncodeCursor(7))
+	if err != nil || id != 7 {
+		t.Fatalf("expected 7, got %d, err %v", id, err)
+	}
+	_, err = DecodeCursor(EncodeCursor(0))
+	if err == nil {
+		t.Fatalf("expected error decoding a cursor without id")
+	}
+}
+
+func TestNewResponse(t *testing.T) {
+	test := []argResponse{
+		{
+			name:   "NewResponse() with a next page",
+			items:  []int{1, 2, 3},
+			page:   Page{Limit: 2},
+			output: Response[int]{Data: []int{1, 2}, NextCursor: EncodeCursor(2)},
+		},
+		{
+			name:   "NewResponse() on the last page",
+			items:  []int{1, 2},
+			page:   Page{Limit: 2},
+			output: Response[int]{Data: []int{1, 2}},
+		},
+		{
+			name:   "NewResponse() without items",
+			items:  nil,
+			page:   Page{Limit: 2},
+			output: Response[int]{Data: []int{}},
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			res := NewResponse(tt.items, tt.page, func(item int) int { return item })
+			if !reflect.DeepEqual(res, tt.output) {
+				t.Fatalf("expected %+v, got %+v", tt.output, res)
+			}
+		})
+	}
+}
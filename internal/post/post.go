@@ -1,26 +1,43 @@
 package post
 
 import (
-	"errors"
+	"context"
+	"socialBuddy/internal/apperror"
 	"socialBuddy/internal/user"
+	"strings"
 	"time"
 )
 
 type Post struct {
-	ID      int
-	IDUser  int
-	Date    time.Time
-	Title   string
-	Content string
+	ID        int
+	IDUser    int
+	Date      time.Time
+	Title     string
+	Content   string
+	Reactions map[string]int
+	DeletedAt *time.Time `json:"-"`
 }
 
-func ValidateIDUser(idUser int, serviceUser user.Service) error {
-	userPost, err := serviceUser.GetUserByID(idUser)
+// ReactionCounter counts the reactions of each type on a set of posts or comments.
+type ReactionCounter interface {
+	CountReactions(ctx context.Context, targetType string, ids []int) (map[int]map[string]int, error)
+}
+
+func ValidateIDUser(ctx context.Context, idUser int, serviceUser user.Service) error {
+	_, err := serviceUser.GetUserByID(ctx, idUser)
 	if err != nil {
 		return err
 	}
-	if userPost == nil {
-		return errors.New("the user is not in database")
+	return nil
+}
+
+// postValidation is shared by the creation and every kind of edit of a post.
+func postValidation(post Post) error {
+	if strings.TrimSpace(post.Title) == "" {
+		return apperror.Validation("the title is required")
+	}
+	if strings.TrimSpace(post.Content) == "" {
+		return apperror.Validation("the content is required")
 	}
 	return nil
 }
@@ -1,139 +1,125 @@
 package post
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/pagination"
 	"time"
 )
 
 type Repository interface {
-	CreatePost(post Post) (*Post, error)
-	GetPosts() ([]Post, error)
-	GetPostByID(idPost int) (*Post, error)
-	GetPostByUserID(idUser int) ([]Post, error)
-	GetPostByDate(date time.Time) ([]Post, error)
-	GetPostByTitle(title string) ([]Post, error)
-	EditPost(post Post, idPost int) (*Post, error)
-	DeletePost(idPost int) error
+	CreatePost(ctx context.Context, post Post) (*Post, error)
+	GetPosts(ctx context.Context, page pagination.Page) ([]Post, error)
+	GetPostByID(ctx context.Context, idPost int) (*Post, error)
+	GetPostByUserID(ctx context.Context, idUser int, page pagination.Page) ([]Post, error)
+	GetPostByDate(ctx context.Context, date time.Time, page pagination.Page) ([]Post, error)
+	GetPostByTitle(ctx context.Context, title string, page pagination.Page) ([]Post, error)
+	GetFeed(ctx context.Context, idUser int, includeOwn bool, page pagination.Page) ([]Post, error)
+	EditPost(ctx context.Context, post Post, idPost int) (*Post, error)
+	DeletePost(ctx context.Context, idPost int, deletedAt time.Time) error
+	GetDeletedPost(ctx context.Context, idPost int) (*Post, error)
+	RestorePost(ctx context.Context, idPost int) error
+	HasComments(ctx context.Context, idPost int) (bool, error)
+	PurgePosts(ctx context.Context, before time.Time, restrict bool) (int64, error)
 }
 
 type repository struct {
-	db *sql.DB
+	db *dialect.DB
 }
 
-func (r *repository) CreatePost(post Post) (*Post, error) {
-	res, err := r.db.Exec(`INSERT INTO Posts (IDUser, DatePost, Title, Content)
-	VALUES (?,?,?,?)`, post.IDUser, post.Date, post.Title, post.Content)
+const selectPost = "SELECT ID, IDUser, DatePost, Title, Content FROM Posts"
 
+func (r *repository) CreatePost(ctx context.Context, post Post) (*Post, error) {
+	ctx = dialect.Operation(ctx, "post", "CreatePost")
+	var newPost *Post
+	err := r.db.Transact(ctx, func(ctx context.Context) error {
+		idPost, err := r.db.InsertContext(ctx, `INSERT INTO Posts (IDUser, DatePost, Title, Content)
+	VALUES (?,?,?,?)`, post.IDUser, post.Date, post.Title, post.Content)
+		if err != nil {
+			return err
+		}
+		newPost, err = r.GetPostByID(ctx, int(idPost))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	idPost, err := res.LastInsertId()
-	newPost, err := r.GetPostByID(int(idPost))
+	return newPost, nil
+}
+
+func (r *repository) GetPosts(ctx context.Context, page pagination.Page) ([]Post, error) {
+	ctx = dialect.Operation(ctx, "post", "GetPosts")
+	posts, err := r.db.QueryContext(ctx, selectPost+" WHERE ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?", page.After, page.Fetch())
 	if err != nil {
 		return nil, err
 	}
-	return newPost, nil
+	return scanPosts(posts)
 }
 
-func (r *repository) GetPosts() ([]Post, error) {
-	posts, err := r.db.Query("SELECT * FROM Posts")
+func (r *repository) GetPostByID(ctx context.Context, idPost int) (*Post, error) {
+	ctx = dialect.Operation(ctx, "post", "GetPostByID")
+	var post Post
+	err := r.db.QueryRowContext(ctx, selectPost+" WHERE ID = ? AND DeletedAt IS NULL", idPost).Scan(
+		&post.ID,
+		&post.IDUser,
+		&post.Date,
+		&post.Title,
+		&post.Content,
+	)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("the post is not in database")
+	}
 	if err != nil {
 		return nil, err
 	}
-	var listPosts []Post
-	for posts.Next() {
-		var post Post
-		err := posts.Scan(
-			&post.ID,
-			&post.IDUser,
-			&post.Date,
-			&post.Title,
-			&post.Content,
-		)
-		if err != nil {
-			return nil, err
-		}
-		listPosts = append(listPosts, post)
-	}
-	return listPosts, nil
+	return &post, nil
 }
 
-func (r *repository) GetPostByID(idPost int) (*Post, error) {
-	rows, err := r.db.Query("SELECT * FROM Posts WHERE ID = ?", idPost)
+func (r *repository) GetPostByUserID(ctx context.Context, idUser int, page pagination.Page) ([]Post, error) {
+	ctx = dialect.Operation(ctx, "post", "GetPostByUserID")
+	rows, err := r.db.QueryContext(ctx, selectPost+" WHERE IDUser = ? AND ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?", idUser, page.After, page.Fetch())
 	if err != nil {
 		return nil, err
 	}
-	var post Post
-	for rows.Next() {
-		err := rows.Scan(
-			&post.ID,
-			&post.IDUser,
-			&post.Date,
-			&post.Title,
-			&post.Content,
-		)
-		if err != nil {
-			return nil, err
-		}
-		return &post, nil
-	}
-	return nil, nil
+	return scanPosts(rows)
 }
 
-func (r *repository) GetPostByUserID(idUser int) ([]Post, error) {
-	rows, err := r.db.Query("SELECT * FROM Posts WHERE IDUser = ?", idUser)
+func (r *repository) GetPostByDate(ctx context.Context, date time.Time, page pagination.Page) ([]Post, error) {
+	ctx = dialect.Operation(ctx, "post", "GetPostByDate")
+	rows, err := r.db.QueryContext(ctx, selectPost+` WHERE `+r.db.Date("DatePost")+` = ? AND ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?`,
+		date.Format(time.DateOnly), page.After, page.Fetch())
 	if err != nil {
 		return nil, err
 	}
-	var listPosts []Post
-	for rows.Next() {
-		var post Post
-		err := rows.Scan(
-			&post.ID,
-			&post.IDUser,
-			&post.Date,
-			&post.Title,
-			&post.Content,
-		)
-		if err != nil {
-			return nil, err
-		}
-		listPosts = append(listPosts, post)
-	}
-	return listPosts, nil
+	return scanPosts(rows)
 }
 
-func (r *repository) GetPostByDate(date time.Time) ([]Post, error) {
-	dateFormat := date.Format("2006-01-02")
-	log.Println(dateFormat)
-	rows, err := r.db.Query(`SELECT * FROM Posts WHERE strftime('%Y-%m-%d', DatePost) = ?`, date.Format("2006-01-02"))
+func (r *repository) GetPostByTitle(ctx context.Context, title string, page pagination.Page) ([]Post, error) {
+	ctx = dialect.Operation(ctx, "post", "GetPostByTitle")
+	rows, err := r.db.QueryContext(ctx, selectPost+" WHERE Title = ? AND ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?", title, page.After, page.Fetch())
 	if err != nil {
 		return nil, err
 	}
-	var listPosts []Post
-	for rows.Next() {
-		var post Post
-		err := rows.Scan(
-			&post.ID,
-			&post.IDUser,
-			&post.Date,
-			&post.Title,
-			&post.Content,
-		)
-		if err != nil {
-			return nil, err
-		}
-		listPosts = append(listPosts, post)
-	}
-	return listPosts, nil
+	return scanPosts(rows)
 }
 
-func (r *repository) GetPostByTitle(title string) ([]Post, error) {
-	rows, err := r.db.Query("SELECT * FROM Posts WHERE Title = ?", title)
+// GetFeed lists the newest posts first, a zero page.After is the first page so the ID has no upper bound.
+func (r *repository) GetFeed(ctx context.Context, idUser int, includeOwn bool, page pagination.Page) ([]Post, error) {
+	ctx = dialect.Operation(ctx, "post", "GetFeed")
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT Posts.ID, Posts.IDUser, Posts.DatePost, Posts.Title, Posts.Content FROM Posts
+		LEFT JOIN Connection ON Connection.IdFollowing = Posts.IDUser AND Connection.IdFollower = ?
+		WHERE (Connection.IdFollower IS NOT NULL OR (? AND Posts.IDUser = ?)) AND (? = 0 OR Posts.ID < ?)
+		AND Posts.DeletedAt IS NULL ORDER BY Posts.ID DESC LIMIT ?`, idUser, includeOwn, idUser, page.After, page.After, page.Fetch())
 	if err != nil {
 		return nil, err
 	}
+	return scanPosts(rows)
+}
+
+func scanPosts(rows *sql.Rows) ([]Post, error) {
+	defer rows.Close()
 	var listPosts []Post
 	for rows.Next() {
 		var post Post
@@ -149,36 +135,126 @@ func (r *repository) GetPostByTitle(title string) ([]Post, error) {
 		}
 		listPosts = append(listPosts, post)
 	}
-	return listPosts, nil
+	return listPosts, rows.Err()
 }
 
-func (r *repository) EditPost(post Post, idPost int) (*Post, error) {
-	_, err := r.db.Exec(`UPDATE Posts SET IDUser = ?, DatePost = ?, Title = ?, Content = ?
+func (r *repository) EditPost(ctx context.Context, post Post, idPost int) (*Post, error) {
+	ctx = dialect.Operation(ctx, "post", "EditPost")
+	var editedPost *Post
+	err := r.db.Transact(ctx, func(ctx context.Context) error {
+		_, err := r.db.ExecContext(ctx, `UPDATE Posts SET IDUser = ?, DatePost = ?, Title = ?, Content = ?
 			WHERE ID = ?`, post.IDUser, post.Date, post.Title, post.Content, idPost)
+		if err != nil {
+			return err
+		}
+		editedPost, err = r.GetPostByID(ctx, idPost)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return editedPost, nil
+}
+
+// DeletePost hides the post and its comments, they share deletedAt so RestorePost brings back exactly them.
+func (r *repository) DeletePost(ctx context.Context, idPost int, deletedAt time.Time) error {
+	ctx = dialect.Operation(ctx, "post", "DeletePost")
+	return r.db.Transact(ctx, func(ctx context.Context) error {
+		res, err := r.db.ExecContext(ctx, "UPDATE Posts SET DeletedAt = ? WHERE ID = ? AND DeletedAt IS NULL", deletedAt, idPost)
+		if err != nil {
+			return err
+		}
+		deleted, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return apperror.NotFound("the post is not in database")
+		}
+		_, err = r.db.ExecContext(ctx, "UPDATE Comment SET DeletedAt = ? WHERE IDPost = ? AND DeletedAt IS NULL", deletedAt, idPost)
+		return err
+	})
+}
 
-	editedPost, err := r.GetPostByID(idPost)
+func (r *repository) GetDeletedPost(ctx context.Context, idPost int) (*Post, error) {
+	ctx = dialect.Operation(ctx, "post", "GetDeletedPost")
+	var post Post
+	var deletedAt time.Time
+	err := r.db.QueryRowContext(ctx, "SELECT ID, IDUser, DatePost, Title, Content, DeletedAt FROM Posts WHERE ID = ? AND DeletedAt IS NOT NULL", idPost).Scan(
+		&post.ID,
+		&post.IDUser,
+		&post.Date,
+		&post.Title,
+		&post.Content,
+		&deletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("the deleted post is not in database")
+	}
 	if err != nil {
 		return nil, err
 	}
+	post.DeletedAt = &deletedAt
+	return &post, nil
+}
 
-	return editedPost, nil
+func (r *repository) RestorePost(ctx context.Context, idPost int) error {
+	ctx = dialect.Operation(ctx, "post", "RestorePost")
+	return r.db.Transact(ctx, func(ctx context.Context) error {
+		_, err := r.db.ExecContext(ctx, `UPDATE Comment SET DeletedAt = NULL
+			WHERE IDPost = ? AND DeletedAt = (SELECT DeletedAt FROM Posts WHERE ID = ?)`, idPost, idPost)
+		if err != nil {
+			return err
+		}
+		res, err := r.db.ExecContext(ctx, "UPDATE Posts SET DeletedAt = NULL WHERE ID = ? AND DeletedAt IS NOT NULL", idPost)
+		if err != nil {
+			return err
+		}
+		restored, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if restored == 0 {
+			return apperror.NotFound("the deleted post is not in database")
+		}
+		return nil
+	})
 }
 
-func (r *repository) DeletePost(idPost int) error {
-	_, err := r.db.Exec("PRAGMA foreign_keys = ON")
+// HasComments tells whether the post still has comments that are not deleted.
+func (r *repository) HasComments(ctx context.Context, idPost int) (bool, error) {
+	ctx = dialect.Operation(ctx, "post", "HasComments")
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM Comment WHERE IDPost = ? AND DeletedAt IS NULL", idPost).Scan(&count)
 	if err != nil {
-		return err
+		return false, err
 	}
-	_, err = r.db.Exec("DELETE FROM Posts WHERE ID = ?", idPost)
-	if err != nil {
+	return count > 0, nil
+}
+
+// PurgePosts deletes for good the posts deleted before the cutoff, with their comments and reactions.
+// With restrict the posts that still have comments, deleted ones included, are kept for a later purge.
+func (r *repository) PurgePosts(ctx context.Context, before time.Time, restrict bool) (int64, error) {
+	ctx = dialect.Operation(ctx, "post", "PurgePosts")
+	query := "DELETE FROM Posts WHERE DeletedAt < ?"
+	if restrict {
+		query += " AND NOT EXISTS (SELECT 1 FROM Comment WHERE Comment.IDPost = Posts.ID)"
+	}
+	var purged int64
+	err := r.db.Transact(ctx, func(ctx context.Context) error {
+		res, err := r.db.ExecContext(ctx, query, before)
+		if err != nil {
+			return err
+		}
+		purged, err = res.RowsAffected()
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return purged, nil
 }
 
-func NewRepository(db *sql.DB) Repository {
+func NewRepository(db *dialect.DB) Repository {
 	return &repository{db}
 }
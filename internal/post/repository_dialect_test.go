@@ -0,0 +1,118 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/dialect/dialecttest"
+	"socialBuddy/internal/pagination"
+	"testing"
+	"time"
+)
+
+func createDialectUser(t *testing.T, db *dialect.DB, email string) int {
+	id, err := db.Insert("INSERT INTO Users (Name, Email) VALUES (?, ?)", "Name First", email)
+	if err != nil {
+		t.Fatalf("the creation of user is failed %v", err)
+	}
+	return int(id)
+}
+
+func TestRepositoryDialects(t *testing.T) {
+	dialecttest.Run(t, func(t *testing.T, db *dialect.DB) {
+		rep := NewRepository(db)
+		idAuthor := createDialectUser(t, db, "author@gmail.com")
+		idReader := createDialectUser(t, db, "reader@gmail.com")
+		_, err := db.Exec("INSERT INTO Connection (IdFollower, IdFollowing) VALUES (?, ?)", idReader, idAuthor)
+		if err != nil {
+			t.Fatalf("the creation of connection is failed %v", err)
+		}
+		date := time.Date(2023, 11, 13, 10, 30, 0, 0, time.UTC)
+
+		post, err := rep.CreatePost(context.Background(), Post{IDUser: idAuthor, Date: date, Title: "title1", Content: "content1"})
+		if err != nil || post.Title != "title1" || !post.Date.Equal(date) {
+			t.Fatalf("unexpected post %+v, err %v", post, err)
+		}
+		byDate, err := rep.GetPostByDate(context.Background(), date, pagination.Page{Limit: 10})
+		if err != nil || len(byDate) != 1 || byDate[0].ID != post.ID {
+			t.Fatalf("unexpected posts by date %+v, err %v", byDate, err)
+		}
+		feed, err := rep.GetFeed(context.Background(), idReader, false, pagination.Page{Limit: 10})
+		if err != nil || len(feed) != 1 || feed[0].ID != post.ID {
+			t.Fatalf("unexpected feed %+v, err %v", feed, err)
+		}
+		ownFeed, err := rep.GetFeed(context.Background(), idAuthor, true, pagination.Page{Limit: 10})
+		if err != nil || len(ownFeed) != 1 {
+			t.Fatalf("unexpected own feed %+v, err %v", ownFeed, err)
+		}
+		newer, err := rep.CreatePost(context.Background(), Post{IDUser: idAuthor, Date: date, Title: "title3", Content: "content3"})
+		if err != nil {
+			t.Fatalf("the creation of post is failed %v", err)
+		}
+		feed, err = rep.GetFeed(context.Background(), idReader, false, pagination.Page{Limit: 1, After: newer.ID})
+		if err != nil || len(feed) != 1 || feed[0].ID != post.ID {
+			t.Fatalf("expected the page after the cursor to have the older post, got %+v, err %v", feed, err)
+		}
+
+		edited, err := rep.EditPost(context.Background(), Post{IDUser: idAuthor, Date: date, Title: "title2", Content: "content2"}, post.ID)
+		if err != nil || edited.Title != "title2" {
+			t.Fatalf("expected the title to change, got %+v, err %v", edited, err)
+		}
+		_, err = db.Insert("INSERT INTO Comment (IDPost, IDUser, DateComment, Content) VALUES (?, ?, ?, ?)", post.ID, idReader, date, "content1")
+		if err != nil {
+			t.Fatalf("the creation of comment is failed %v", err)
+		}
+		hasComments, err := rep.HasComments(context.Background(), post.ID)
+		if err != nil || !hasComments {
+			t.Fatalf("expected the post to have comments, err %v", err)
+		}
+		deletedAt := time.Now().UTC()
+		err = rep.DeletePost(context.Background(), post.ID, deletedAt)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		_, err = rep.GetPostByID(context.Background(), post.ID)
+		if !errors.Is(err, apperror.ErrNotFound) {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+		feed, err = rep.GetFeed(context.Background(), idReader, false, pagination.Page{Limit: 10})
+		if err != nil || len(feed) != 1 || feed[0].ID != newer.ID {
+			t.Fatalf("expected the deleted post to leave the feed, got %+v, err %v", feed, err)
+		}
+		hasComments, err = rep.HasComments(context.Background(), post.ID)
+		if err != nil || hasComments {
+			t.Fatalf("expected the comments to be deleted with the post, err %v", err)
+		}
+		deleted, err := rep.GetDeletedPost(context.Background(), post.ID)
+		if err != nil || deleted.DeletedAt == nil || deleted.Title != "title2" {
+			t.Fatalf("unexpected deleted post %+v, err %v", deleted, err)
+		}
+
+		err = rep.RestorePost(context.Background(), post.ID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		hasComments, err = rep.HasComments(context.Background(), post.ID)
+		if err != nil || !hasComments {
+			t.Fatalf("expected the comments to be restored with the post, err %v", err)
+		}
+
+		err = rep.DeletePost(context.Background(), post.ID, deletedAt)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		purged, err := rep.PurgePosts(context.Background(), deletedAt.Add(time.Minute), true)
+		if err != nil || purged != 0 {
+			t.Fatalf("expected the post with comments to be kept under restrict, got %d, err %v", purged, err)
+		}
+		purged, err = rep.PurgePosts(context.Background(), deletedAt.Add(time.Minute), false)
+		if err != nil || purged != 1 {
+			t.Fatalf("expected one post to be purged, got %d, err %v", purged, err)
+		}
+		_, err = rep.GetDeletedPost(context.Background(), post.ID)
+		if !errors.Is(err, apperror.ErrNotFound) {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+	})
+}
@@ -1,12 +1,16 @@
 package post
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"log"
 	"reflect"
 	"regexp"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/pagination"
 	"testing"
 	"time"
 )
@@ -52,6 +56,15 @@ type argTitle struct {
 	hasError error
 }
 
+type argFeed struct {
+	name       string
+	idUser     int
+	includeOwn bool
+	page       pagination.Page
+	output     []Post
+	hasError   error
+}
+
 type argEdit struct {
 	name       string
 	editedPost Post
@@ -75,7 +88,7 @@ func TestGetPosts(t *testing.T) {
 		_ = mockDB.Close()
 
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 
 	//format := "Mon Jan _2 15:04:05 2006"
 	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
@@ -84,7 +97,7 @@ func TestGetPosts(t *testing.T) {
 	result := sqlmock.NewRows([]string{
 		"ID", "IDUser", "DatePost", "Title", "Content",
 	}).AddRow(1, 2, timeNow, "title1", "content1")
-	mock.ExpectQuery("SELECT \\* FROM Posts").WillReturnRows(result)
+	mock.ExpectQuery("SELECT (.+) FROM Posts WHERE ID > \\? AND DeletedAt IS NULL ORDER BY ID LIMIT \\?").WithArgs(0, 11).WillReturnRows(result)
 
 	test := []argGet{
 		{name: "GetPosts() is succeed",
@@ -107,7 +120,7 @@ func TestGetPosts(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			posts, err := rep.GetPosts()
+			posts, err := rep.GetPosts(context.Background(), pagination.Page{Limit: 10})
 			log.Printf("users: %+v, err: %+v", posts, err)
 			if !reflect.DeepEqual(posts, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, posts)
@@ -131,12 +144,14 @@ func TestCreatePost(t *testing.T) {
 
 	customDate := time.Now().In(time.Local)
 	log.Printf("test: %v", customDate)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO Posts").WithArgs(2, customDate, "title1", "content1").WillReturnResult(sqlmock.NewResult(1, 1))
 	result := sqlmock.NewRows([]string{
 		"ID", "IDUser", "DatePost", "Title", "Content",
 	}).AddRow(1, 2, customDate, "title1", "content1")
-	mock.ExpectQuery("SELECT \\* FROM Posts WHERE ID = ?").WithArgs(1).WillReturnRows(result)
+	mock.ExpectQuery("SELECT (.+) FROM Posts WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(1).WillReturnRows(result)
+	mock.ExpectCommit()
 
 	test := []argCreate{
 		{name: "CreatePost() is succeed",
@@ -172,7 +187,7 @@ func TestCreatePost(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			posts, err := rep.CreatePost(tt.newPost)
+			posts, err := rep.CreatePost(context.Background(), tt.newPost)
 			log.Printf("posts: %+v, err: %+v", posts, err)
 			if !reflect.DeepEqual(posts, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, posts)
@@ -195,11 +210,11 @@ func TestGetPostByID(t *testing.T) {
 	//format := "Mon Jan _2 15:04:05 2006"
 	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
 
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	result := sqlmock.NewRows([]string{
 		"ID", "IDUser", "DatePost", "Title", "Content",
 	}).AddRow(1, 2, timeNow, "title1", "content1")
-	mock.ExpectQuery("SELECT \\* FROM Posts WHERE ID = ?").WithArgs(1).WillReturnRows(result)
+	mock.ExpectQuery("SELECT (.+) FROM Posts WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(1).WillReturnRows(result)
 
 	test := []argID{
 		{name: "GetPostsByID() is succeed",
@@ -223,7 +238,7 @@ func TestGetPostByID(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			posts, err := rep.GetPostByID(tt.id)
+			posts, err := rep.GetPostByID(context.Background(), tt.id)
 			log.Printf("posts: %+v, err: %+v", posts, err)
 			if !reflect.DeepEqual(posts, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, posts)
@@ -247,11 +262,11 @@ func TestGetPostByUserID(t *testing.T) {
 	//format := "Mon Jan _2 15:04:05 2006"
 	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
 
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	result := sqlmock.NewRows([]string{
 		"ID", "IDUser", "DatePost", "Title", "Content",
 	}).AddRow(1, 2, timeNow, "title1", "content1")
-	mock.ExpectQuery("SELECT \\* FROM Posts WHERE IDUser = ?").WithArgs(2).WillReturnRows(result)
+	mock.ExpectQuery("SELECT (.+) FROM Posts WHERE IDUser = \\? AND ID > \\? AND DeletedAt IS NULL ORDER BY ID LIMIT \\?").WithArgs(2, 0, 11).WillReturnRows(result)
 
 	test := []argIDUser{
 		{name: "GetPostsByUserID() is succeed",
@@ -277,7 +292,7 @@ func TestGetPostByUserID(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			posts, err := rep.GetPostByUserID(tt.idUser)
+			posts, err := rep.GetPostByUserID(context.Background(), tt.idUser, pagination.Page{Limit: 10})
 			log.Printf("posts: %+v, err: %+v", posts, err)
 			if !reflect.DeepEqual(posts, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, posts)
@@ -301,11 +316,11 @@ func TestGetPostByDate(t *testing.T) {
 	//format := "Mon Jan _2 15:04:05 2006"
 	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
 
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	result := sqlmock.NewRows([]string{
 		"ID", "IDUser", "Date", "Title", "Content",
 	}).AddRow(1, 2, timeNow, "title1", "content1")
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM Posts WHERE strftime('%Y-%m-%d', DatePost) = ?`)).WithArgs(time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local).Format("2006-01-02")).WillReturnRows(result)
+	mock.ExpectQuery(regexp.QuoteMeta(selectPost+` WHERE strftime('%Y-%m-%d', DatePost) = ? AND ID > ? AND DeletedAt IS NULL ORDER BY ID LIMIT ?`)).WithArgs(time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local).Format("2006-01-02"), 0, 11).WillReturnRows(result)
 
 	test := []argDate{
 		{name: "GetPostsByDate() is succeed",
@@ -329,7 +344,7 @@ func TestGetPostByDate(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			posts, err := rep.GetPostByDate(tt.date)
+			posts, err := rep.GetPostByDate(context.Background(), tt.date, pagination.Page{Limit: 10})
 			log.Printf("posts: %+v, err: %+v", posts, err)
 			if !reflect.DeepEqual(posts, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, posts)
@@ -352,11 +367,11 @@ func TestGetPostByTitle(t *testing.T) {
 	//format := "Mon Jan _2 15:04:05 2006"
 	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
 
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	result := sqlmock.NewRows([]string{
 		"ID", "IDUser", "DatePost", "Title", "Content",
 	}).AddRow(1, 2, timeNow, "title1", "content1")
-	mock.ExpectQuery("SELECT \\* FROM Posts WHERE Title =?").WithArgs("title1").WillReturnRows(result)
+	mock.ExpectQuery("SELECT (.+) FROM Posts WHERE Title = \\? AND ID > \\? AND DeletedAt IS NULL ORDER BY ID LIMIT \\?").WithArgs("title1", 0, 11).WillReturnRows(result)
 
 	test := []argTitle{
 		{name: "GetPostsByTitle() is succeed",
@@ -381,7 +396,7 @@ func TestGetPostByTitle(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			posts, err := rep.GetPostByTitle(tt.title)
+			posts, err := rep.GetPostByTitle(context.Background(), tt.title, pagination.Page{Limit: 10})
 			log.Printf("posts: %+v, err: %+v", posts, err)
 			if !reflect.DeepEqual(posts, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, posts)
@@ -393,6 +408,67 @@ func TestGetPostByTitle(t *testing.T) {
 	}
 }
 
+func TestGetFeed(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
+
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	query := "LEFT JOIN Connection ON Connection.IdFollowing = Posts.IDUser AND Connection.IdFollower = \\?.*ORDER BY Posts.ID DESC LIMIT \\?"
+	mock.ExpectQuery(query).WithArgs(1, false, 1, 0, 0, 11).WillReturnRows(sqlmock.NewRows([]string{
+		"ID", "IDUser", "DatePost", "Title", "Content",
+	}).AddRow(3, 2, timeNow, "title3", "content3").AddRow(2, 3, timeNow, "title2", "content2"))
+	mock.ExpectQuery(query).WithArgs(1, true, 1, 3, 3, 11).WillReturnRows(sqlmock.NewRows([]string{
+		"ID", "IDUser", "DatePost", "Title", "Content",
+	}).AddRow(2, 1, timeNow, "title2", "content2"))
+
+	test := []argFeed{
+		{
+			name:   "GetFeed() is succeed",
+			idUser: 1,
+			page:   pagination.Page{Limit: 10},
+			output: []Post{
+				{ID: 3, IDUser: 2, Date: timeNow, Title: "title3", Content: "content3"},
+				{ID: 2, IDUser: 3, Date: timeNow, Title: "title2", Content: "content2"},
+			},
+			hasError: nil,
+		},
+		{
+			name:       "GetFeed() with own posts after a cursor",
+			idUser:     1,
+			includeOwn: true,
+			page:       pagination.Page{Limit: 10, After: 3},
+			output: []Post{
+				{ID: 2, IDUser: 1, Date: timeNow, Title: "title2", Content: "content2"},
+			},
+			hasError: nil,
+		},
+		{
+			name:     "GetFeed() has not succeed",
+			idUser:   2,
+			page:     pagination.Page{Limit: 10},
+			output:   nil,
+			hasError: errors.New("the feed query is failed"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, err := rep.GetFeed(context.Background(), tt.idUser, tt.includeOwn, tt.page)
+			if !reflect.DeepEqual(posts, tt.output) {
+				t.Fatalf("expected %+v, got %+v", tt.output, posts)
+			}
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
+
 func TestEditPost(t *testing.T) {
 	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	if err != nil {
@@ -405,12 +481,14 @@ func TestEditPost(t *testing.T) {
 	//format := "Mon Jan _2 15:04:05 2006"
 	customDate := time.Now().In(time.Local)
 	log.Printf("test: %v", customDate)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
 	mock.ExpectExec("UPDATE Posts SET IDUser = ?, DatePost = ?, Title = ?, Content = ? WHERE ID = ?").WithArgs(2, customDate, "title1", "content1", 1).WillReturnResult(sqlmock.NewResult(1, 1))
 	result := sqlmock.NewRows([]string{
 		"ID", "IDUser", "DatePost", "Title", "Content",
 	}).AddRow(1, 2, customDate, "title1", "content1")
-	mock.ExpectQuery("SELECT * FROM Posts WHERE ID = ?").WithArgs(1).WillReturnRows(result)
+	mock.ExpectQuery(selectPost + " WHERE ID = ? AND DeletedAt IS NULL").WithArgs(1).WillReturnRows(result)
+	mock.ExpectCommit()
 	test := []argEdit{
 		{name: "EditPosts() is succeed",
 			editedPost: Post{
@@ -446,7 +524,7 @@ func TestEditPost(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			post, err := rep.EditPost(tt.editedPost, tt.id)
+			post, err := rep.EditPost(context.Background(), tt.editedPost, tt.id)
 			log.Printf("post: %+v, err: %+v", post, err)
 			if !reflect.DeepEqual(post, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, post)
@@ -467,9 +545,11 @@ func TestDeletePost(t *testing.T) {
 		_ = mockDB.Close()
 
 	}(mockDB)
-	rep := NewRepository(mockDB)
-	mock.ExpectExec("PRAGMA foreign_keys = ON").WithoutArgs().WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectExec("DELETE FROM Posts WHERE ID = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE Posts SET DeletedAt = \\? WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE Comment SET DeletedAt = \\? WHERE IDPost = \\? AND DeletedAt IS NULL").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
 
 	test := []argDelete{
 		{name: "DeletePost() is succeed",
@@ -484,7 +564,7 @@ func TestDeletePost(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			err := rep.DeletePost(tt.id)
+			err := rep.DeletePost(context.Background(), tt.id, time.Now().UTC())
 			log.Printf("err: %v", err)
 			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
 				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
@@ -492,3 +572,48 @@ func TestDeletePost(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPostByIDNotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectQuery("SELECT (.+) FROM Posts WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{
+		"ID", "IDUser", "DatePost", "Title", "Content",
+	}))
+	post, err := rep.GetPostByID(context.Background(), 3)
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("expected not found error, got %+v", err)
+	}
+	if post != nil {
+		t.Fatalf("expected no post, got %+v", post)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestDeletePostNotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE Posts SET DeletedAt = \\? WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(sqlmock.AnyArg(), 3).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = rep.DeletePost(context.Background(), 3, time.Now().UTC())
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("expected not found error, got %+v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %v", err)
+	}
+}
@@ -3,7 +3,11 @@ package post
 import (
 	"encoding/json"
 	"github.com/go-chi/chi/v5"
+	"io"
 	"net/http"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/pagination"
 	"strconv"
 	"time"
 )
@@ -13,39 +17,49 @@ type Server struct {
 }
 
 func (s *Server) CreatePost(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
 	var newPost Post
 	err := json.NewDecoder(r.Body).Decode(&newPost)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	err = r.Body.Close()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
-	post, err := s.postService.CreatePost(newPost)
+	post, err := s.postService.CreatePost(r.Context(), newPost, identity.ID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	err = json.NewEncoder(w).Encode(post)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
 
-func (s *Server) GetPosts(w http.ResponseWriter, _ *http.Request) {
-	posts, err := s.postService.GetPosts()
+func (s *Server) GetPosts(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	posts, err := s.postService.GetPosts(r.Context(), page)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(posts)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(posts, page, postID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
@@ -54,18 +68,18 @@ func (s *Server) GetPostByID(w http.ResponseWriter, r *http.Request) {
 	postId := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(postId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	post, err := s.postService.GetPostByID(id)
+	post, err := s.postService.GetPostByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(post)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
@@ -74,17 +88,23 @@ func (s *Server) GetPostByUserID(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id_user")
 	id, err := strconv.Atoi(userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	post, err := s.postService.GetPostByUserID(id)
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	post, err := s.postService.GetPostByUserID(r.Context(), id, page)
+	if err != nil {
+		apperror.Write(w, err)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(post)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(post, page, postID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
@@ -93,83 +113,205 @@ func (s *Server) GetPostByDate(w http.ResponseWriter, r *http.Request) {
 	postDate := chi.URLParam(r, "date")
 	date, err := time.Parse("2006-01-02", postDate)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	post, err := s.postService.GetPostByDate(date)
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	post, err := s.postService.GetPostByDate(r.Context(), date, page)
+	if err != nil {
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(post)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(post, page, postID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
 
 func (s *Server) GetPostByTitle(w http.ResponseWriter, r *http.Request) {
 	postTitle := chi.URLParam(r, "title")
-	post, err := s.postService.GetPostByTitle(postTitle)
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	post, err := s.postService.GetPostByTitle(r.Context(), postTitle, page)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(post)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(post, page, postID))
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func (s *Server) GetFeed(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
+	userID := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	includeOwn := false
+	if own := r.URL.Query().Get("include_own"); own != "" {
+		includeOwn, err = strconv.ParseBool(own)
+		if err != nil {
+			apperror.Write(w, apperror.Validation(err.Error()))
+			return
+		}
+	}
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	posts, err := s.postService.GetFeed(r.Context(), id, includeOwn, page, identity)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(posts, page, postID))
+	if err != nil {
+		apperror.Write(w, err)
 		return
 	}
 }
 
 func (s *Server) EditPost(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
 	postId := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(postId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	var editedPost Post
 	err = json.NewDecoder(r.Body).Decode(&editedPost)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	err = r.Body.Close()
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	post, err := s.postService.EditPost(r.Context(), editedPost, id, identity)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(post)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func (s *Server) PatchPost(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
+	postId := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(postId)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	err = r.Body.Close()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
-	post, err := s.postService.EditPost(editedPost, id)
+	post, err := s.postService.PatchPost(r.Context(), patch, id, identity)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(post)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
 
 func (s *Server) DeletePost(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
 	postId := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(postId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	err = s.postService.DeletePost(id)
+	err = s.postService.DeletePost(r.Context(), id, identity)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *Server) RestorePost(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
+	postId := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(postId)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	post, err := s.postService.RestorePost(r.Context(), id, identity)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(post)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func postID(post Post) int {
+	return post.ID
+}
+
 func NewServer(postService Service) *Server {
 	return &Server{postService}
 }
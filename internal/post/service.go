@@ -1,6 +1,14 @@
 package post
 
 import (
+	"context"
+	"errors"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/deletion"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/mergepatch"
+	"socialBuddy/internal/pagination"
 	"socialBuddy/internal/user"
 	"time"
 )
@@ -8,90 +16,259 @@ import (
 type service struct {
 	PostRepository Repository
 	UserService    user.Service
+	PostPolicy     auth.Policy
+	Reactions      ReactionCounter
+	UnitOfWork     dialect.UnitOfWork
+	Deletion       deletion.Policy
 }
 
 type Service interface {
-	CreatePost(post Post) (*Post, error)
-	GetPosts() ([]Post, error)
-	GetPostByID(idPost int) (*Post, error)
-	GetPostByUserID(idUser int) ([]Post, error)
-	GetPostByDate(date time.Time) ([]Post, error)
-	GetPostByTitle(title string) ([]Post, error)
-	EditPost(post Post, idPost int) (*Post, error)
-	DeletePost(idPost int) error
+	CreatePost(ctx context.Context, post Post, idUser int) (*Post, error)
+	GetPosts(ctx context.Context, page pagination.Page) ([]Post, error)
+	GetPostByID(ctx context.Context, idPost int) (*Post, error)
+	GetPostByUserID(ctx context.Context, idUser int, page pagination.Page) ([]Post, error)
+	GetPostByDate(ctx context.Context, date time.Time, page pagination.Page) ([]Post, error)
+	GetPostByTitle(ctx context.Context, title string, page pagination.Page) ([]Post, error)
+	GetFeed(ctx context.Context, idUser int, includeOwn bool, page pagination.Page, actor auth.Identity) ([]Post, error)
+	EditPost(ctx context.Context, post Post, idPost int, actor auth.Identity) (*Post, error)
+	PatchPost(ctx context.Context, patch []byte, idPost int, actor auth.Identity) (*Post, error)
+	DeletePost(ctx context.Context, idPost int, actor auth.Identity) error
+	RestorePost(ctx context.Context, idPost int, actor auth.Identity) (*Post, error)
 }
 
-func (s *service) CreatePost(post Post) (*Post, error) {
-	err := ValidateIDUser(post.IDUser, s.UserService)
+func (s *service) CreatePost(ctx context.Context, post Post, idUser int) (*Post, error) {
+	post.IDUser = idUser
+	err := postValidation(post)
 	if err != nil {
 		return nil, err
 	}
 	post.Date = time.Now()
-	newPost, err := s.PostRepository.CreatePost(post)
+	var newPost *Post
+	err = s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		err := ValidateIDUser(ctx, post.IDUser, s.UserService)
+		if err != nil {
+			return err
+		}
+		newPost, err = s.PostRepository.CreatePost(ctx, post)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	// a new post has no reactions, the empty map keeps the answer like the one of GetPostByID
+	if s.Reactions != nil {
+		newPost.Reactions = map[string]int{}
+	}
 	return newPost, nil
 }
 
-func (s *service) GetPosts() ([]Post, error) {
-	posts, err := s.PostRepository.GetPosts()
+func (s *service) GetPosts(ctx context.Context, page pagination.Page) ([]Post, error) {
+	posts, err := s.PostRepository.GetPosts(ctx, page)
 	if err != nil {
 		return nil, err
 	}
-	return posts, nil
+	return s.withReactions(ctx, posts)
 }
 
-func (s *service) GetPostByID(idPost int) (*Post, error) {
-	post, err := s.PostRepository.GetPostByID(idPost)
+func (s *service) GetPostByID(ctx context.Context, idPost int) (*Post, error) {
+	post, err := s.PostRepository.GetPostByID(ctx, idPost)
 	if err != nil {
 		return nil, err
 	}
-	return post, nil
+	return s.withPostReactions(ctx, post)
 }
 
-func (s *service) GetPostByUserID(idUser int) ([]Post, error) {
-	posts, err := s.PostRepository.GetPostByUserID(idUser)
+func (s *service) GetPostByUserID(ctx context.Context, idUser int, page pagination.Page) ([]Post, error) {
+	posts, err := s.PostRepository.GetPostByUserID(ctx, idUser, page)
 	if err != nil {
 		return nil, err
 	}
-	return posts, err
+	return s.withReactions(ctx, posts)
 }
 
-func (s *service) GetPostByDate(date time.Time) ([]Post, error) {
-	posts, err := s.PostRepository.GetPostByDate(date)
+func (s *service) GetPostByDate(ctx context.Context, date time.Time, page pagination.Page) ([]Post, error) {
+	posts, err := s.PostRepository.GetPostByDate(ctx, date, page)
 	if err != nil {
 		return nil, err
 	}
-	return posts, nil
+	return s.withReactions(ctx, posts)
 }
 
-func (s *service) GetPostByTitle(title string) ([]Post, error) {
-	post, err := s.PostRepository.GetPostByTitle(title)
+func (s *service) GetPostByTitle(ctx context.Context, title string, page pagination.Page) ([]Post, error) {
+	post, err := s.PostRepository.GetPostByTitle(ctx, title, page)
+	if err != nil {
+		return nil, err
+	}
+	return s.withReactions(ctx, post)
+}
+
+// GetFeed lists the posts of the users idUser follows, the feed is only shown to idUser.
+func (s *service) GetFeed(ctx context.Context, idUser int, includeOwn bool, page pagination.Page, actor auth.Identity) ([]Post, error) {
+	err := s.PostPolicy.Authorize(actor, auth.ActionView, auth.Resource{Kind: auth.ResourceUser, ID: idUser, IDOwner: idUser})
+	if err != nil {
+		return nil, err
+	}
+	err = ValidateIDUser(ctx, idUser, s.UserService)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.PostRepository.GetFeed(ctx, idUser, includeOwn, page)
+	if err != nil {
+		return nil, err
+	}
+	return s.withReactions(ctx, posts)
+}
+
+func (s *service) EditPost(ctx context.Context, editPost Post, idPost int, actor auth.Identity) (*Post, error) {
+	var post *Post
+	err := s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		storedPost, err := s.authorize(ctx, actor, auth.ActionEdit, idPost)
+		if err != nil {
+			return err
+		}
+		post, err = s.edit(ctx, editPost, storedPost)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// PatchPost applies a JSON Merge Patch to the stored post, only the title and the content can change.
+func (s *service) PatchPost(ctx context.Context, patch []byte, idPost int, actor auth.Identity) (*Post, error) {
+	var post *Post
+	err := s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		storedPost, err := s.authorize(ctx, actor, auth.ActionEdit, idPost)
+		if err != nil {
+			return err
+		}
+		editPost := *storedPost
+		err = mergepatch.Apply(&editPost, patch)
+		if err != nil {
+			return err
+		}
+		post, err = s.edit(ctx, editPost, storedPost)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return post, nil
 }
 
-func (s *service) EditPost(editPost Post, idPost int) (*Post, error) {
+func (s *service) edit(ctx context.Context, editPost Post, storedPost *Post) (*Post, error) {
+	err := postValidation(editPost)
+	if err != nil {
+		return nil, err
+	}
+	editPost.IDUser = storedPost.IDUser
 	editPost.Date = time.Now()
-	post, err := s.PostRepository.EditPost(editPost, idPost)
+	post, err := s.PostRepository.EditPost(ctx, editPost, storedPost.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withPostReactions(ctx, post)
+}
+
+func (s *service) DeletePost(ctx context.Context, idPost int, actor auth.Identity) error {
+	return s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		_, err := s.authorize(ctx, actor, auth.ActionDelete, idPost)
+		if err != nil {
+			return err
+		}
+		if s.Deletion.Restricted() {
+			hasComments, err := s.PostRepository.HasComments(ctx, idPost)
+			if err != nil {
+				return err
+			}
+			if hasComments {
+				return apperror.Conflict("the post still has comments")
+			}
+		}
+		return s.PostRepository.DeletePost(ctx, idPost, time.Now().UTC())
+	})
+}
+
+// RestorePost brings back a deleted post and the comments deleted with it, until the grace period is over.
+// The author must not be deleted.
+func (s *service) RestorePost(ctx context.Context, idPost int, actor auth.Identity) (*Post, error) {
+	var post *Post
+	err := s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		deletedPost, err := s.PostRepository.GetDeletedPost(ctx, idPost)
+		if err != nil {
+			return err
+		}
+		err = s.PostPolicy.Authorize(actor, auth.ActionRestore, auth.Resource{Kind: auth.ResourcePost, ID: idPost, IDOwner: deletedPost.IDUser})
+		if err != nil {
+			return err
+		}
+		if !s.Deletion.Restorable(*deletedPost.DeletedAt) {
+			return apperror.Gone("the post can no longer be restored")
+		}
+		err = ValidateIDUser(ctx, deletedPost.IDUser, s.UserService)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return apperror.Conflict("the author of the post is deleted")
+		}
+		if err != nil {
+			return err
+		}
+		err = s.PostRepository.RestorePost(ctx, idPost)
+		if err != nil {
+			return err
+		}
+		post, err = s.PostRepository.GetPostByID(ctx, idPost)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.withPostReactions(ctx, post)
+}
+
+// authorize loads the post and checks that the actor is its author or an admin.
+func (s *service) authorize(ctx context.Context, actor auth.Identity, action string, idPost int) (*Post, error) {
+	post, err := s.PostRepository.GetPostByID(ctx, idPost)
+	if err != nil {
+		return nil, err
+	}
+	err = s.PostPolicy.Authorize(actor, action, auth.Resource{Kind: auth.ResourcePost, ID: idPost, IDOwner: post.IDUser})
 	if err != nil {
 		return nil, err
 	}
 	return post, nil
+}
 
+// withReactions fills the reaction counts of every post, it does nothing when no counter is configured.
+func (s *service) withReactions(ctx context.Context, posts []Post) ([]Post, error) {
+	if s.Reactions == nil || len(posts) == 0 {
+		return posts, nil
+	}
+	ids := make([]int, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	counts, err := s.Reactions.CountReactions(ctx, "post", ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		posts[i].Reactions = counts[posts[i].ID]
+		if posts[i].Reactions == nil {
+			posts[i].Reactions = map[string]int{}
+		}
+	}
+	return posts, nil
 }
 
-func (s *service) DeletePost(idPost int) error {
-	err := s.PostRepository.DeletePost(idPost)
+func (s *service) withPostReactions(ctx context.Context, post *Post) (*Post, error) {
+	posts, err := s.withReactions(ctx, []Post{*post})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return &posts[0], nil
 }
 
-func NewService(postRepository Repository, UserService user.Service) Service {
-	return &service{postRepository, UserService}
+func NewService(postRepository Repository, UserService user.Service, postPolicy auth.Policy, reactions ReactionCounter, unitOfWork dialect.UnitOfWork, deletionPolicy deletion.Policy) Service {
+	return &service{postRepository, UserService, postPolicy, reactions, unitOfWork, deletionPolicy}
 }
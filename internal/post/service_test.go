@@ -1,10 +1,16 @@
 package post
 
 import (
+	"context"
 	"errors"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/stretchr/testify/mock"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/deletion"
+	"socialBuddy/internal/dialect/dialecttest"
+	"socialBuddy/internal/pagination"
 	"socialBuddy/internal/user"
 	"time"
 )
@@ -17,12 +23,21 @@ type mockUserService struct {
 	user.Service
 }
 
-func (m *mockUserService) GetUserByID(idUser int) (*user.User, error) {
+type mockReactionCounter struct {
+	mock.Mock
+}
+
+func (m *mockReactionCounter) CountReactions(ctx context.Context, targetType string, ids []int) (map[int]map[string]int, error) {
+	args := m.Called(targetType, ids)
+	return args.Get(0).(map[int]map[string]int), args.Error(1)
+}
+
+func (m *mockUserService) GetUserByID(ctx context.Context, idUser int) (*user.User, error) {
 	args := m.Called(idUser)
 	return args.Get(0).(*user.User), args.Error(1)
 }
 
-func (m *mockRepository) CreatePost(post Post) (*Post, error) {
+func (m *mockRepository) CreatePost(ctx context.Context, post Post) (*Post, error) {
 	args := m.Called(post)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -30,32 +45,37 @@ func (m *mockRepository) CreatePost(post Post) (*Post, error) {
 	return args.Get(0).(*Post), args.Error(1)
 }
 
-func (m *mockRepository) GetPosts() ([]Post, error) {
-	args := m.Called()
+func (m *mockRepository) GetPosts(ctx context.Context, page pagination.Page) ([]Post, error) {
+	args := m.Called(page)
 	return args.Get(0).([]Post), args.Error(1)
 }
 
-func (m *mockRepository) GetPostByID(idPost int) (*Post, error) {
+func (m *mockRepository) GetPostByID(ctx context.Context, idPost int) (*Post, error) {
 	args := m.Called(idPost)
 	return args.Get(0).(*Post), args.Error(1)
 }
 
-func (m *mockRepository) GetPostByUserID(idUser int) ([]Post, error) {
-	args := m.Called(idUser)
+func (m *mockRepository) GetPostByUserID(ctx context.Context, idUser int, page pagination.Page) ([]Post, error) {
+	args := m.Called(idUser, page)
 	return args.Get(0).([]Post), args.Error(1)
 }
 
-func (m *mockRepository) GetPostByDate(date time.Time) ([]Post, error) {
-	args := m.Called(date)
+func (m *mockRepository) GetPostByDate(ctx context.Context, date time.Time, page pagination.Page) ([]Post, error) {
+	args := m.Called(date, page)
 	return args.Get(0).([]Post), args.Error(1)
 }
 
-func (m *mockRepository) GetPostByTitle(title string) ([]Post, error) {
-	args := m.Called(title)
+func (m *mockRepository) GetPostByTitle(ctx context.Context, title string, page pagination.Page) ([]Post, error) {
+	args := m.Called(title, page)
 	return args.Get(0).([]Post), args.Error(1)
 }
 
-func (m *mockRepository) EditPost(post Post, idPost int) (*Post, error) {
+func (m *mockRepository) GetFeed(ctx context.Context, idUser int, includeOwn bool, page pagination.Page) ([]Post, error) {
+	args := m.Called(idUser, includeOwn, page)
+	return args.Get(0).([]Post), args.Error(1)
+}
+
+func (m *mockRepository) EditPost(ctx context.Context, post Post, idPost int) (*Post, error) {
 	args := m.Called(post, idPost)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -63,11 +83,36 @@ func (m *mockRepository) EditPost(post Post, idPost int) (*Post, error) {
 	return args.Get(0).(*Post), args.Error(1)
 }
 
-func (m *mockRepository) DeletePost(idPost int) error {
+func (m *mockRepository) DeletePost(ctx context.Context, idPost int, deletedAt time.Time) error {
+	args := m.Called(idPost, deletedAt)
+	return args.Error(0)
+}
+
+func (m *mockRepository) GetDeletedPost(ctx context.Context, idPost int) (*Post, error) {
+	args := m.Called(idPost)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Post), args.Error(1)
+}
+
+func (m *mockRepository) RestorePost(ctx context.Context, idPost int) error {
 	args := m.Called(idPost)
 	return args.Error(0)
 }
 
+func (m *mockRepository) HasComments(ctx context.Context, idPost int) (bool, error) {
+	args := m.Called(idPost)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepository) PurgePosts(ctx context.Context, before time.Time, restrict bool) (int64, error) {
+	args := m.Called(before, restrict)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+var deletions = deletion.Policy{Cascade: deletion.Cascade, GracePeriod: time.Hour}
+
 var _ = Describe("The Service Test", func() {
 	var (
 		mockPostRepository *mockRepository
@@ -79,7 +124,7 @@ var _ = Describe("The Service Test", func() {
 	})
 	It("should CreatePost successfully", func() {
 		customDate := time.Now().In(time.Local)
-		mockPostRepository.On("CreatePost", mock.AnythingOfType("Post")).Return(&Post{
+		mockPostRepository.On("CreatePost", mock.MatchedBy(func(post Post) bool { return post.IDUser == 2 })).Return(&Post{
 			ID:      1,
 			IDUser:  2,
 			Date:    customDate,
@@ -105,36 +150,37 @@ var _ = Describe("The Service Test", func() {
 				Complement:   "C",
 			},
 		}, nil)
-		newService := NewService(mockPostRepository, mockService)
-		post, err := newService.CreatePost(Post{
-			ID:     1,
-			IDUser: 2,
+		mockCounter := new(mockReactionCounter)
+		newService := NewService(mockPostRepository, mockService, auth.NewPolicy(), mockCounter, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.CreatePost(context.Background(), Post{
+			ID: 1,
 			//Date:    customDate,
 			Title:   "title1",
 			Content: "content1",
-		})
+		}, 2)
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(post.ID).Should(Equal(1))
 		Expect(post.Title).Should(Equal("title1"))
+		Expect(post.Reactions).Should(Equal(map[string]int{}))
+		mockCounter.AssertNotCalled(GinkgoT(), "CountReactions", mock.Anything, mock.Anything)
 	})
 	It("should CreatePost unsuccessfully", func() {
 		//customDate := time.Now().In(time.Local)
 		mockPostRepository.On("CreatePost", mock.AnythingOfType("Post")).Return(nil, errors.New("error while CreatePost()"))
 		mockService.On("GetUserByID", 2).Return(&user.User{}, nil)
-		newService := NewService(mockPostRepository, mockService)
-		post, err := newService.CreatePost(Post{
-			ID:     1,
-			IDUser: 2,
+		newService := NewService(mockPostRepository, mockService, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.CreatePost(context.Background(), Post{
+			ID: 1,
 			//Date:    customDate,
 			Title:   "title1",
 			Content: "content1",
-		})
+		}, 2)
 		Expect(err).Should(HaveOccurred())
 		Expect(post).Should(BeNil())
 	})
 	It("should GetPosts successfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockPostRepository.On("GetPosts").Return([]Post{
+		mockPostRepository.On("GetPosts", pagination.Page{Limit: 10}).Return([]Post{
 			{ID: 1,
 				IDUser:  2,
 				Date:    timeNow,
@@ -142,16 +188,29 @@ var _ = Describe("The Service Test", func() {
 				Content: "content1",
 			},
 		}, nil)
-		newService := NewService(mockPostRepository, nil)
-		posts, err := newService.GetPosts()
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetPosts(context.Background(), pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(posts[0].ID).Should(Equal(1))
 		Expect(posts[0].Title).Should(Equal("title1"))
 	})
+	It("should GetPosts with reaction counts", func() {
+		mockCounter := new(mockReactionCounter)
+		mockPostRepository.On("GetPosts", pagination.Page{Limit: 10}).Return([]Post{
+			{ID: 1, IDUser: 2, Title: "title1"},
+			{ID: 2, IDUser: 2, Title: "title2"},
+		}, nil)
+		mockCounter.On("CountReactions", "post", []int{1, 2}).Return(map[int]map[string]int{1: {"like": 2}}, nil)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), mockCounter, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetPosts(context.Background(), pagination.Page{Limit: 10})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(posts[0].Reactions).Should(Equal(map[string]int{"like": 2}))
+		Expect(posts[1].Reactions).Should(BeEmpty())
+	})
 	It("should GetPosts unsuccessfully", func() {
-		mockPostRepository.On("GetPosts").Return([]Post{}, errors.New("error while GetPosts()"))
-		newService := NewService(mockPostRepository, nil)
-		posts, err := newService.GetPosts()
+		mockPostRepository.On("GetPosts", pagination.Page{Limit: 10}).Return([]Post{}, errors.New("error while GetPosts()"))
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetPosts(context.Background(), pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 		Expect(len(posts)).Should(Equal(0))
 	})
@@ -164,21 +223,21 @@ var _ = Describe("The Service Test", func() {
 			Title:   "title1",
 			Content: "content1",
 		}, nil)
-		newService := NewService(mockPostRepository, nil)
-		post, err := newService.GetPostByID(1)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.GetPostByID(context.Background(), 1)
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(post.ID).Should(Equal(1))
 		Expect(post.Title).Should(Equal("title1"))
 	})
 	It("should GetPostByID unsuccessfully", func() {
 		mockPostRepository.On("GetPostByID", 2).Return(&Post{}, errors.New("error while GetPostByID()"))
-		newService := NewService(mockPostRepository, nil)
-		_, err := newService.GetPostByID(2)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		_, err := newService.GetPostByID(context.Background(), 2)
 		Expect(err).Should(HaveOccurred())
 	})
 	It("should GetPostByUserID successfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockPostRepository.On("GetPostByUserID", 2).Return([]Post{
+		mockPostRepository.On("GetPostByUserID", 2, pagination.Page{Limit: 10}).Return([]Post{
 			{ID: 1,
 				IDUser:  2,
 				Date:    timeNow,
@@ -186,22 +245,22 @@ var _ = Describe("The Service Test", func() {
 				Content: "content1",
 			},
 		}, nil)
-		newService := NewService(mockPostRepository, nil)
-		posts, err := newService.GetPostByUserID(2)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetPostByUserID(context.Background(), 2, pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(posts[0].ID).Should(Equal(1))
 		Expect(posts[0].Title).Should(Equal("title1"))
 	})
 	It("should GetPostByUserID unsuccessfully", func() {
-		mockPostRepository.On("GetPostByUserID", 1).Return([]Post{}, errors.New("error while GetPostByUserID()"))
-		newService := NewService(mockPostRepository, nil)
-		posts, err := newService.GetPostByUserID(1)
+		mockPostRepository.On("GetPostByUserID", 1, pagination.Page{Limit: 10}).Return([]Post{}, errors.New("error while GetPostByUserID()"))
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetPostByUserID(context.Background(), 1, pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 		Expect(len(posts)).Should(Equal(0))
 	})
 	It("should GetPostByDate successfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockPostRepository.On("GetPostByDate", timeNow).Return([]Post{
+		mockPostRepository.On("GetPostByDate", timeNow, pagination.Page{Limit: 10}).Return([]Post{
 			{ID: 1,
 				IDUser:  2,
 				Date:    timeNow,
@@ -209,23 +268,23 @@ var _ = Describe("The Service Test", func() {
 				Content: "content1",
 			},
 		}, nil)
-		newService := NewService(mockPostRepository, nil)
-		posts, err := newService.GetPostByDate(timeNow)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetPostByDate(context.Background(), timeNow, pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(posts[0].ID).Should(Equal(1))
 		Expect(posts[0].Title).Should(Equal("title1"))
 	})
 	It("should GetPostByDate unsuccessfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockPostRepository.On("GetPostByDate", timeNow).Return([]Post{}, errors.New("error while GetPostByDate()"))
-		newService := NewService(mockPostRepository, nil)
-		posts, err := newService.GetPostByDate(timeNow)
+		mockPostRepository.On("GetPostByDate", timeNow, pagination.Page{Limit: 10}).Return([]Post{}, errors.New("error while GetPostByDate()"))
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetPostByDate(context.Background(), timeNow, pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 		Expect(len(posts)).Should(Equal(0))
 	})
 	It("should GetPostByTitle successfully", func() {
 		timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
-		mockPostRepository.On("GetPostByTitle", "title1").Return([]Post{
+		mockPostRepository.On("GetPostByTitle", "title1", pagination.Page{Limit: 10}).Return([]Post{
 			{ID: 1,
 				IDUser:  2,
 				Date:    timeNow,
@@ -233,63 +292,180 @@ var _ = Describe("The Service Test", func() {
 				Content: "content1",
 			},
 		}, nil)
-		newService := NewService(mockPostRepository, nil)
-		posts, err := newService.GetPostByTitle("title1")
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetPostByTitle(context.Background(), "title1", pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(posts[0].ID).Should(Equal(1))
 		Expect(posts[0].Title).Should(Equal("title1"))
 	})
 	It("should GetPostByTitle unsuccessfully", func() {
-		mockPostRepository.On("GetPostByTitle", "title1").Return([]Post{}, errors.New("error while GetPostByTitle()"))
-		newService := NewService(mockPostRepository, nil)
-		posts, err := newService.GetPostByTitle("title1")
+		mockPostRepository.On("GetPostByTitle", "title1", pagination.Page{Limit: 10}).Return([]Post{}, errors.New("error while GetPostByTitle()"))
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetPostByTitle(context.Background(), "title1", pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 		Expect(len(posts)).Should(Equal(0))
 	})
 	It("should EditPost successfully", func() {
 		customDate := time.Now().In(time.Local)
-		mockPostRepository.On("EditPost", mock.AnythingOfType("Post"), 1).Return(&Post{
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2, Title: "title0", Content: "content0"}, nil)
+		mockPostRepository.On("EditPost", mock.MatchedBy(func(post Post) bool { return post.IDUser == 2 }), 1).Return(&Post{
 			ID:      1,
 			IDUser:  2,
 			Date:    customDate,
 			Title:   "title1",
 			Content: "content1",
 		}, nil)
-		newService := NewService(mockPostRepository, nil)
-		post, err := newService.EditPost(Post{
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.EditPost(context.Background(), Post{
 			ID:     1,
-			IDUser: 2,
+			IDUser: 3,
 			//Date:    customDate,
 			Title:   "title1",
 			Content: "content1",
-		}, 1)
+		}, 1, auth.Identity{ID: 2})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(post.ID).Should(Equal(1))
 		Expect(post.Title).Should(Equal("title1"))
 	})
 	It("should EditPost unsuccessfully", func() {
+		mockPostRepository.On("GetPostByID", 2).Return(&Post{ID: 2, IDUser: 2}, nil)
 		mockPostRepository.On("EditPost", mock.AnythingOfType("Post"), 2).Return(nil, errors.New("error while EditPost()"))
-		newService := NewService(mockPostRepository, nil)
-		post, err := newService.EditPost(Post{
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.EditPost(context.Background(), Post{
 			ID:     1,
 			IDUser: 2,
 			//Date:    customDate,
 			Title:   "title1",
 			Content: "content1",
-		}, 2)
+		}, 2, auth.Identity{ID: 2})
 		Expect(err).Should(HaveOccurred())
 		Expect(post).Should(BeNil())
 	})
+	It("should EditPost of another user as admin", func() {
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2}, nil)
+		mockPostRepository.On("EditPost", mock.MatchedBy(func(post Post) bool { return post.IDUser == 2 }), 1).Return(&Post{ID: 1, IDUser: 2, Title: "title1"}, nil)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.EditPost(context.Background(), Post{Title: "title1", Content: "content1"}, 1, auth.Identity{ID: 5, Role: auth.RoleAdmin})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(post.IDUser).Should(Equal(2))
+	})
+	It("should not EditPost of another user", func() {
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2}, nil)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.EditPost(context.Background(), Post{Title: "title1"}, 1, auth.Identity{ID: 3, Role: auth.RoleUser})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+		Expect(post).Should(BeNil())
+		mockPostRepository.AssertNotCalled(GinkgoT(), "EditPost", mock.Anything, mock.Anything)
+	})
+	It("should PatchPost successfully", func() {
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2, Title: "title1", Content: "content1"}, nil)
+		mockPostRepository.On("EditPost", mock.MatchedBy(func(post Post) bool {
+			return post.IDUser == 2 && post.Title == "title1" && post.Content == "content2"
+		}), 1).Return(&Post{ID: 1, IDUser: 2, Title: "title1", Content: "content2"}, nil)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.PatchPost(context.Background(), []byte(`{"Content":"content2","IDUser":3}`), 1, auth.Identity{ID: 2})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(post.Content).Should(Equal("content2"))
+	})
+	It("should not PatchPost removing the title", func() {
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2, Title: "title1", Content: "content1"}, nil)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.PatchPost(context.Background(), []byte(`{"Title":null}`), 1, auth.Identity{ID: 2})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+		Expect(post).Should(BeNil())
+		mockPostRepository.AssertNotCalled(GinkgoT(), "EditPost", mock.Anything, mock.Anything)
+	})
+	It("should not EditPost without content", func() {
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2}, nil)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.EditPost(context.Background(), Post{Title: "title1"}, 1, auth.Identity{ID: 2})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+		Expect(post).Should(BeNil())
+	})
 	It("should DeletePost successfully", func() {
-		mockPostRepository.On("DeletePost", 1).Return(nil)
-		newService := NewService(mockPostRepository, nil)
-		err := newService.DeletePost(1)
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2}, nil)
+		mockPostRepository.On("DeletePost", 1, mock.AnythingOfType("time.Time")).Return(nil)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		err := newService.DeletePost(context.Background(), 1, auth.Identity{ID: 2})
 		Expect(err).ShouldNot(HaveOccurred())
 	})
 	It("should DeletePost unsuccessfully", func() {
-		mockPostRepository.On("DeletePost", 1).Return(errors.New("error while DeletePost()"))
-		newService := NewService(mockPostRepository, nil)
-		err := newService.DeletePost(1)
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2}, nil)
+		mockPostRepository.On("DeletePost", 1, mock.AnythingOfType("time.Time")).Return(errors.New("error while DeletePost()"))
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		err := newService.DeletePost(context.Background(), 1, auth.Identity{ID: 2})
 		Expect(err).Should(HaveOccurred())
 	})
+	It("should not DeletePost of another user", func() {
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2}, nil)
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		err := newService.DeletePost(context.Background(), 1, auth.Identity{ID: 3})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+		mockPostRepository.AssertNotCalled(GinkgoT(), "DeletePost", mock.Anything, mock.Anything)
+	})
+	It("should not DeletePost with comments when the policy restricts it", func() {
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2}, nil)
+		mockPostRepository.On("HasComments", 1).Return(true, nil)
+		restrict := deletion.Policy{Cascade: deletion.Restrict, GracePeriod: time.Hour}
+		newService := NewService(mockPostRepository, nil, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, restrict)
+		err := newService.DeletePost(context.Background(), 1, auth.Identity{ID: 2})
+		Expect(errors.Is(err, apperror.ErrConflict)).Should(BeTrue())
+		mockPostRepository.AssertNotCalled(GinkgoT(), "DeletePost", mock.Anything, mock.Anything)
+	})
+	It("should RestorePost successfully", func() {
+		deletedAt := time.Now().UTC()
+		mockPostRepository.On("GetDeletedPost", 1).Return(&Post{ID: 1, IDUser: 2, DeletedAt: &deletedAt}, nil)
+		mockService.On("GetUserByID", 2).Return(&user.User{ID: 2}, nil)
+		mockPostRepository.On("RestorePost", 1).Return(nil)
+		mockPostRepository.On("GetPostByID", 1).Return(&Post{ID: 1, IDUser: 2, Title: "title1"}, nil)
+		newService := NewService(mockPostRepository, mockService, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.RestorePost(context.Background(), 1, auth.Identity{ID: 2})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(post.Title).Should(Equal("title1"))
+	})
+	It("should not RestorePost after the grace period", func() {
+		deletedAt := time.Now().UTC().Add(-2 * time.Hour)
+		mockPostRepository.On("GetDeletedPost", 1).Return(&Post{ID: 1, IDUser: 2, DeletedAt: &deletedAt}, nil)
+		newService := NewService(mockPostRepository, mockService, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.RestorePost(context.Background(), 1, auth.Identity{ID: 2})
+		Expect(errors.Is(err, apperror.ErrGone)).Should(BeTrue())
+		Expect(post).Should(BeNil())
+		mockPostRepository.AssertNotCalled(GinkgoT(), "RestorePost", mock.Anything)
+	})
+	It("should not RestorePost when the author is deleted", func() {
+		deletedAt := time.Now().UTC()
+		mockPostRepository.On("GetDeletedPost", 1).Return(&Post{ID: 1, IDUser: 2, DeletedAt: &deletedAt}, nil)
+		mockService.On("GetUserByID", 2).Return((*user.User)(nil), apperror.NotFound("the user is not in database"))
+		newService := NewService(mockPostRepository, mockService, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		post, err := newService.RestorePost(context.Background(), 1, auth.Identity{ID: 2})
+		Expect(errors.Is(err, apperror.ErrConflict)).Should(BeTrue())
+		Expect(post).Should(BeNil())
+	})
+	It("should GetFeed successfully", func() {
+		mockService.On("GetUserByID", 1).Return(&user.User{ID: 1}, nil)
+		mockPostRepository.On("GetFeed", 1, true, pagination.Page{Limit: 10}).Return([]Post{
+			{ID: 3, IDUser: 2, Title: "title3"},
+			{ID: 2, IDUser: 1, Title: "title2"},
+		}, nil)
+		newService := NewService(mockPostRepository, mockService, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetFeed(context.Background(), 1, true, pagination.Page{Limit: 10}, auth.Identity{ID: 1})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(posts).Should(HaveLen(2))
+		Expect(posts[0].ID).Should(Equal(3))
+	})
+	It("should not GetFeed of another user", func() {
+		newService := NewService(mockPostRepository, mockService, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetFeed(context.Background(), 1, false, pagination.Page{Limit: 10}, auth.Identity{ID: 2, Role: auth.RoleAdmin})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+		Expect(posts).Should(BeNil())
+		mockPostRepository.AssertNotCalled(GinkgoT(), "GetFeed", mock.Anything, mock.Anything, mock.Anything)
+	})
+	It("should GetFeed unsuccessfully when the user doesn't exist", func() {
+		mockService.On("GetUserByID", 9).Return((*user.User)(nil), apperror.NotFound("the user is not in database"))
+		newService := NewService(mockPostRepository, mockService, auth.NewPolicy(), nil, dialecttest.UnitOfWork{}, deletions)
+		posts, err := newService.GetFeed(context.Background(), 9, false, pagination.Page{Limit: 10}, auth.Identity{ID: 9})
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+		Expect(posts).Should(BeNil())
+		mockPostRepository.AssertNotCalled(GinkgoT(), "GetFeed", mock.Anything, mock.Anything, mock.Anything)
+	})
 })
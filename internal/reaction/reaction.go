@@ -0,0 +1,48 @@
+package reaction
+
+import (
+	"socialBuddy/internal/apperror"
+	"time"
+)
+
+const (
+	TargetPost    = "post"
+	TargetComment = "comment"
+)
+
+var emojis = map[string]string{
+	"like":  "👍",
+	"love":  "❤️",
+	"haha":  "😂",
+	"wow":   "😮",
+	"sad":   "😢",
+	"angry": "😡",
+}
+
+type Reaction struct {
+	ID         int
+	IDUser     int
+	TargetType string
+	IDTarget   int
+	Type       string
+	Emoji      string
+	Date       time.Time
+}
+
+// Target is the post or comment a reaction belongs to, IDPost is the post of a comment.
+type Target struct {
+	Type   string
+	ID     int
+	IDPost int
+}
+
+type Request struct {
+	Type string `json:"type"`
+}
+
+func typeValidation(reactionType string) error {
+	if _, ok := emojis[reactionType]; !ok {
+		return apperror.Validation("the reaction type must be one of like, love, haha, wow, sad or angry")
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package reaction
+
+import (
+	"context"
+	"database/sql"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/pagination"
+	"strings"
+)
+
+type Repository interface {
+	React(ctx context.Context, reaction Reaction) (*Reaction, error)
+	GetReaction(ctx context.Context, idUser int, target Target) (*Reaction, error)
+	GetReactions(ctx context.Context, target Target, reactionType string, page pagination.Page) ([]Reaction, error)
+	DeleteReaction(ctx context.Context, idUser int, target Target) error
+	CountReactions(ctx context.Context, targetType string, ids []int) (map[int]map[string]int, error)
+}
+
+type repository struct {
+	db *dialect.DB
+}
+
+const selectReaction = `SELECT ID, IDUser, TargetType, IDTarget, Type, DateReaction FROM Reaction`
+
+// selectLiveReaction leaves out the reactions of the deleted users, they come back if the user is restored.
+const selectLiveReaction = `SELECT Reaction.ID, Reaction.IDUser, Reaction.TargetType, Reaction.IDTarget, Reaction.Type,
+	Reaction.DateReaction FROM Reaction INNER JOIN Users ON Users.ID = Reaction.IDUser`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReaction(row scanner) (Reaction, error) {
+	var reaction Reaction
+	err := row.Scan(
+		&reaction.ID,
+		&reaction.IDUser,
+		&reaction.TargetType,
+		&reaction.IDTarget,
+		&reaction.Type,
+		&reaction.Date,
+	)
+	reaction.Emoji = emojis[reaction.Type]
+	return reaction, err
+}
+
+func (r *repository) React(ctx context.Context, reaction Reaction) (*Reaction, error) {
+	ctx = dialect.Operation(ctx, "reaction", "React")
+	var saved *Reaction
+	err := r.db.Transact(ctx, func(ctx context.Context) error {
+		_, err := r.db.ExecContext(ctx, `INSERT INTO Reaction (IDUser, TargetType, IDTarget, Type, DateReaction) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (IDUser, TargetType, IDTarget) DO UPDATE SET Type = excluded.Type, DateReaction = excluded.DateReaction`,
+			reaction.IDUser, reaction.TargetType, reaction.IDTarget, reaction.Type, reaction.Date)
+		if err != nil {
+			return err
+		}
+		saved, err = r.GetReaction(ctx, reaction.IDUser, Target{Type: reaction.TargetType, ID: reaction.IDTarget})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (r *repository) GetReaction(ctx context.Context, idUser int, target Target) (*Reaction, error) {
+	ctx = dialect.Operation(ctx, "reaction", "GetReaction")
+	reaction, err := scanReaction(r.db.QueryRowContext(ctx, selectReaction+" WHERE IDUser = ? AND TargetType = ? AND IDTarget = ?",
+		idUser, target.Type, target.ID))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("the reaction is not in database")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reaction, nil
+}
+
+func (r *repository) GetReactions(ctx context.Context, target Target, reactionType string, page pagination.Page) ([]Reaction, error) {
+	ctx = dialect.Operation(ctx, "reaction", "GetReactions")
+	rows, err := r.db.QueryContext(ctx, selectLiveReaction+` WHERE Reaction.TargetType = ? AND Reaction.IDTarget = ?
+		AND (? = '' OR Reaction.Type = ?) AND Users.DeletedAt IS NULL AND Reaction.ID > ? ORDER BY Reaction.ID LIMIT ?`, target.Type, target.ID, reactionType, reactionType, page.After, page.Fetch())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var reactions []Reaction
+	for rows.Next() {
+		reaction, err := scanReaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, reaction)
+	}
+	return reactions, rows.Err()
+}
+
+func (r *repository) DeleteReaction(ctx context.Context, idUser int, target Target) error {
+	ctx = dialect.Operation(ctx, "reaction", "DeleteReaction")
+	res, err := r.db.ExecContext(ctx, "DELETE FROM Reaction WHERE IDUser = ? AND TargetType = ? AND IDTarget = ?", idUser, target.Type, target.ID)
+	if err != nil {
+		return err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return apperror.NotFound("the reaction is not in database")
+	}
+	return nil
+}
+
+// CountReactions returns, for every id, how many reactions of each type it has from the users not deleted.
+func (r *repository) CountReactions(ctx context.Context, targetType string, ids []int) (map[int]map[string]int, error) {
+	ctx = dialect.Operation(ctx, "reaction", "CountReactions")
+	counts := make(map[int]map[string]int, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	args := []any{targetType}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT Reaction.IDTarget, Reaction.Type, COUNT(*) FROM Reaction
+		INNER JOIN Users ON Users.ID = Reaction.IDUser WHERE Reaction.TargetType = ? AND Reaction.IDTarget IN (?`+
+		strings.Repeat(", ?", len(ids)-1)+`) AND Users.DeletedAt IS NULL GROUP BY Reaction.IDTarget, Reaction.Type`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var idTarget, count int
+		var reactionType string
+		err := rows.Scan(&idTarget, &reactionType, &count)
+		if err != nil {
+			return nil, err
+		}
+		if counts[idTarget] == nil {
+			counts[idTarget] = make(map[string]int)
+		}
+		counts[idTarget][reactionType] = count
+	}
+	return counts, rows.Err()
+}
+
+func NewRepository(db *dialect.DB) Repository {
+	return &repository{db}
+}
@@ -0,0 +1,59 @@
+package reaction
+
+import (
+	"context"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/dialect/dialecttest"
+	"socialBuddy/internal/pagination"
+	"testing"
+	"time"
+)
+
+func TestRepositoryDialects(t *testing.T) {
+	dialecttest.Run(t, func(t *testing.T, db *dialect.DB) {
+		rep := NewRepository(db)
+		idFirst, err := db.Insert("INSERT INTO Users (Name, Email) VALUES (?, ?)", "Name First", "first@gmail.com")
+		if err != nil {
+			t.Fatalf("the creation of user is failed %v", err)
+		}
+		idSecond, err := db.Insert("INSERT INTO Users (Name, Email) VALUES (?, ?)", "Name Second", "second@gmail.com")
+		if err != nil {
+			t.Fatalf("the creation of user is failed %v", err)
+		}
+		idPost, err := db.Insert("INSERT INTO Posts (IDUser, DatePost, Title, Content) VALUES (?, ?, ?, ?)",
+			idFirst, time.Now(), "title1", "content1")
+		if err != nil {
+			t.Fatalf("the creation of post is failed %v", err)
+		}
+		target := Target{Type: TargetPost, ID: int(idPost)}
+		for _, idUser := range []int64{idFirst, idSecond} {
+			_, err = rep.React(context.Background(), Reaction{IDUser: int(idUser), TargetType: target.Type, IDTarget: target.ID,
+				Type: "like", Date: time.Now().UTC()})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		_, err = db.Exec("UPDATE Users SET DeletedAt = ? WHERE ID = ?", time.Now().UTC(), idSecond)
+		if err != nil {
+			t.Fatalf("the deletion of user is failed %v", err)
+		}
+		reactions, err := rep.GetReactions(context.Background(), target, "", pagination.Page{Limit: 10})
+		if err != nil || len(reactions) != 1 || reactions[0].IDUser != int(idFirst) {
+			t.Fatalf("expected only the reaction of the user not deleted, got %+v, err %v", reactions, err)
+		}
+		counts, err := rep.CountReactions(context.Background(), TargetPost, []int{target.ID})
+		if err != nil || counts[target.ID]["like"] != 1 {
+			t.Fatalf("expected one like, got %v, err %v", counts, err)
+		}
+
+		_, err = db.Exec("UPDATE Users SET DeletedAt = NULL WHERE ID = ?", idSecond)
+		if err != nil {
+			t.Fatalf("the restore of user is failed %v", err)
+		}
+		counts, err = rep.CountReactions(context.Background(), TargetPost, []int{target.ID})
+		if err != nil || counts[target.ID]["like"] != 2 {
+			t.Fatalf("expected the likes of the restored user to count again, got %v, err %v", counts, err)
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package reaction
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"reflect"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/pagination"
+	"testing"
+	"time"
+)
+
+type argReact struct {
+	name     string
+	reaction Reaction
+	output   *Reaction
+	hasError error
+}
+
+type argGetReactions struct {
+	name         string
+	target       Target
+	reactionType string
+	page         pagination.Page
+	output       []Reaction
+	hasError     error
+}
+
+type argDeleteReaction struct {
+	name     string
+	idUser   int
+	target   Target
+	hasError error
+}
+
+type argCountReactions struct {
+	name       string
+	targetType string
+	ids        []int
+	output     map[int]map[string]int
+	hasError   error
+}
+
+var reactionColumns = []string{"ID", "IDUser", "TargetType", "IDTarget", "Type", "DateReaction"}
+
+func TestReact(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
+
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO Reaction .* ON CONFLICT").
+		WithArgs(1, TargetPost, 2, "love", timeNow).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT (.+) FROM Reaction WHERE IDUser = \\? AND TargetType = \\? AND IDTarget = \\?").
+		WithArgs(1, TargetPost, 2).
+		WillReturnRows(sqlmock.NewRows(reactionColumns).AddRow(1, 1, TargetPost, 2, "love", timeNow))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO Reaction .* ON CONFLICT").
+		WithArgs(1, TargetComment, 3, "like", timeNow).
+		WillReturnError(errors.New("the reaction is failed"))
+	mock.ExpectRollback()
+
+	test := []argReact{
+		{
+			name:     "React() on a post",
+			reaction: Reaction{IDUser: 1, TargetType: TargetPost, IDTarget: 2, Type: "love", Date: timeNow},
+			output:   &Reaction{ID: 1, IDUser: 1, TargetType: TargetPost, IDTarget: 2, Type: "love", Emoji: "❤️", Date: timeNow},
+			hasError: nil,
+		},
+		{
+			name:     "React() is failed",
+			reaction: Reaction{IDUser: 1, TargetType: TargetComment, IDTarget: 3, Type: "like", Date: timeNow},
+			output:   nil,
+			hasError: errors.New("the reaction is failed"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			reaction, err := rep.React(context.Background(), tt.reaction)
+			if !reflect.DeepEqual(reaction, tt.output) {
+				t.Fatalf("expected %+v, got %+v", tt.output, reaction)
+			}
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
+
+func TestGetReactions(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
+
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectQuery("SELECT (.+) FROM Reaction INNER JOIN Users (.+) WHERE Reaction.TargetType = \\? AND Reaction.IDTarget = \\? .* Users.DeletedAt IS NULL .* ORDER BY Reaction.ID LIMIT \\?").
+		WithArgs(TargetPost, 2, "", "", 0, 21).
+		WillReturnRows(sqlmock.NewRows(reactionColumns).
+			AddRow(1, 1, TargetPost, 2, "love", timeNow).
+			AddRow(2, 3, TargetPost, 2, "haha", timeNow))
+	mock.ExpectQuery("SELECT (.+) FROM Reaction INNER JOIN Users (.+) WHERE Reaction.TargetType = \\? AND Reaction.IDTarget = \\? .* Users.DeletedAt IS NULL .* ORDER BY Reaction.ID LIMIT \\?").
+		WithArgs(TargetComment, 3, "sad", "sad", 4, 6).
+		WillReturnError(errors.New("the list is failed"))
+
+	test := []argGetReactions{
+		{
+			name:   "GetReactions() of a post",
+			target: Target{Type: TargetPost, ID: 2},
+			page:   pagination.Page{Limit: 20},
+			output: []Reaction{
+				{ID: 1, IDUser: 1, TargetType: TargetPost, IDTarget: 2, Type: "love", Emoji: "❤️", Date: timeNow},
+				{ID: 2, IDUser: 3, TargetType: TargetPost, IDTarget: 2, Type: "haha", Emoji: "😂", Date: timeNow},
+			},
+			hasError: nil,
+		},
+		{
+			name:         "GetReactions() is failed",
+			target:       Target{Type: TargetComment, ID: 3, IDPost: 2},
+			reactionType: "sad",
+			page:         pagination.Page{Limit: 5, After: 4},
+			output:       nil,
+			hasError:     errors.New("the list is failed"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			reactions, err := rep.GetReactions(context.Background(), tt.target, tt.reactionType, tt.page)
+			if !reflect.DeepEqual(reactions, tt.output) {
+				t.Fatalf("expected %+v, got %+v", tt.output, reactions)
+			}
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
+
+func TestDeleteReaction(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectExec("DELETE FROM Reaction WHERE IDUser = \\? AND TargetType = \\? AND IDTarget = \\?").
+		WithArgs(1, TargetPost, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM Reaction WHERE IDUser = \\? AND TargetType = \\? AND IDTarget = \\?").
+		WithArgs(1, TargetComment, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	test := []argDeleteReaction{
+		{
+			name:     "DeleteReaction() of a post",
+			idUser:   1,
+			target:   Target{Type: TargetPost, ID: 2},
+			hasError: nil,
+		},
+		{
+			name:     "DeleteReaction() is not found",
+			idUser:   1,
+			target:   Target{Type: TargetComment, ID: 3},
+			hasError: errors.New("the reaction is not in database"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rep.DeleteReaction(context.Background(), tt.idUser, tt.target)
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
+
+func TestCountReactions(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectQuery("SELECT Reaction.IDTarget, Reaction.Type, COUNT\\(\\*\\) FROM Reaction INNER JOIN Users (.+) WHERE Reaction.TargetType = \\? AND Reaction.IDTarget IN \\(\\?, \\?\\) AND Users.DeletedAt IS NULL GROUP BY Reaction.IDTarget, Reaction.Type").
+		WithArgs(TargetPost, 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"IDTarget", "Type", "COUNT(*)"}).
+			AddRow(1, "like", 3).
+			AddRow(1, "love", 1).
+			AddRow(2, "wow", 2))
+
+	test := []argCountReactions{
+		{
+			name:       "CountReactions() of two posts",
+			targetType: TargetPost,
+			ids:        []int{1, 2},
+			output:     map[int]map[string]int{1: {"like": 3, "love": 1}, 2: {"wow": 2}},
+			hasError:   nil,
+		},
+		{
+			name:       "CountReactions() without ids",
+			targetType: TargetComment,
+			ids:        nil,
+			output:     map[int]map[string]int{},
+			hasError:   nil,
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			counts, err := rep.CountReactions(context.Background(), tt.targetType, tt.ids)
+			if !reflect.DeepEqual(counts, tt.output) {
+				t.Fatalf("expected %+v, got %+v", tt.output, counts)
+			}
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
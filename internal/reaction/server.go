@@ -0,0 +1,144 @@
+package reaction
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/pagination"
+	"strconv"
+)
+
+type Server struct {
+	reactionService Service
+}
+
+func (s *Server) ReactPost(w http.ResponseWriter, r *http.Request) {
+	s.react(w, r, TargetPost)
+}
+
+func (s *Server) ReactCom(w http.ResponseWriter, r *http.Request) {
+	s.react(w, r, TargetComment)
+}
+
+func (s *Server) GetPostReactions(w http.ResponseWriter, r *http.Request) {
+	s.getReactions(w, r, TargetPost)
+}
+
+func (s *Server) GetComReactions(w http.ResponseWriter, r *http.Request) {
+	s.getReactions(w, r, TargetComment)
+}
+
+func (s *Server) DeletePostReaction(w http.ResponseWriter, r *http.Request) {
+	s.deleteReaction(w, r, TargetPost)
+}
+
+func (s *Server) DeleteComReaction(w http.ResponseWriter, r *http.Request) {
+	s.deleteReaction(w, r, TargetComment)
+}
+
+func (s *Server) react(w http.ResponseWriter, r *http.Request, targetType string) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
+	target, err := targetFromRequest(r, targetType)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	var req Request
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	err = r.Body.Close()
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	reaction, err := s.reactionService.React(r.Context(), target, req.Type, identity)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(reaction)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func (s *Server) getReactions(w http.ResponseWriter, r *http.Request, targetType string) {
+	target, err := targetFromRequest(r, targetType)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	reactions, err := s.reactionService.GetReactions(r.Context(), target, r.URL.Query().Get("type"), page)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(reactions, page, reactionID))
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func (s *Server) deleteReaction(w http.ResponseWriter, r *http.Request, targetType string) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
+	target, err := targetFromRequest(r, targetType)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	err = s.reactionService.DeleteReaction(r.Context(), target, identity)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func targetFromRequest(r *http.Request, targetType string) (Target, error) {
+	if targetType == TargetPost {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			return Target{}, err
+		}
+		return Target{Type: TargetPost, ID: id}, nil
+	}
+	idPost, err := strconv.Atoi(chi.URLParam(r, "id_post"))
+	if err != nil {
+		return Target{}, err
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return Target{}, err
+	}
+	return Target{Type: TargetComment, ID: id, IDPost: idPost}, nil
+}
+
+func reactionID(reaction Reaction) int {
+	return reaction.ID
+}
+
+func NewServer(reactionService Service) *Server {
+	return &Server{reactionService}
+}
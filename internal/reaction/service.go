@@ -0,0 +1,103 @@
+package reaction
+
+import (
+	"context"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/comment"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/pagination"
+	"socialBuddy/internal/post"
+	"socialBuddy/internal/user"
+	"time"
+)
+
+type service struct {
+	ReactionRepository Repository
+	PostService        post.Service
+	ComService         comment.Service
+	UserService        user.Service
+	UnitOfWork         dialect.UnitOfWork
+}
+
+type Service interface {
+	React(ctx context.Context, target Target, reactionType string, actor auth.Identity) (*Reaction, error)
+	GetReactions(ctx context.Context, target Target, reactionType string, page pagination.Page) ([]Reaction, error)
+	DeleteReaction(ctx context.Context, target Target, actor auth.Identity) error
+}
+
+func (s *service) React(ctx context.Context, target Target, reactionType string, actor auth.Identity) (*Reaction, error) {
+	err := typeValidation(reactionType)
+	if err != nil {
+		return nil, err
+	}
+	var reaction *Reaction
+	err = s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		// a deleted user keeps a valid access token until it expires, as in the creation of posts and comments
+		err := post.ValidateIDUser(ctx, actor.ID, s.UserService)
+		if err != nil {
+			return err
+		}
+		err = s.validateTarget(ctx, target)
+		if err != nil {
+			return err
+		}
+		reaction, err = s.ReactionRepository.React(ctx, Reaction{
+			IDUser:     actor.ID,
+			TargetType: target.Type,
+			IDTarget:   target.ID,
+			Type:       reactionType,
+			Date:       time.Now(),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reaction, nil
+}
+
+func (s *service) GetReactions(ctx context.Context, target Target, reactionType string, page pagination.Page) ([]Reaction, error) {
+	if reactionType != "" {
+		err := typeValidation(reactionType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := s.validateTarget(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := s.ReactionRepository.GetReactions(ctx, target, reactionType, page)
+	if err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
+
+func (s *service) DeleteReaction(ctx context.Context, target Target, actor auth.Identity) error {
+	return s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		err := s.validateTarget(ctx, target)
+		if err != nil {
+			return err
+		}
+		return s.ReactionRepository.DeleteReaction(ctx, actor.ID, target)
+	})
+}
+
+func (s *service) validateTarget(ctx context.Context, target Target) error {
+	switch target.Type {
+	case TargetPost:
+		_, err := s.PostService.GetPostByID(ctx, target.ID)
+		return err
+	case TargetComment:
+		_, err := s.ComService.GetComByID(ctx, target.ID, target.IDPost)
+		return err
+	default:
+		return apperror.Validation("the reaction target is not valid")
+	}
+}
+
+func NewService(reactionRepository Repository, postService post.Service, comService comment.Service, userService user.Service, unitOfWork dialect.UnitOfWork) Service {
+	return &service{reactionRepository, postService, comService, userService, unitOfWork}
+}
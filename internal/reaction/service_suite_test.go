@@ -0,0 +1,13 @@
+package reaction
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestReactionService(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Reaction Service Suite")
+}
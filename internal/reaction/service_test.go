@@ -0,0 +1,178 @@
+package reaction
+
+import (
+	"context"
+	"errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/comment"
+	"socialBuddy/internal/dialect/dialecttest"
+	"socialBuddy/internal/pagination"
+	"socialBuddy/internal/post"
+	"socialBuddy/internal/user"
+	"time"
+)
+
+type mockRepository struct {
+	mock.Mock
+}
+
+type mockPostService struct {
+	mock.Mock
+	post.Service
+}
+
+type mockComService struct {
+	mock.Mock
+	comment.Service
+}
+
+type mockUserService struct {
+	mock.Mock
+	user.Service
+}
+
+func (m *mockRepository) React(ctx context.Context, reaction Reaction) (*Reaction, error) {
+	args := m.Called(reaction)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Reaction), args.Error(1)
+}
+
+func (m *mockRepository) GetReaction(ctx context.Context, idUser int, target Target) (*Reaction, error) {
+	args := m.Called(idUser, target)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Reaction), args.Error(1)
+}
+
+func (m *mockRepository) GetReactions(ctx context.Context, target Target, reactionType string, page pagination.Page) ([]Reaction, error) {
+	args := m.Called(target, reactionType, page)
+	return args.Get(0).([]Reaction), args.Error(1)
+}
+
+func (m *mockRepository) DeleteReaction(ctx context.Context, idUser int, target Target) error {
+	args := m.Called(idUser, target)
+	return args.Error(0)
+}
+
+func (m *mockRepository) CountReactions(ctx context.Context, targetType string, ids []int) (map[int]map[string]int, error) {
+	args := m.Called(targetType, ids)
+	return args.Get(0).(map[int]map[string]int), args.Error(1)
+}
+
+func (m *mockPostService) GetPostByID(ctx context.Context, idPost int) (*post.Post, error) {
+	args := m.Called(idPost)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*post.Post), args.Error(1)
+}
+
+func (m *mockComService) GetComByID(ctx context.Context, idCom int, idPost int) (*comment.Comment, error) {
+	args := m.Called(idCom, idPost)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*comment.Comment), args.Error(1)
+}
+
+func (m *mockUserService) GetUserByID(ctx context.Context, idUser int) (*user.User, error) {
+	args := m.Called(idUser)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+var _ = Describe("The Service Test", func() {
+	var (
+		mockReactionRepository *mockRepository
+		mockServicePost        *mockPostService
+		mockServiceCom         *mockComService
+		mockServiceUser        *mockUserService
+		actor                  auth.Identity
+	)
+	BeforeEach(func() {
+		mockReactionRepository = new(mockRepository)
+		mockServicePost = new(mockPostService)
+		mockServiceCom = new(mockComService)
+		mockServiceUser = new(mockUserService)
+		mockServiceUser.On("GetUserByID", 1).Return(&user.User{ID: 1}, nil)
+		actor = auth.Identity{ID: 1, Role: auth.RoleUser}
+	})
+	It("should React to a post successfully", func() {
+		customDate := time.Now().In(time.Local)
+		mockServicePost.On("GetPostByID", 2).Return(&post.Post{ID: 2, IDUser: 3}, nil)
+		mockReactionRepository.On("React", mock.MatchedBy(func(reaction Reaction) bool {
+			return reaction.IDUser == 1 && reaction.TargetType == TargetPost && reaction.IDTarget == 2 && reaction.Type == "love"
+		})).Return(&Reaction{ID: 1, IDUser: 1, TargetType: TargetPost, IDTarget: 2, Type: "love", Emoji: "❤️", Date: customDate}, nil)
+		newService := NewService(mockReactionRepository, mockServicePost, mockServiceCom, mockServiceUser, dialecttest.UnitOfWork{})
+		reaction, err := newService.React(context.Background(), Target{Type: TargetPost, ID: 2}, "love", actor)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(reaction.Emoji).Should(Equal("❤️"))
+	})
+	It("should not React as a deleted user", func() {
+		deleted := new(mockUserService)
+		deleted.On("GetUserByID", 1).Return(nil, apperror.NotFound("the user is not in database"))
+		newService := NewService(mockReactionRepository, mockServicePost, mockServiceCom, deleted, dialecttest.UnitOfWork{})
+		reaction, err := newService.React(context.Background(), Target{Type: TargetPost, ID: 2}, "love", actor)
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+		Expect(reaction).Should(BeNil())
+		mockReactionRepository.AssertNotCalled(GinkgoT(), "React", mock.Anything)
+	})
+	It("should not React with an unknown type", func() {
+		newService := NewService(mockReactionRepository, mockServicePost, mockServiceCom, mockServiceUser, dialecttest.UnitOfWork{})
+		reaction, err := newService.React(context.Background(), Target{Type: TargetPost, ID: 2}, "clap", actor)
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+		Expect(reaction).Should(BeNil())
+		mockReactionRepository.AssertNotCalled(GinkgoT(), "React", mock.Anything)
+	})
+	It("should not React to a missing post", func() {
+		mockServicePost.On("GetPostByID", 2).Return(nil, apperror.NotFound("the post is not in database"))
+		newService := NewService(mockReactionRepository, mockServicePost, mockServiceCom, mockServiceUser, dialecttest.UnitOfWork{})
+		reaction, err := newService.React(context.Background(), Target{Type: TargetPost, ID: 2}, "like", actor)
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+		Expect(reaction).Should(BeNil())
+	})
+	It("should not React to a comment of another post", func() {
+		mockServiceCom.On("GetComByID", 3, 2).Return(nil, apperror.NotFound("the comment is not in the post"))
+		newService := NewService(mockReactionRepository, mockServicePost, mockServiceCom, mockServiceUser, dialecttest.UnitOfWork{})
+		reaction, err := newService.React(context.Background(), Target{Type: TargetComment, ID: 3, IDPost: 2}, "like", actor)
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+		Expect(reaction).Should(BeNil())
+	})
+	It("should GetReactions of a comment successfully", func() {
+		page := pagination.Page{Limit: 20}
+		target := Target{Type: TargetComment, ID: 3, IDPost: 2}
+		mockServiceCom.On("GetComByID", 3, 2).Return(&comment.Comment{ID: 3, IDPost: 2}, nil)
+		mockReactionRepository.On("GetReactions", target, "wow", page).Return([]Reaction{
+			{ID: 1, IDUser: 1, TargetType: TargetComment, IDTarget: 3, Type: "wow", Emoji: "😮"},
+		}, nil)
+		newService := NewService(mockReactionRepository, mockServicePost, mockServiceCom, mockServiceUser, dialecttest.UnitOfWork{})
+		reactions, err := newService.GetReactions(context.Background(), target, "wow", page)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(reactions).Should(HaveLen(1))
+	})
+	It("should DeleteReaction successfully", func() {
+		target := Target{Type: TargetPost, ID: 2}
+		mockServicePost.On("GetPostByID", 2).Return(&post.Post{ID: 2, IDUser: 3}, nil)
+		mockReactionRepository.On("DeleteReaction", 1, target).Return(nil)
+		newService := NewService(mockReactionRepository, mockServicePost, mockServiceCom, mockServiceUser, dialecttest.UnitOfWork{})
+		err := newService.DeleteReaction(context.Background(), target, actor)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("should DeleteReaction unsuccessfully", func() {
+		target := Target{Type: TargetPost, ID: 2}
+		mockServicePost.On("GetPostByID", 2).Return(&post.Post{ID: 2, IDUser: 3}, nil)
+		mockReactionRepository.On("DeleteReaction", 1, target).Return(apperror.NotFound("the reaction is not in database"))
+		newService := NewService(mockReactionRepository, mockServicePost, mockServiceCom, mockServiceUser, dialecttest.UnitOfWork{})
+		err := newService.DeleteReaction(context.Background(), target, actor)
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+	})
+})
@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"socialBuddy/internal/dialect"
+	"strings"
+)
+
+const (
+	snippetOpen  = "<mark>"
+	snippetClose = "</mark>"
+	dateFormat   = "2006-01-02"
+)
+
+const searchPosts = `SELECT 'post' AS Kind, Posts.ID, Posts.ID, Posts.IDUser, Posts.DatePost, Posts.Title,
+	snippet(PostSearch, -1, ?, ?, '...', 16), bm25(PostSearch, 2.0, 1.0) AS Rank
+	FROM PostSearch INNER JOIN Posts ON Posts.ID = PostSearch.rowid
+	WHERE PostSearch MATCH ? AND Posts.DeletedAt IS NULL AND (? = 0 OR Posts.IDUser = ?)
+	AND strftime('%Y-%m-%d', Posts.DatePost) BETWEEN ? AND ?`
+
+const searchComments = `SELECT 'comment' AS Kind, Comment.ID, Comment.IDPost, Comment.IDUser, Comment.DateComment, '',
+	snippet(CommentSearch, -1, ?, ?, '...', 16), bm25(CommentSearch) AS Rank
+	FROM CommentSearch INNER JOIN Comment ON Comment.ID = CommentSearch.rowid
+	WHERE CommentSearch MATCH ? AND Comment.DeletedAt IS NULL AND (? = 0 OR Comment.IDUser = ?)
+	AND strftime('%Y-%m-%d', Comment.DateComment) BETWEEN ? AND ?`
+
+type Repository interface {
+	Search(ctx context.Context, query Query) ([]Result, error)
+}
+
+type repository struct {
+	db *dialect.DB
+}
+
+func (r *repository) Search(ctx context.Context, query Query) ([]Result, error) {
+	ctx = dialect.Operation(ctx, "search", "Search")
+	from, to := "0000-01-01", "9999-12-31"
+	if !query.From.IsZero() {
+		from = query.From.Format(dateFormat)
+	}
+	if !query.To.IsZero() {
+		to = query.To.Format(dateFormat)
+	}
+	match := matchExpression(query.Text)
+
+	var parts []string
+	var args []any
+	if query.Kind == "" || query.Kind == KindPost {
+		parts = append(parts, searchPosts)
+		args = append(args, snippetOpen, snippetClose, match, query.IDUser, query.IDUser, from, to)
+	}
+	if query.Kind == "" || query.Kind == KindComment {
+		parts = append(parts, searchComments)
+		args = append(args, snippetOpen, snippetClose, match, query.IDUser, query.IDUser, from, to)
+	}
+	args = append(args, query.Limit)
+
+	rows, err := r.db.QueryContext(ctx, strings.Join(parts, "\n\tUNION ALL\n\t")+"\n\tORDER BY Rank LIMIT ?", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var results []Result
+	for rows.Next() {
+		var result Result
+		var rank float64
+		err := rows.Scan(
+			&result.Kind,
+			&result.ID,
+			&result.IDPost,
+			&result.IDUser,
+			&result.Date,
+			&result.Title,
+			&result.Snippet,
+			&rank,
+		)
+		if err != nil {
+			return nil, err
+		}
+		// bm25 is lower for better matches, the score is flipped so clients can sort descending
+		result.Score = -rank
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// Available tells whether the full-text index was created, it needs a build with the sqlite_fts5 tag.
+// A database that has the index cannot be written by a binary without FTS5, the triggers on Posts and
+// Comment fail every insert, so Available returns an error for it and the server must not start.
+func Available(ctx context.Context, db *dialect.DB) (bool, error) {
+	if db.Dialect().Name() != dialect.NameSQLite {
+		return false, nil
+	}
+	var count int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'PostSearch'").Scan(&count)
+	if err != nil || count == 0 {
+		return false, err
+	}
+	var fts5 bool
+	err = db.QueryRowContext(ctx, "SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5)
+	if err != nil {
+		return false, err
+	}
+	if !fts5 {
+		return false, errors.New("the database has the full-text search index but the binary is built without FTS5, build it with -tags sqlite_fts5")
+	}
+	return true, nil
+}
+
+func NewRepository(db *dialect.DB) Repository {
+	return &repository{db}
+}
@@ -0,0 +1,91 @@
+//go:build sqlite_fts5 || fts5
+
+package search
+
+import (
+	"context"
+	_ "github.com/mattn/go-sqlite3"
+	"path/filepath"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/migration"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openDB(t *testing.T) *dialect.DB {
+	db, err := dialect.Open(dialect.NameSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("the creation of database is failed %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	migrations, err := migration.SQLite()
+	if err != nil {
+		t.Fatalf("the load of migrations is failed %v", err)
+	}
+	_, err = migration.NewMigrator(db, migrations).Up()
+	if err != nil {
+		t.Fatalf("the migration is failed %v", err)
+	}
+	return db
+}
+
+func TestSearchWithFTS5(t *testing.T) {
+	db := openDB(t)
+	available, err := Available(context.Background(), db)
+	if err != nil || !available {
+		t.Fatalf("expected the search index to be available, got %v, err %v", available, err)
+	}
+	day := time.Date(2023, 11, 13, 10, 0, 0, 0, time.UTC)
+	_, err = db.Exec(`INSERT INTO Users (ID, Name) VALUES (1, 'Name First'), (2, 'Name Second');
+INSERT INTO Posts (ID, IDUser, DatePost, Title, Content) VALUES
+	(1, 1, ?, 'Golang tips', 'channels and goroutines'),
+	(2, 2, ?, 'Cooking', 'a recipe with golang fish'),
+	(3, 2, ?, 'Travel', 'nothing related');
+INSERT INTO Comment (ID, IDPost, IDUser, DateComment, Content) VALUES (1, 3, 1, ?, 'I prefer golang');`,
+		day, day.AddDate(0, 0, 1), day, day.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("the insert of rows is failed %v", err)
+	}
+	rep := NewRepository(db)
+
+	results, err := rep.Search(context.Background(), Query{Text: "golang", Limit: 10})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(results) != 3 || results[0].ID != 1 || results[0].Kind != KindPost {
+		t.Fatalf("expected the title match first, got %+v", results)
+	}
+	if !strings.Contains(results[0].Snippet, "<mark>Golang</mark>") || !results[0].Date.Equal(day) {
+		t.Fatalf("unexpected result %+v", results[0])
+	}
+
+	results, err = rep.Search(context.Background(), Query{Text: "golang", IDUser: 1, Kind: KindComment, Limit: 10})
+	if err != nil || len(results) != 1 || results[0].IDPost != 3 {
+		t.Fatalf("expected the comment of user 1, got %+v, err %v", results, err)
+	}
+
+	results, err = rep.Search(context.Background(), Query{Text: "golang", From: day.AddDate(0, 0, 1), To: day.AddDate(0, 0, 1), Limit: 10})
+	if err != nil || len(results) != 1 || results[0].ID != 2 {
+		t.Fatalf("expected the post of the second day, got %+v, err %v", results, err)
+	}
+
+	_, err = db.Exec("UPDATE Posts SET Content = 'only rust now', Title = 'Rust' WHERE ID = 1")
+	if err != nil {
+		t.Fatalf("the update of post is failed %v", err)
+	}
+	_, err = db.Exec("DELETE FROM Comment WHERE ID = 1")
+	if err != nil {
+		t.Fatalf("the delete of comment is failed %v", err)
+	}
+	results, err = rep.Search(context.Background(), Query{Text: `gol* "unbalanced`, Limit: 10})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	results, err = rep.Search(context.Background(), Query{Text: "gol*", Limit: 10})
+	if err != nil || len(results) != 1 || results[0].ID != 2 {
+		t.Fatalf("expected the index to follow updates and deletes, got %+v, err %v", results, err)
+	}
+}
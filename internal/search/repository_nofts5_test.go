@@ -0,0 +1,31 @@
+//go:build !sqlite_fts5 && !fts5
+
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"socialBuddy/internal/dialect"
+	"testing"
+)
+
+func TestAvailableWithoutFTS5(t *testing.T) {
+	db, err := dialect.Open(dialect.NameSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("the creation of database is failed %v", err)
+	}
+	defer db.Close()
+	available, err := Available(context.Background(), db)
+	if err != nil || available {
+		t.Fatalf("expected the search to be unavailable without the index, got %v, err %v", available, err)
+	}
+	// a database migrated by a binary built with FTS5 has the index, a plain table stands for it here
+	_, err = db.Exec("CREATE TABLE PostSearch (Title TEXT, Content TEXT)")
+	if err != nil {
+		t.Fatalf("the creation of table is failed %v", err)
+	}
+	_, err = Available(context.Background(), db)
+	if err == nil {
+		t.Fatalf("expected an error for a database with the index and a binary without FTS5")
+	}
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"reflect"
+	"socialBuddy/internal/dialect"
+	"testing"
+	"time"
+)
+
+type argSearch struct {
+	name     string
+	query    Query
+	output   []Result
+	hasError error
+}
+
+func TestSearch(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	timeNow := time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local)
+	columns := []string{"Kind", "ID", "IDPost", "IDUser", "DatePost", "Title", "Snippet", "Rank"}
+
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectQuery("FROM PostSearch .* UNION ALL .* FROM CommentSearch .* ORDER BY Rank LIMIT \\?").
+		WithArgs(snippetOpen, snippetClose, `"golang"`, 0, 0, "0000-01-01", "9999-12-31",
+			snippetOpen, snippetClose, `"golang"`, 0, 0, "0000-01-01", "9999-12-31", 10).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("post", 1, 1, 2, timeNow, "title1", "<mark>golang</mark> tips", -2.5).
+			AddRow("comment", 4, 1, 3, timeNow, "", "I like <mark>golang</mark>", -1.0))
+	mock.ExpectQuery("FROM CommentSearch .* ORDER BY Rank LIMIT \\?").
+		WithArgs(snippetOpen, snippetClose, `"golang"*`, 3, 3, "2023-11-01", "2023-11-30", 5).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	test := []argSearch{
+		{
+			name:  "Search() posts and comments",
+			query: Query{Text: "golang", Limit: 10},
+			output: []Result{
+				{Kind: KindPost, ID: 1, IDPost: 1, IDUser: 2, Date: timeNow, Title: "title1", Snippet: "<mark>golang</mark> tips", Score: 2.5},
+				{Kind: KindComment, ID: 4, IDPost: 1, IDUser: 3, Date: timeNow, Snippet: "I like <mark>golang</mark>", Score: 1.0},
+			},
+			hasError: nil,
+		},
+		{
+			name: "Search() comments of an author in a date range",
+			query: Query{Text: "golang*", Kind: KindComment, IDUser: 3, Limit: 5,
+				From: time.Date(2023, 11, 1, 0, 0, 0, 0, time.Local), To: time.Date(2023, 11, 30, 0, 0, 0, 0, time.Local)},
+			output:   nil,
+			hasError: nil,
+		},
+		{
+			name:     "Search() is failed",
+			query:    Query{Text: "golang", Kind: KindPost, Limit: 10},
+			output:   nil,
+			hasError: errors.New("the search is failed"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := rep.Search(context.Background(), tt.query)
+			if !reflect.DeepEqual(results, tt.output) {
+				t.Fatalf("expected %+v, got %+v", tt.output, results)
+			}
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
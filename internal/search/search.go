@@ -0,0 +1,50 @@
+package search
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	KindPost    = "post"
+	KindComment = "comment"
+)
+
+type Query struct {
+	Text   string
+	Kind   string
+	IDUser int
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+type Result struct {
+	Kind    string    `json:"kind"`
+	ID      int       `json:"id"`
+	IDPost  int       `json:"id_post"`
+	IDUser  int       `json:"id_user"`
+	Date    time.Time `json:"date"`
+	Title   string    `json:"title,omitempty"`
+	Snippet string    `json:"snippet"`
+	Score   float64   `json:"score"`
+}
+
+// matchExpression quotes every term of the user input so it can't break the FTS5 query syntax,
+// a trailing * is kept as a prefix search.
+func matchExpression(text string) string {
+	var terms []string
+	for _, field := range strings.Fields(text) {
+		prefix := strings.HasSuffix(field, "*")
+		field = strings.TrimRight(field, "*")
+		if field == "" {
+			continue
+		}
+		term := `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,28 @@
+package search
+
+import (
+	"testing"
+)
+
+type argMatch struct {
+	name   string
+	text   string
+	output string
+}
+
+func TestMatchExpression(t *testing.T) {
+	test := []argMatch{
+		{name: "matchExpression() with words", text: "golang  tips", output: `"golang" "tips"`},
+		{name: "matchExpression() with a prefix", text: "gol*", output: `"gol"*`},
+		{name: "matchExpression() with FTS5 syntax", text: `title:go OR "x`, output: `"title:go" "OR" """x"`},
+		{name: "matchExpression() without words", text: " * ", output: ""},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			match := matchExpression(tt.text)
+			if match != tt.output {
+				t.Fatalf("expected %s, got %s", tt.output, match)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package search
+
+import (
+	"encoding/json"
+	"net/http"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/pagination"
+	"strconv"
+	"time"
+)
+
+type Server struct {
+	searchService Service
+}
+
+type response struct {
+	Data []Result `json:"data"`
+}
+
+// Search answers the best ranked matches only, up to the limit. The results are ordered by rank across
+// posts and comments, which an ID cursor cannot resume, so a cursor is refused instead of being ignored.
+func (s *Server) Search(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	if values.Has("cursor") {
+		apperror.Write(w, apperror.Validation("the search is not paged, narrow the query or raise the limit instead of passing a cursor"))
+		return
+	}
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	query := Query{Text: values.Get("q"), Kind: values.Get("kind"), Limit: page.Limit}
+	if author := values.Get("author"); author != "" {
+		query.IDUser, err = strconv.Atoi(author)
+		if err != nil {
+			apperror.Write(w, apperror.Validation(err.Error()))
+			return
+		}
+	}
+	if from := values.Get("from"); from != "" {
+		query.From, err = time.Parse(dateFormat, from)
+		if err != nil {
+			apperror.Write(w, apperror.Validation(err.Error()))
+			return
+		}
+	}
+	if to := values.Get("to"); to != "" {
+		query.To, err = time.Parse(dateFormat, to)
+		if err != nil {
+			apperror.Write(w, apperror.Validation(err.Error()))
+			return
+		}
+	}
+	results, err := s.searchService.Search(r.Context(), query)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	if results == nil {
+		results = []Result{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response{results})
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func NewServer(searchService Service) *Server {
+	return &Server{searchService}
+}
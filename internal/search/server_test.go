@@ -0,0 +1,51 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type stubService struct {
+	queries []Query
+}
+
+func (s *stubService) Search(ctx context.Context, query Query) ([]Result, error) {
+	s.queries = append(s.queries, query)
+	return nil, nil
+}
+
+type argServer struct {
+	name   string
+	target string
+	status int
+	limit  int
+}
+
+func TestSearchServer(t *testing.T) {
+	test := []argServer{
+		{name: "Search() with a limit", target: "/v1/search?q=golang&limit=5", status: http.StatusOK, limit: 5},
+		{name: "Search() with a cursor", target: "/v1/search?q=golang&cursor=aWQ6Mw", status: http.StatusBadRequest},
+		{name: "Search() with an empty cursor", target: "/v1/search?q=golang&cursor=", status: http.StatusBadRequest},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubService{}
+			rec := httptest.NewRecorder()
+			NewServer(service).Search(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status != http.StatusOK {
+				if len(service.queries) != 0 {
+					t.Fatalf("expected the search not to run, got %+v", service.queries)
+				}
+				return
+			}
+			if len(service.queries) != 1 || service.queries[0].Limit != tt.limit {
+				t.Fatalf("expected one search with the limit %d, got %+v", tt.limit, service.queries)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package search
+
+import (
+	"context"
+	"socialBuddy/internal/apperror"
+)
+
+type service struct {
+	SearchRepository Repository
+}
+
+type Service interface {
+	Search(ctx context.Context, query Query) ([]Result, error)
+}
+
+func (s *service) Search(ctx context.Context, query Query) ([]Result, error) {
+	if matchExpression(query.Text) == "" {
+		return nil, apperror.Validation("the search text is not valid")
+	}
+	if query.Kind != "" && query.Kind != KindPost && query.Kind != KindComment {
+		return nil, apperror.Validation("the kind must be post or comment")
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		return nil, apperror.Validation("the date range is not valid")
+	}
+	results, err := s.SearchRepository.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func NewService(searchRepository Repository) Service {
+	return &service{searchRepository}
+}
@@ -0,0 +1,13 @@
+package search
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestSearchService(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Search Service Suite")
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"context"
+	"errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"socialBuddy/internal/apperror"
+	"time"
+)
+
+type mockRepository struct {
+	mock.Mock
+}
+
+func (m *mockRepository) Search(ctx context.Context, query Query) ([]Result, error) {
+	args := m.Called(query)
+	return args.Get(0).([]Result), args.Error(1)
+}
+
+var _ = Describe("The Service Test", func() {
+	var (
+		mockSearchRepository *mockRepository
+	)
+	BeforeEach(func() {
+		mockSearchRepository = new(mockRepository)
+	})
+	It("should Search successfully", func() {
+		query := Query{Text: "golang", Limit: 10}
+		mockSearchRepository.On("Search", query).Return([]Result{
+			{Kind: KindPost, ID: 1, IDPost: 1, IDUser: 2, Snippet: "<mark>golang</mark>", Score: 2.5},
+		}, nil)
+		newService := NewService(mockSearchRepository)
+		results, err := newService.Search(context.Background(), query)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(results).Should(HaveLen(1))
+		Expect(results[0].Kind).Should(Equal(KindPost))
+	})
+	It("should Search unsuccessfully", func() {
+		query := Query{Text: "golang", Limit: 10}
+		mockSearchRepository.On("Search", query).Return([]Result{}, errors.New("error while Search()"))
+		newService := NewService(mockSearchRepository)
+		results, err := newService.Search(context.Background(), query)
+		Expect(err).Should(HaveOccurred())
+		Expect(results).Should(BeNil())
+	})
+	It("should not Search without text", func() {
+		newService := NewService(mockSearchRepository)
+		_, err := newService.Search(context.Background(), Query{Text: "  ", Limit: 10})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+		mockSearchRepository.AssertNotCalled(GinkgoT(), "Search", mock.Anything)
+	})
+	It("should not Search with an unknown kind", func() {
+		newService := NewService(mockSearchRepository)
+		_, err := newService.Search(context.Background(), Query{Text: "golang", Kind: "user", Limit: 10})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+	})
+	It("should not Search with an inverted date range", func() {
+		newService := NewService(mockSearchRepository)
+		_, err := newService.Search(context.Background(), Query{Text: "golang", Limit: 10,
+			From: time.Date(2023, 11, 13, 0, 0, 0, 0, time.Local), To: time.Date(2023, 11, 1, 0, 0, 0, 0, time.Local)})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+	})
+})
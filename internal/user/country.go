@@ -0,0 +1,196 @@
+package user
+
+import (
+	"fmt"
+	"regexp"
+	"socialBuddy/internal/apperror"
+	"strings"
+	"sync"
+)
+
+// Country holds the rules the address, phone and document of a user must follow.
+type Country struct {
+	Code string
+	// Name is the spelling stored on the address, Aliases are the other spellings accepted.
+	Name    string
+	Aliases []string
+	// CallingCode is the E.164 country code without the +.
+	CallingCode string
+	// Phone matches the national significant number, the digits after the calling code.
+	Phone      *regexp.Regexp
+	PostalCode *regexp.Regexp
+	// HouseNumber matches the number of the address, a country without one only requires it to be filled.
+	HouseNumber *regexp.Regexp
+	// Documents are the accepted national IDs, a country without any does not require one.
+	Documents []DocumentType
+	// LookupAddress fills the address from the postal code instead of trusting the user.
+	LookupAddress bool
+}
+
+type DocumentType struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Check verifies the check digits once the pattern matched, nil when the document has none.
+	Check func(documentNumber string) bool
+}
+
+var countries = struct {
+	sync.RWMutex
+	byName map[string]Country
+}{byName: map[string]Country{}}
+
+// RegisterCountry adds or replaces a country, it can be found by its code, name or aliases.
+func RegisterCountry(country Country) {
+	countries.Lock()
+	defer countries.Unlock()
+	for _, name := range append([]string{country.Code, country.Name}, country.Aliases...) {
+		countries.byName[strings.ToLower(name)] = country
+	}
+}
+
+func LookupCountry(name string) (Country, bool) {
+	countries.RLock()
+	defer countries.RUnlock()
+	country, ok := countries.byName[strings.ToLower(strings.TrimSpace(name))]
+	return country, ok
+}
+
+func init() {
+	RegisterCountry(Country{
+		Code:        "BR",
+		Name:        "Brasil",
+		Aliases:     []string{"Brazil"},
+		CallingCode: "55",
+		Phone:       regexp.MustCompile(`^\d{2}9\d{8}$`),
+		PostalCode:  regexp.MustCompile(`^\d{5}-\d{3}$`),
+		HouseNumber: regexp.MustCompile(`^[1-9]\d{0,3}$`),
+		Documents: []DocumentType{
+			{Name: "CPF", Pattern: regexp.MustCompile(`^\d{3}\.\d{3}\.\d{3}-\d{2}$`), Check: cpfCheck},
+			{Name: "CNPJ", Pattern: regexp.MustCompile(`^\d{2}\.\d{3}\.\d{3}/\d{4}-\d{2}$`), Check: cnpjCheck},
+		},
+		LookupAddress: true,
+	})
+	RegisterCountry(Country{
+		Code:        "PT",
+		Name:        "Portugal",
+		CallingCode: "351",
+		Phone:       regexp.MustCompile(`^[29]\d{8}$`),
+		PostalCode:  regexp.MustCompile(`^\d{4}-\d{3}$`),
+		HouseNumber: regexp.MustCompile(`^[1-9]\d{0,3}[A-Z]?$`),
+		Documents:   []DocumentType{{Name: "NIF", Pattern: regexp.MustCompile(`^\d{9}$`)}},
+	})
+	RegisterCountry(Country{
+		Code:        "US",
+		Name:        "United States",
+		Aliases:     []string{"USA", "United States of America"},
+		CallingCode: "1",
+		Phone:       regexp.MustCompile(`^[2-9]\d{2}[2-9]\d{6}$`),
+		PostalCode:  regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+		HouseNumber: regexp.MustCompile(`^[1-9]\d{0,5}[A-Z]?$`),
+	})
+	RegisterCountry(Country{
+		Code:        "GB",
+		Name:        "United Kingdom",
+		Aliases:     []string{"UK", "Great Britain"},
+		CallingCode: "44",
+		Phone:       regexp.MustCompile(`^[1-9]\d{8,9}$`),
+		PostalCode:  regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}$`),
+		HouseNumber: regexp.MustCompile(`^[1-9]\d{0,3}[A-Z]?$`),
+	})
+	RegisterCountry(Country{
+		Code:        "DE",
+		Name:        "Deutschland",
+		Aliases:     []string{"Germany"},
+		CallingCode: "49",
+		Phone:       regexp.MustCompile(`^[1-9]\d{5,12}$`),
+		PostalCode:  regexp.MustCompile(`^\d{5}$`),
+		HouseNumber: regexp.MustCompile(`^[1-9]\d{0,3}[a-z]?$`),
+		Documents:   []DocumentType{{Name: "Personalausweis", Pattern: regexp.MustCompile(`^[CFGHJKLMNPRTVWXYZ0-9]{9}$`)}},
+	})
+}
+
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+// ParsePhone splits an E.164 number, written with or without separators, into the calling code
+// of the country and its national significant number.
+func (c Country) ParsePhone(phone string) (string, error) {
+	digits := phoneSeparators.Replace(phone)
+	if !strings.HasPrefix(digits, "+") || len(digits) < 8 || len(digits) > 16 {
+		return "", apperror.Validation("the phone number is not valid")
+	}
+	digits = digits[1:]
+	for _, char := range digits {
+		if char < '0' || char > '9' {
+			return "", apperror.Validation("the phone number is not valid")
+		}
+	}
+	national, found := strings.CutPrefix(digits, c.CallingCode)
+	if !found || !c.Phone.MatchString(national) {
+		return "", apperror.Validation("the phone number is not valid")
+	}
+	return national, nil
+}
+
+func (c Country) documentNames() string {
+	names := make([]string, len(c.Documents))
+	for i, document := range c.Documents {
+		names[i] = document.Name
+	}
+	return strings.Join(names, " or ")
+}
+
+func countryValidation(name string) (Country, error) {
+	country, ok := LookupCountry(name)
+	if !ok {
+		return Country{}, apperror.Validation("the country is not valid")
+	}
+	return country, nil
+}
+
+func documentValidation(country Country, documentNumber string) error {
+	if len(country.Documents) == 0 {
+		if len(documentNumber) > 30 {
+			return apperror.Validation("the document number is not valid")
+		}
+		return nil
+	}
+	for _, document := range country.Documents {
+		if document.Pattern.MatchString(documentNumber) {
+			if document.Check != nil && !document.Check(documentNumber) {
+				return apperror.Validation(fmt.Sprintf("%s is not valid", document.Name))
+			}
+			return nil
+		}
+	}
+	return apperror.Validation(fmt.Sprintf("%s is not valid", country.documentNames()))
+}
+
+func phoneValidation(country Country, phone string) error {
+	_, err := country.ParsePhone(phone)
+	return err
+}
+
+func zipCodeValidation(country Country, zipCode string) error {
+	if !country.PostalCode.MatchString(zipCode) {
+		return apperror.Validation("the zip code is not valid")
+	}
+	return nil
+}
+
+func numberValidation(country Country, number string) error {
+	if country.HouseNumber == nil {
+		return requiredValidation(number, "the number is not valid")
+	}
+	if !country.HouseNumber.MatchString(number) {
+		return apperror.Validation("the number is not valid")
+	}
+	return nil
+}
+
+// requiredValidation checks the address fields the user types in when the country has no postal code lookup.
+func requiredValidation(value string, message string) error {
+	if strings.TrimSpace(value) == "" {
+		return apperror.Validation(message)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package user
+
+// cpfCheck verifies the two check digits of a CPF, a number made of a single repeated digit is not valid
+// even though its check digits match.
+func cpfCheck(cpf string) bool {
+	digits := documentDigits(cpf)
+	if len(digits) != 11 || repeated(digits) {
+		return false
+	}
+	return checkDigit(digits[:9], []int{10, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[9] &&
+		checkDigit(digits[:10], []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[10]
+}
+
+// cnpjCheck verifies the two check digits of a CNPJ, the document of business accounts.
+func cnpjCheck(cnpj string) bool {
+	digits := documentDigits(cnpj)
+	if len(digits) != 14 || repeated(digits) {
+		return false
+	}
+	return checkDigit(digits[:12], []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[12] &&
+		checkDigit(digits[:13], []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[13]
+}
+
+// checkDigit is the modulo 11 digit shared by CPF and CNPJ.
+func checkDigit(digits []int, weights []int) int {
+	sum := 0
+	for i, digit := range digits {
+		sum += digit * weights[i]
+	}
+	rest := sum % 11
+	if rest < 2 {
+		return 0
+	}
+	return 11 - rest
+}
+
+func documentDigits(document string) []int {
+	var digits []int
+	for _, char := range document {
+		if char >= '0' && char <= '9' {
+			digits = append(digits, int(char-'0'))
+		}
+	}
+	return digits
+}
+
+func repeated(digits []int) bool {
+	for _, digit := range digits[1:] {
+		if digit != digits[0] {
+			return false
+		}
+	}
+	return true
+}
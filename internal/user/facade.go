@@ -1,10 +1,12 @@
 package user
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"socialBuddy/internal/apperror"
 )
 
 type facade struct {
@@ -12,16 +14,24 @@ type facade struct {
 	client     *http.Client
 }
 type Facade interface {
-	FindCep(cepUser string, number string, complement string) (*Address, error)
+	FindCep(ctx context.Context, cepUser string, number string, complement string) (*Address, error)
 }
 
-func (f *facade) FindCep(cepUser string, number string, complement string) (*Address, error) {
+func (f *facade) FindCep(ctx context.Context, cepUser string, number string, complement string) (*Address, error) {
 	url := fmt.Sprintf("%s/ws/%s/json", f.findCepUrl, cepUser)
-	resUrl, err := f.client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	resUrl, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resUrl.Body.Close()
 
+	if resUrl.StatusCode == http.StatusBadRequest {
+		return nil, apperror.Validation("the zip code is not valid")
+	}
 	if resUrl.StatusCode != 200 {
 		return nil, errors.New("finding this cep is failed")
 	}
@@ -32,12 +42,7 @@ func (f *facade) FindCep(cepUser string, number string, complement string) (*Add
 	}
 
 	if result["erro"] == "true" {
-		return nil, errors.New("finding this cep is failed")
-	}
-
-	err = resUrl.Body.Close()
-	if err != nil {
-		return nil, err
+		return nil, apperror.NotFound("the zip code was not found")
 	}
 
 	return &Address{
@@ -58,3 +63,34 @@ func NewFacade(findCepUrl string, client *http.Client) Facade {
 		client:     client,
 	}
 }
+
+// CepCheck looks a well-known CEP up through facade, it tells the readiness probe whether the configured
+// providers answer. As in NewObservedFacade, an unknown or invalid zip code is still an answer.
+func CepCheck(facade Facade) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := facade.FindCep(ctx, "01001000", "", "")
+		var appErr *apperror.Error
+		if err != nil && !errors.As(err, &appErr) {
+			return fmt.Errorf("the CEP providers do not answer: %w", err)
+		}
+		return nil
+	}
+}
+
+type observedFacade struct {
+	facade  Facade
+	observe func(success bool)
+}
+
+// NewObservedFacade reports every lookup to observe. A lookup succeeds when the provider answers,
+// even if the zip code is invalid or unknown.
+func NewObservedFacade(facade Facade, observe func(success bool)) Facade {
+	return &observedFacade{facade, observe}
+}
+
+func (f *observedFacade) FindCep(ctx context.Context, cepUser string, number string, complement string) (*Address, error) {
+	address, err := f.facade.FindCep(ctx, cepUser, number, complement)
+	var appErr *apperror.Error
+	f.observe(err == nil || errors.As(err, &appErr))
+	return address, err
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -58,7 +59,7 @@ func TestFindCepSuccessfully(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			address, err := api.FindCep(tt.userCEP, tt.number, tt.complement)
+			address, err := api.FindCep(context.Background(), tt.userCEP, tt.number, tt.complement)
 			log.Printf("user: %v, err: %v", address, err)
 			if !reflect.DeepEqual(address, tt.expectedAddress) {
 				t.Fatalf("expected %+v, got %+v", tt.expectedAddress, address)
@@ -106,7 +107,7 @@ func TestFindCepWithStatusBadRequest(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			address, err := api.FindCep(tt.userCEP, tt.number, tt.complement)
+			address, err := api.FindCep(context.Background(), tt.userCEP, tt.number, tt.complement)
 			log.Printf("user: %v, err: %v", address, err)
 			if !reflect.DeepEqual(address, tt.expectedAddress) {
 				t.Fatalf("expected %+v, got %+v", tt.expectedAddress, address)
@@ -138,7 +139,7 @@ func TestFindCepError(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			address, err := api.FindCep(tt.userCEP, tt.number, tt.complement)
+			address, err := api.FindCep(context.Background(), tt.userCEP, tt.number, tt.complement)
 			log.Printf("user: %v, err: %v", address, err)
 			if !reflect.DeepEqual(address, tt.expectedAddress) {
 				t.Fatalf("expected %+v, got %+v", tt.expectedAddress, address)
@@ -146,3 +147,62 @@ func TestFindCepError(t *testing.T) {
 		})
 	}
 }
+
+func TestObservedFacade(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ws/01001000/json":
+			_, _ = w.Write([]byte(`{"uf": "SP"}`))
+		case "/ws/99999999/json":
+			_, _ = w.Write([]byte(`{"erro": "true"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer mockServer.Close()
+	test := []struct {
+		name    string
+		userCEP string
+		success bool
+	}{
+		{name: "FindCep() is observed as a success", userCEP: "01001000", success: true},
+		{name: "FindCep() of an unknown CEP is observed as a success", userCEP: "99999999", success: true},
+		{name: "FindCep() is observed as a failure", userCEP: "12246260", success: false},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			var observed []bool
+			api := NewObservedFacade(NewFacade(mockServer.URL, mockServer.Client()), func(success bool) {
+				observed = append(observed, success)
+			})
+			_, _ = api.FindCep(context.Background(), tt.userCEP, "10", "")
+			if !reflect.DeepEqual(observed, []bool{tt.success}) {
+				t.Fatalf("expected %v, got %v", []bool{tt.success}, observed)
+			}
+		})
+	}
+}
+
+func TestCepCheck(t *testing.T) {
+	status, body := http.StatusOK, `{"uf": "SP"}`
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer mockServer.Close()
+	check := CepCheck(NewFacade(mockServer.URL, mockServer.Client()))
+	err := check(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	body = `{"erro": "true"}`
+	err = check(context.Background())
+	if err != nil {
+		t.Fatalf("expected an unknown CEP to be an answer, got %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	err = check(context.Background())
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
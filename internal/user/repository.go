@@ -1,255 +1,443 @@
 package user
 
 import (
+	"context"
 	"database/sql"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/pagination"
+	"strings"
+	"time"
 )
 
 type Repository interface {
-	CreateUser(user User) (*User, error)
-	GetUsers() ([]User, error)
-	GetUserByID(idUser int) (*User, error)
-	GetUserByEmail(emailUser string) (*User, error)
-	UpdateUser(user User, idUser int) (*User, error)
-	DeleteUser(idUser int) error
-	FollowUser(idFollower int, idFollowing int) error
-	DeleteConnection(idFollower int, idFollowing int) error
-	GetFollowingByUserID(idUser int) ([]User, error)
-	GetUserFollowers(idUser int) ([]User, error)
+	CreateUser(ctx context.Context, user User, passwordHash string) (*User, error)
+	GetUsers(ctx context.Context, page pagination.Page) ([]User, error)
+	GetUserByID(ctx context.Context, idUser int) (*User, error)
+	GetUserByEmail(ctx context.Context, emailUser string) (*User, error)
+	GetCredentials(ctx context.Context, emailUser string) (*Credentials, error)
+	GetRole(ctx context.Context, idUser int) (string, error)
+	UpdateUser(ctx context.Context, user User, idUser int) (*User, error)
+	DeleteUser(ctx context.Context, idUser int, deletedAt time.Time) error
+	GetDeletedAt(ctx context.Context, idUser int) (time.Time, error)
+	RestoreUser(ctx context.Context, idUser int) error
+	HasContent(ctx context.Context, idUser int) (bool, error)
+	PurgeUsers(ctx context.Context, before time.Time, restrict bool) (int64, error)
+	FollowUser(ctx context.Context, idFollower int, idFollowing int) error
+	DeleteConnection(ctx context.Context, idFollower int, idFollowing int) error
+	IsFollowing(ctx context.Context, idFollower int, idFollowing int) (bool, error)
+	GetFollowingByUserID(ctx context.Context, idUser int, page pagination.Page) ([]User, error)
+	GetUserFollowers(ctx context.Context, idUser int, page pagination.Page) ([]User, error)
+	GetMutuals(ctx context.Context, idUser int, page pagination.Page) ([]User, error)
+	CountConnections(ctx context.Context, idUser int) (followers int, following int, err error)
+	GetSuggestions(ctx context.Context, idUser int, limit int) ([]Suggestion, error)
 }
 type repository struct {
-	db *sql.DB
+	db *dialect.DB
 }
 
-func (r *repository) CreateUser(user User) (*User, error) {
-	res, err := r.db.Exec(`INSERT INTO Users ("Name", "Age", "DocumentNumber", "Email", 
-                   "Phone", "ZipCode", "Country", "State", "City", "Neighborhood", "Street", "Number", "Complement")
-					VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, user.Name, user.Age, user.DocumentNumber,
-		user.Email, user.Phone, user.Address.ZipCode, user.Address.Country, user.Address.State,
-		user.Address.City, user.Address.Neighborhood, user.Address.Street, user.Address.Number, user.Address.Complement)
-	if err != nil {
-		return nil, err
+const selectUser = `SELECT Users.ID, Users.Name, Users.Age, Users.DocumentNumber, Users.Email, Users.Phone,
+	Users.ZipCode, Users.Country, Users.State, Users.City, Users.Neighborhood, Users.Street, Users.Number, Users.Complement
+	FROM Users`
+
+// userThread selects the comments of a user, the comments on their posts and every reply below them.
+const userThread = `WITH RECURSIVE thread (ID) AS (
+		SELECT ID FROM Comment WHERE IDUser = ? OR IDPost IN (SELECT ID FROM Posts WHERE IDUser = ?)
+		UNION SELECT Comment.ID FROM Comment INNER JOIN thread ON Comment.IDParent = thread.ID)
+	SELECT ID FROM thread`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row scanner) (User, error) {
+	var user User
+	err := row.Scan(
+		&user.ID,
+		&user.Name,
+		&user.Age,
+		&user.DocumentNumber,
+		&user.Email,
+		&user.Phone,
+		&user.Address.ZipCode,
+		&user.Address.Country,
+		&user.Address.State,
+		&user.Address.City,
+		&user.Address.Neighborhood,
+		&user.Address.Street,
+		&user.Address.Number,
+		&user.Address.Complement,
+	)
+	return user, err
+}
+
+func scanUsers(rows *sql.Rows) ([]User, error) {
+	defer rows.Close()
+	var listUser []User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		listUser = append(listUser, user)
 	}
-	idUser, err := res.LastInsertId()
-	newUser, err := r.GetUserByID(int(idUser))
+	return listUser, rows.Err()
+}
+
+func (r *repository) CreateUser(ctx context.Context, user User, passwordHash string) (*User, error) {
+	ctx = dialect.Operation(ctx, "user", "CreateUser")
+	var newUser *User
+	err := r.db.Transact(ctx, func(ctx context.Context) error {
+		idUser, err := r.db.InsertContext(ctx, `INSERT INTO Users (Name, Age, DocumentNumber, Email,
+                   Phone, ZipCode, Country, State, City, Neighborhood, Street, Number, Complement, PasswordHash)
+					VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, user.Name, user.Age, user.DocumentNumber,
+			user.Email, user.Phone, user.Address.ZipCode, user.Address.Country, user.Address.State,
+			user.Address.City, user.Address.Neighborhood, user.Address.Street, user.Address.Number, user.Address.Complement, passwordHash)
+		if err != nil {
+			return r.conflict(err)
+		}
+		newUser, err = r.GetUserByID(ctx, int(idUser))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return newUser, nil
 }
 
-func (r *repository) GetUsers() ([]User, error) {
-	users, err := r.db.Query("SELECT * FROM Users")
+func (r *repository) GetUsers(ctx context.Context, page pagination.Page) ([]User, error) {
+	ctx = dialect.Operation(ctx, "user", "GetUsers")
+	rows, err := r.db.QueryContext(ctx, selectUser+" WHERE Users.ID > ? AND Users.DeletedAt IS NULL ORDER BY Users.ID LIMIT ?", page.After, page.Fetch())
 	if err != nil {
 		return nil, err
 	}
-	var listUser []User
-	for users.Next() {
-		var user User
-		err := users.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Age,
-			&user.DocumentNumber,
-			&user.Email,
-			&user.Phone,
-			&user.Address.ZipCode,
-			&user.Address.Country,
-			&user.Address.State,
-			&user.Address.City,
-			&user.Address.Neighborhood,
-			&user.Address.Street,
-			&user.Address.Number,
-			&user.Address.Complement,
-		)
-		if err != nil {
-			return nil, err
-		}
-		listUser = append(listUser, user)
-	}
-	return listUser, nil
+	return scanUsers(rows)
 }
 
-func (r *repository) GetUserByID(idUser int) (*User, error) {
-	rows, err := r.db.Query("SELECT * FROM Users WHERE ID = ?", idUser)
+func (r *repository) GetUserByID(ctx context.Context, idUser int) (*User, error) {
+	ctx = dialect.Operation(ctx, "user", "GetUserByID")
+	user, err := scanUser(r.db.QueryRowContext(ctx, selectUser+" WHERE Users.ID = ? AND Users.DeletedAt IS NULL", idUser))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("the user is not in database")
+	}
 	if err != nil {
 		return nil, err
 	}
-	var user User
-	if rows.Next() {
-		err := rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Age,
-			&user.DocumentNumber,
-			&user.Email,
-			&user.Phone,
-			&user.Address.ZipCode,
-			&user.Address.Country,
-			&user.Address.State,
-			&user.Address.City,
-			&user.Address.Neighborhood,
-			&user.Address.Street,
-			&user.Address.Number,
-			&user.Address.Complement,
-		)
-		if err != nil {
-			return nil, err
-		}
-		return &user, nil
+	return &user, nil
+}
+
+func (r *repository) GetUserByEmail(ctx context.Context, emailUser string) (*User, error) {
+	ctx = dialect.Operation(ctx, "user", "GetUserByEmail")
+	user, err := scanUser(r.db.QueryRowContext(ctx, selectUser+" WHERE LOWER(Users.Email) = LOWER(?) AND Users.DeletedAt IS NULL", emailUser))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("the user is not in database")
 	}
-	//log.Println("output:", &user)
-	return nil, nil
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
 
-func (r *repository) GetUserByEmail(emailUser string) (*User, error) {
-	rows, err := r.db.Query("SELECT * FROM Users WHERE Email = ?", emailUser)
+// GetCredentials also finds the deleted accounts, so their owner can log in and restore them, the login
+// only gives them an access token that RestoreUser accepts.
+func (r *repository) GetCredentials(ctx context.Context, emailUser string) (*Credentials, error) {
+	ctx = dialect.Operation(ctx, "user", "GetCredentials")
+	var credentials Credentials
+	var passwordHash sql.NullString
+	err := r.db.QueryRowContext(ctx, "SELECT ID, PasswordHash, Role FROM Users WHERE LOWER(Email) = LOWER(?)", emailUser).Scan(&credentials.ID, &passwordHash, &credentials.Role)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	var user User
-	if rows.Next() {
-		err := rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Age,
-			&user.DocumentNumber,
-			&user.Email,
-			&user.Phone,
-			&user.Address.ZipCode,
-			&user.Address.Country,
-			&user.Address.State,
-			&user.Address.City,
-			&user.Address.Neighborhood,
-			&user.Address.Street,
-			&user.Address.Number,
-			&user.Address.Complement,
-		)
-		if err != nil {
-			return nil, err
-		}
-		return &user, nil
+	credentials.PasswordHash = passwordHash.String
+	return &credentials, nil
+}
+
+// GetRole is empty for the deleted users, their tokens are no longer refreshed.
+func (r *repository) GetRole(ctx context.Context, idUser int) (string, error) {
+	ctx = dialect.Operation(ctx, "user", "GetRole")
+	var role string
+	err := r.db.QueryRowContext(ctx, "SELECT Role FROM Users WHERE ID = ? AND DeletedAt IS NULL", idUser).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
 	}
-	return nil, nil
+	return role, nil
 }
 
-func (r *repository) UpdateUser(user User, idUser int) (*User, error) {
-	_, err := r.db.Exec(`UPDATE Users SET Name = ?, Age = ?, DocumentNumber = ?, Email = ?, 
+func (r *repository) UpdateUser(ctx context.Context, user User, idUser int) (*User, error) {
+	ctx = dialect.Operation(ctx, "user", "UpdateUser")
+	var editedUser *User
+	err := r.db.Transact(ctx, func(ctx context.Context) error {
+		_, err := r.db.ExecContext(ctx, `UPDATE Users SET Name = ?, Age = ?, DocumentNumber = ?, Email = ?, 
             Phone = ?, ZipCode = ?, Country = ?, State = ?, City = ?, Neighborhood = ?, Street = ?, Number = ?, Complement = ?
 			WHERE ID = ?`, user.Name, user.Age, user.DocumentNumber,
-		user.Email, user.Phone, user.Address.ZipCode, user.Address.Country, user.Address.State,
-		user.Address.City, user.Address.Neighborhood, user.Address.Street, user.Address.Number, user.Address.Complement, idUser)
+			user.Email, user.Phone, user.Address.ZipCode, user.Address.Country, user.Address.State,
+			user.Address.City, user.Address.Neighborhood, user.Address.Street, user.Address.Number, user.Address.Complement, idUser)
+		if err != nil {
+			return r.conflict(err)
+		}
+		editedUser, err = r.GetUserByID(ctx, idUser)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return editedUser, nil
+}
 
-	editedUser, err := r.GetUserByID(idUser)
+// DeleteUser hides the user along with their posts, their comments and the comments on their posts, every
+// row gets the same deletedAt so RestoreUser brings back exactly them. The refresh tokens of the user are revoked.
+func (r *repository) DeleteUser(ctx context.Context, idUser int, deletedAt time.Time) error {
+	ctx = dialect.Operation(ctx, "user", "DeleteUser")
+	return r.db.Transact(ctx, func(ctx context.Context) error {
+		res, err := r.db.ExecContext(ctx, "UPDATE Users SET DeletedAt = ? WHERE ID = ? AND DeletedAt IS NULL", deletedAt, idUser)
+		if err != nil {
+			return err
+		}
+		deleted, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return apperror.NotFound("the user is not in database")
+		}
+		_, err = r.db.ExecContext(ctx, "UPDATE Posts SET DeletedAt = ? WHERE IDUser = ? AND DeletedAt IS NULL", deletedAt, idUser)
+		if err != nil {
+			return err
+		}
+		_, err = r.db.ExecContext(ctx, "UPDATE Comment SET DeletedAt = ? WHERE DeletedAt IS NULL AND ID IN ("+userThread+")",
+			deletedAt, idUser, idUser)
+		if err != nil {
+			return err
+		}
+		_, err = r.db.ExecContext(ctx, "DELETE FROM RefreshToken WHERE IDUser = ?", idUser)
+		return err
+	})
+}
+
+func (r *repository) GetDeletedAt(ctx context.Context, idUser int) (time.Time, error) {
+	ctx = dialect.Operation(ctx, "user", "GetDeletedAt")
+	var deletedAt time.Time
+	err := r.db.QueryRowContext(ctx, "SELECT DeletedAt FROM Users WHERE ID = ? AND DeletedAt IS NOT NULL", idUser).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, apperror.NotFound("the deleted user is not in database")
+	}
 	if err != nil {
-		return nil, err
+		return time.Time{}, err
 	}
+	return deletedAt, nil
+}
 
-	return editedUser, nil
+func (r *repository) RestoreUser(ctx context.Context, idUser int) error {
+	ctx = dialect.Operation(ctx, "user", "RestoreUser")
+	return r.db.Transact(ctx, func(ctx context.Context) error {
+		_, err := r.db.ExecContext(ctx, `UPDATE Comment SET DeletedAt = NULL
+			WHERE DeletedAt = (SELECT DeletedAt FROM Users WHERE ID = ?) AND ID IN (`+userThread+")", idUser, idUser, idUser)
+		if err != nil {
+			return err
+		}
+		_, err = r.db.ExecContext(ctx, `UPDATE Posts SET DeletedAt = NULL
+			WHERE IDUser = ? AND DeletedAt = (SELECT DeletedAt FROM Users WHERE ID = ?)`, idUser, idUser)
+		if err != nil {
+			return err
+		}
+		res, err := r.db.ExecContext(ctx, "UPDATE Users SET DeletedAt = NULL WHERE ID = ? AND DeletedAt IS NOT NULL", idUser)
+		if err != nil {
+			return err
+		}
+		restored, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if restored == 0 {
+			return apperror.NotFound("the deleted user is not in database")
+		}
+		return nil
+	})
 }
 
-func (r *repository) DeleteUser(idUser int) error {
-	_, err := r.db.Exec("PRAGMA foreign_keys = ON")
+// HasContent tells whether the user still has posts or comments that are not deleted.
+func (r *repository) HasContent(ctx context.Context, idUser int) (bool, error) {
+	ctx = dialect.Operation(ctx, "user", "HasContent")
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT (SELECT COUNT(*) FROM Posts WHERE IDUser = ? AND DeletedAt IS NULL)
+		+ (SELECT COUNT(*) FROM Comment WHERE IDUser = ? AND DeletedAt IS NULL)`, idUser, idUser).Scan(&count)
 	if err != nil {
-		return err
+		return false, err
 	}
-	_, err = r.db.Exec("DELETE FROM Users WHERE ID = ?", idUser)
-	if err != nil {
+	return count > 0, nil
+}
+
+// PurgeUsers deletes for good the users deleted before the cutoff, the foreign keys take their connections,
+// tokens and whatever content is left with them. With restrict the users who still have posts or comments,
+// deleted ones included, are kept for a later purge.
+func (r *repository) PurgeUsers(ctx context.Context, before time.Time, restrict bool) (int64, error) {
+	ctx = dialect.Operation(ctx, "user", "PurgeUsers")
+	query := "DELETE FROM Users WHERE DeletedAt < ?"
+	if restrict {
+		query += ` AND NOT EXISTS (SELECT 1 FROM Posts WHERE Posts.IDUser = Users.ID)
+			AND NOT EXISTS (SELECT 1 FROM Comment WHERE Comment.IDUser = Users.ID)`
+	}
+	var purged int64
+	err := r.db.Transact(ctx, func(ctx context.Context) error {
+		res, err := r.db.ExecContext(ctx, query, before)
+		if err != nil {
+			return err
+		}
+		purged, err = res.RowsAffected()
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
-	return nil
-
+	return purged, nil
 }
 
-func (r *repository) FollowUser(idFollower int, idFollowing int) error {
-	_, err := r.db.Exec(`INSERT INTO Connection ("IdFollower", "IdFollowing") VALUES (?, ?)`, idFollower, idFollowing)
+func (r *repository) FollowUser(ctx context.Context, idFollower int, idFollowing int) error {
+	ctx = dialect.Operation(ctx, "user", "FollowUser")
+	_, err := r.db.ExecContext(ctx, `INSERT INTO Connection (IdFollower, IdFollowing) VALUES (?, ?)`, idFollower, idFollowing)
+	if _, ok := r.db.UniqueViolation(err); ok {
+		return apperror.Conflict("the id cannot follow user more than once")
+	}
 	if err != nil {
 		return err
 	}
 	return nil
 }
-func (r *repository) DeleteConnection(idFollower int, idFollowing int) error {
-	_, err := r.db.Exec("DELETE FROM Connection WHERE IdFollower = ? AND IdFollowing = ?", idFollower, idFollowing)
+func (r *repository) DeleteConnection(ctx context.Context, idFollower int, idFollowing int) error {
+	ctx = dialect.Operation(ctx, "user", "DeleteConnection")
+	_, err := r.db.ExecContext(ctx, "DELETE FROM Connection WHERE IdFollower = ? AND IdFollowing = ?", idFollower, idFollowing)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *repository) GetFollowingByUserID(idUser int) ([]User, error) {
-	row, err := r.db.Query("SELECT Users.* FROM Users INNER JOIN Connection ON Users.ID = Connection.idFollowing WHERE Connection.idFollower = ? ", idUser)
+func (r *repository) IsFollowing(ctx context.Context, idFollower int, idFollowing int) (bool, error) {
+	ctx = dialect.Operation(ctx, "user", "IsFollowing")
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM Connection WHERE IdFollower = ? AND IdFollowing = ?", idFollower, idFollowing).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *repository) GetFollowingByUserID(ctx context.Context, idUser int, page pagination.Page) ([]User, error) {
+	ctx = dialect.Operation(ctx, "user", "GetFollowingByUserID")
+	rows, err := r.db.QueryContext(ctx, selectUser+` INNER JOIN Connection ON Users.ID = Connection.IdFollowing
+		WHERE Connection.IdFollower = ? AND Users.ID > ? AND Users.DeletedAt IS NULL ORDER BY Users.ID LIMIT ?`, idUser, page.After, page.Fetch())
 	if err != nil {
 		return nil, err
 	}
-	var listUser []User
-	for row.Next() {
-		var user User
-		//var con Connection
-		err = row.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Age,
-			&user.DocumentNumber,
-			&user.Email,
-			&user.Phone,
-			&user.Address.ZipCode,
-			&user.Address.Country,
-			&user.Address.State,
-			&user.Address.City,
-			&user.Address.Neighborhood,
-			&user.Address.Street,
-			&user.Address.Number,
-			&user.Address.Complement,
-			//&con.ID,
-			//&con.IdFollower,
-			//&con.IdFollowing,
-		)
-		if err != nil {
-			return nil, err
-		}
-		listUser = append(listUser, user)
+	return scanUsers(rows)
+}
+
+func (r *repository) GetUserFollowers(ctx context.Context, idUser int, page pagination.Page) ([]User, error) {
+	ctx = dialect.Operation(ctx, "user", "GetUserFollowers")
+	rows, err := r.db.QueryContext(ctx, selectUser+` INNER JOIN Connection ON Users.ID = Connection.IdFollower
+		WHERE Connection.IdFollowing = ? AND Users.ID > ? AND Users.DeletedAt IS NULL ORDER BY Users.ID LIMIT ?`, idUser, page.After, page.Fetch())
+	if err != nil {
+		return nil, err
 	}
-	return listUser, nil
+	return scanUsers(rows)
 }
 
-func (r *repository) GetUserFollowers(idUser int) ([]User, error) {
-	row, err := r.db.Query("SELECT Users.* FROM Users INNER JOIN Connection ON Users.ID = Connection.idFollower WHERE Connection.idFollowing = ? ", idUser)
+// GetMutuals lists the users that idUser follows and that follow idUser back.
+func (r *repository) GetMutuals(ctx context.Context, idUser int, page pagination.Page) ([]User, error) {
+	ctx = dialect.Operation(ctx, "user", "GetMutuals")
+	rows, err := r.db.QueryContext(ctx, selectUser+` INNER JOIN Connection AS Following ON Users.ID = Following.IdFollowing
+		INNER JOIN Connection AS Follower ON Users.ID = Follower.IdFollower AND Follower.IdFollowing = Following.IdFollower
+		WHERE Following.IdFollower = ? AND Users.ID > ? AND Users.DeletedAt IS NULL ORDER BY Users.ID LIMIT ?`, idUser, page.After, page.Fetch())
 	if err != nil {
 		return nil, err
 	}
-	var listUser []User
-	for row.Next() {
-		var user User
-		//var con Connection
-		err = row.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Age,
-			&user.DocumentNumber,
-			&user.Email,
-			&user.Phone,
-			&user.Address.ZipCode,
-			&user.Address.Country,
-			&user.Address.State,
-			&user.Address.City,
-			&user.Address.Neighborhood,
-			&user.Address.Street,
-			&user.Address.Number,
-			&user.Address.Complement,
-			//&con.ID,
-			//&con.IdFollower,
-			//&con.IdFollowing,
+	return scanUsers(rows)
+}
+
+// CountConnections counts the followers and the followed users of idUser, leaving out the deleted ones.
+func (r *repository) CountConnections(ctx context.Context, idUser int) (int, int, error) {
+	ctx = dialect.Operation(ctx, "user", "CountConnections")
+	var followers, following int
+	err := r.db.QueryRowContext(ctx, `SELECT
+		(SELECT COUNT(*) FROM Connection INNER JOIN Users ON Users.ID = Connection.IdFollower
+			WHERE Connection.IdFollowing = ? AND Users.DeletedAt IS NULL),
+		(SELECT COUNT(*) FROM Connection INNER JOIN Users ON Users.ID = Connection.IdFollowing
+			WHERE Connection.IdFollower = ? AND Users.DeletedAt IS NULL)`, idUser, idUser).Scan(&followers, &following)
+	if err != nil {
+		return 0, 0, err
+	}
+	return followers, following, nil
+}
+
+// GetSuggestions ranks the users that idUser does not follow yet, first by how many of the users idUser
+// follows already follow them, then by living in the same city and state, then by their latest post or comment.
+// Only the users followed by someone idUser follows or living in the same state are suggested, or in the same
+// city of the same country when the address has no state.
+func (r *repository) GetSuggestions(ctx context.Context, idUser int, limit int) ([]Suggestion, error) {
+	ctx = dialect.Operation(ctx, "user", "GetSuggestions")
+	rows, err := r.db.QueryContext(ctx, `WITH Followed AS (
+			SELECT Connection.IdFollowing AS ID FROM Connection INNER JOIN Users ON Users.ID = Connection.IdFollowing
+			WHERE Connection.IdFollower = ? AND Users.DeletedAt IS NULL),
+		Candidates AS (
+			SELECT Connection.IdFollowing AS ID, COUNT(*) AS Mutuals FROM Connection
+			INNER JOIN Followed ON Connection.IdFollower = Followed.ID GROUP BY Connection.IdFollowing)
+		SELECT ID, Name, State, City, Mutuals, SameCity, SameState FROM (
+			SELECT Users.ID, Users.Name, Users.State, Users.City, COALESCE(Candidates.Mutuals, 0) AS Mutuals,
+				COALESCE(Users.City <> '' AND Users.Country = Me.Country AND Users.State = Me.State
+					AND Users.City = Me.City, FALSE) AS SameCity,
+				COALESCE(Users.State <> '' AND Users.Country = Me.Country AND Users.State = Me.State, FALSE) AS SameState,
+				(SELECT MAX(Activity) FROM (
+					SELECT DatePost AS Activity FROM Posts WHERE Posts.IDUser = Users.ID AND Posts.DeletedAt IS NULL
+					UNION ALL SELECT DateComment FROM Comment WHERE Comment.IDUser = Users.ID AND Comment.DeletedAt IS NULL
+				) AS Activities) AS LastActive
+			FROM Users INNER JOIN Users AS Me ON Me.ID = ?
+			LEFT JOIN Candidates ON Candidates.ID = Users.ID
+			WHERE Users.ID <> Me.ID AND Users.DeletedAt IS NULL AND Users.ID NOT IN (SELECT ID FROM Followed)
+		) AS Ranked WHERE Mutuals > 0 OR SameCity OR SameState
+		ORDER BY Mutuals DESC, SameCity DESC, SameState DESC, LastActive DESC NULLS LAST, ID LIMIT ?`,
+		idUser, idUser, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var suggestions []Suggestion
+	for rows.Next() {
+		var suggestion Suggestion
+		err := rows.Scan(
+			&suggestion.ID,
+			&suggestion.Name,
+			&suggestion.State,
+			&suggestion.City,
+			&suggestion.Mutuals,
+			&suggestion.SameCity,
+			&suggestion.SameState,
 		)
 		if err != nil {
 			return nil, err
 		}
-		listUser = append(listUser, user)
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
+
+// conflict turns a broken unique index of Users into a Conflict naming the duplicated field.
+func (r *repository) conflict(err error) error {
+	constraint, ok := r.db.UniqueViolation(err)
+	if !ok {
+		return err
+	}
+	if strings.Contains(strings.ToLower(constraint), "email") {
+		return apperror.Conflict("the email is already registered")
 	}
-	return listUser, nil
+	return apperror.Conflict("the document number is already registered")
 }
 
-func NewRepository(db *sql.DB) Repository {
+func NewRepository(db *dialect.DB) Repository {
 	return &repository{db}
 }
@@ -0,0 +1,296 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/dialect/dialecttest"
+	"socialBuddy/internal/pagination"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newDialectUser(name string, email string, documentNumber string) User {
+	return User{
+		Name:           name,
+		Age:            30,
+		DocumentNumber: documentNumber,
+		Email:          email,
+		Phone:          "+55 11 91234 5678",
+		Address: Address{
+			ZipCode: "01001-000",
+			Country: "Brasil",
+			State:   "SP",
+			City:    "São Paulo",
+			Street:  "Praça da Sé",
+			Number:  "1",
+		},
+	}
+}
+
+func TestRepositoryDialects(t *testing.T) {
+	dialecttest.Run(t, func(t *testing.T, db *dialect.DB) {
+		rep := NewRepository(db)
+		first, err := rep.CreateUser(context.Background(), newDialectUser("Name First", "first@gmail.com", "529.982.247-25"), "hash1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		second, err := rep.CreateUser(context.Background(), newDialectUser("Name Second", "second@gmail.com", "123.456.789-09"), "hash2")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		_, err = rep.CreateUser(context.Background(), newDialectUser("Name Third", "first@gmail.com", "111.444.777-35"), "hash3")
+		if !errors.Is(err, apperror.ErrConflict) || err.Error() != "the email is already registered" {
+			t.Fatalf("expected an email conflict, got %v", err)
+		}
+		_, err = rep.CreateUser(context.Background(), newDialectUser("Name Third", "FIRST@gmail.com", "111.444.777-35"), "hash3")
+		if !errors.Is(err, apperror.ErrConflict) || err.Error() != "the email is already registered" {
+			t.Fatalf("expected an email conflict regardless of case, got %v", err)
+		}
+		_, err = rep.UpdateUser(context.Background(), newDialectUser("Name Second", "second@gmail.com", "529.982.247-25"), second.ID)
+		if !errors.Is(err, apperror.ErrConflict) || err.Error() != "the document number is already registered" {
+			t.Fatalf("expected a document conflict, got %v", err)
+		}
+
+		byEmail, err := rep.GetUserByEmail(context.Background(), "First@Gmail.com")
+		if err != nil || byEmail.ID != first.ID || byEmail.Address.City != "São Paulo" {
+			t.Fatalf("expected %+v, got %+v, err %v", first, byEmail, err)
+		}
+		credentials, err := rep.GetCredentials(context.Background(), "second@gmail.com")
+		if err != nil || credentials.ID != second.ID || credentials.PasswordHash != "hash2" || credentials.Role != "user" {
+			t.Fatalf("unexpected credentials %+v, err %v", credentials, err)
+		}
+
+		update := newDialectUser("Name Changed", "first@gmail.com", "529.982.247-25")
+		updated, err := rep.UpdateUser(context.Background(), update, first.ID)
+		if err != nil || updated.Name != "Name Changed" {
+			t.Fatalf("expected the name to change, got %+v, err %v", updated, err)
+		}
+
+		err = rep.FollowUser(context.Background(), first.ID, second.ID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		isFollowing, err := rep.IsFollowing(context.Background(), first.ID, second.ID)
+		if err != nil || !isFollowing {
+			t.Fatalf("expected %d to follow %d, err %v", first.ID, second.ID, err)
+		}
+		following, err := rep.GetFollowingByUserID(context.Background(), first.ID, pagination.Page{Limit: 10})
+		if err != nil || len(following) != 1 || following[0].ID != second.ID {
+			t.Fatalf("unexpected following %+v, err %v", following, err)
+		}
+
+		err = rep.FollowUser(context.Background(), first.ID, second.ID)
+		if !errors.Is(err, apperror.ErrConflict) {
+			t.Fatalf("expected a conflict error on the second follow, got %v", err)
+		}
+		mutuals, err := rep.GetMutuals(context.Background(), first.ID, pagination.Page{Limit: 10})
+		if err != nil || len(mutuals) != 0 {
+			t.Fatalf("expected no mutuals before the follow back, got %+v, err %v", mutuals, err)
+		}
+		err = rep.FollowUser(context.Background(), second.ID, first.ID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		mutuals, err = rep.GetMutuals(context.Background(), first.ID, pagination.Page{Limit: 10})
+		if err != nil || len(mutuals) != 1 || mutuals[0].ID != second.ID {
+			t.Fatalf("unexpected mutuals %+v, err %v", mutuals, err)
+		}
+		followers, followed, err := rep.CountConnections(context.Background(), first.ID)
+		if err != nil || followers != 1 || followed != 1 {
+			t.Fatalf("expected one follower and one following, got %d and %d, err %v", followers, followed, err)
+		}
+
+		idPost, err := db.Insert("INSERT INTO Posts (IDUser, DatePost, Title, Content) VALUES (?, ?, ?, ?)", second.ID, time.Now(), "title1", "content1")
+		if err != nil {
+			t.Fatalf("the creation of post is failed %v", err)
+		}
+		idComment, err := db.Insert("INSERT INTO Comment (IDPost, IDUser, DateComment, Content) VALUES (?, ?, ?, ?)", idPost, first.ID, time.Now(), "content1")
+		if err != nil {
+			t.Fatalf("the creation of comment is failed %v", err)
+		}
+		_, err = db.Insert("INSERT INTO Comment (IDPost, IDUser, DateComment, Content, IDParent) VALUES (?, ?, ?, ?, ?)", idPost, first.ID, time.Now(), "content2", idComment)
+		if err != nil {
+			t.Fatalf("the creation of reply is failed %v", err)
+		}
+		hasContent, err := rep.HasContent(context.Background(), second.ID)
+		if err != nil || !hasContent {
+			t.Fatalf("expected %d to have content, err %v", second.ID, err)
+		}
+
+		_, err = db.Exec("INSERT INTO RefreshToken (IDUser, TokenHash, ExpiresAt) VALUES (?, ?, ?)", second.ID, "hash", time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("the creation of refresh token is failed %v", err)
+		}
+
+		deletedAt := time.Now().UTC()
+		err = rep.DeleteUser(context.Background(), second.ID, deletedAt)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		_, err = rep.GetUserByID(context.Background(), second.ID)
+		if !errors.Is(err, apperror.ErrNotFound) {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+		role, err := rep.GetRole(context.Background(), second.ID)
+		if err != nil || role != "" {
+			t.Fatalf("expected no role for the deleted user, got %q, err %v", role, err)
+		}
+		var tokens int
+		err = db.QueryRow("SELECT COUNT(*) FROM RefreshToken WHERE IDUser = ?", second.ID).Scan(&tokens)
+		if err != nil || tokens != 0 {
+			t.Fatalf("expected the refresh tokens to be revoked, got %d, err %v", tokens, err)
+		}
+		following, err = rep.GetFollowingByUserID(context.Background(), first.ID, pagination.Page{Limit: 10})
+		if err != nil || len(following) != 0 {
+			t.Fatalf("expected the deleted user to be hidden, got %+v, err %v", following, err)
+		}
+		followers, followed, err = rep.CountConnections(context.Background(), first.ID)
+		if err != nil || followers != 0 || followed != 0 {
+			t.Fatalf("expected the deleted user to leave the counts, got %d and %d, err %v", followers, followed, err)
+		}
+		if posts, comments := countVisible(t, db); posts != 0 || comments != 0 {
+			t.Fatalf("expected the posts and comments to be deleted with the user, got %d and %d", posts, comments)
+		}
+		storedAt, err := rep.GetDeletedAt(context.Background(), second.ID)
+		if err != nil || storedAt.Sub(deletedAt).Abs() > time.Second {
+			t.Fatalf("expected the deletion date %s, got %s, err %v", deletedAt, storedAt, err)
+		}
+
+		err = rep.RestoreUser(context.Background(), second.ID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if posts, comments := countVisible(t, db); posts != 1 || comments != 2 {
+			t.Fatalf("expected the posts and comments to be restored, got %d and %d", posts, comments)
+		}
+		_, err = rep.GetDeletedAt(context.Background(), second.ID)
+		if !errors.Is(err, apperror.ErrNotFound) {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+
+		err = rep.DeleteUser(context.Background(), second.ID, deletedAt)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		purged, err := rep.PurgeUsers(context.Background(), deletedAt.Add(-time.Minute), false)
+		if err != nil || purged != 0 {
+			t.Fatalf("expected nothing to purge, got %d, err %v", purged, err)
+		}
+		purged, err = rep.PurgeUsers(context.Background(), deletedAt.Add(time.Minute), true)
+		if err != nil || purged != 0 {
+			t.Fatalf("expected the user with posts to be kept under restrict, got %d, err %v", purged, err)
+		}
+		purged, err = rep.PurgeUsers(context.Background(), deletedAt.Add(time.Minute), false)
+		if err != nil || purged != 1 {
+			t.Fatalf("expected one user to be purged, got %d, err %v", purged, err)
+		}
+		isFollowing, err = rep.IsFollowing(context.Background(), first.ID, second.ID)
+		if err != nil || isFollowing {
+			t.Fatalf("expected the connection to be purged with the user, err %v", err)
+		}
+		var rows int
+		err = db.QueryRow("SELECT COUNT(*) FROM Posts").Scan(&rows)
+		if err != nil || rows != 0 {
+			t.Fatalf("expected the posts to be purged with the user, got %d, err %v", rows, err)
+		}
+	})
+}
+
+func countVisible(t *testing.T, db *dialect.DB) (int, int) {
+	var posts, comments int
+	err := db.QueryRow("SELECT (SELECT COUNT(*) FROM Posts WHERE DeletedAt IS NULL), (SELECT COUNT(*) FROM Comment WHERE DeletedAt IS NULL)").Scan(&posts, &comments)
+	if err != nil {
+		t.Fatalf("the count is failed %v", err)
+	}
+	return posts, comments
+}
+
+func TestSuggestionsDialects(t *testing.T) {
+	dialecttest.Run(t, func(t *testing.T, db *dialect.DB) {
+		rep := NewRepository(db)
+		ids := map[string]int{}
+		for _, u := range []struct{ name, state, city string }{
+			{"me", "SP", "São Paulo"}, {"friend", "SP", "São Paulo"}, {"other", "MG", "Belo Horizonte"},
+			{"one", "RJ", "Rio de Janeiro"}, {"two", "RJ", "Rio de Janeiro"}, {"active", "SP", "São Paulo"},
+			{"quiet", "SP", "São Paulo"}, {"state", "SP", "Campinas"}, {"far", "RJ", "Niterói"},
+		} {
+			newUser := newDialectUser(u.name, u.name+"@gmail.com", "")
+			newUser.Address.State = u.state
+			newUser.Address.City = u.city
+			created, err := rep.CreateUser(context.Background(), newUser, "hash")
+			if err != nil {
+				t.Fatalf("the creation of user is failed %v", err)
+			}
+			ids[u.name] = created.ID
+		}
+		for _, connection := range [][2]string{
+			{"me", "friend"}, {"me", "other"}, {"friend", "one"}, {"friend", "two"}, {"other", "two"}, {"friend", "me"},
+		} {
+			err := rep.FollowUser(context.Background(), ids[connection[0]], ids[connection[1]])
+			if err != nil {
+				t.Fatalf("the creation of connection is failed %v", err)
+			}
+		}
+		_, err := db.Insert("INSERT INTO Posts (IDUser, DatePost, Title, Content) VALUES (?, ?, ?, ?)", ids["active"], time.Now(), "title1", "content1")
+		if err != nil {
+			t.Fatalf("the creation of post is failed %v", err)
+		}
+
+		suggestions, err := rep.GetSuggestions(context.Background(), ids["me"], 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		var names []string
+		for _, suggestion := range suggestions {
+			names = append(names, suggestion.Name)
+		}
+		if strings.Join(names, ",") != "two,one,active,quiet,state" {
+			t.Fatalf("unexpected suggestions %v", names)
+		}
+		if suggestions[0].Mutuals != 2 || suggestions[1].Mutuals != 1 || !suggestions[2].SameCity || suggestions[4].SameCity || !suggestions[4].SameState {
+			t.Fatalf("unexpected reasons %+v", suggestions)
+		}
+
+		err = rep.DeleteUser(context.Background(), ids["two"], time.Now().UTC())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		suggestions, err = rep.GetSuggestions(context.Background(), ids["me"], 2)
+		if err != nil || len(suggestions) != 2 || suggestions[0].Name != "one" || suggestions[1].Name != "active" {
+			t.Fatalf("unexpected suggestions after the deletion %+v, err %v", suggestions, err)
+		}
+	})
+}
+
+func TestSuggestionsWithoutStateDialects(t *testing.T) {
+	dialecttest.Run(t, func(t *testing.T, db *dialect.DB) {
+		rep := NewRepository(db)
+		ids := map[string]int{}
+		for _, u := range []struct{ name, country, city string }{
+			{"me", "Portugal", "Lisboa"}, {"neighbor", "Portugal", "Lisboa"}, {"porto", "Portugal", "Porto"},
+			{"abroad", "Brasil", "Lisboa"}, {"nowhere", "Portugal", ""},
+		} {
+			newUser := newDialectUser(u.name, u.name+"@gmail.com", "")
+			newUser.Address.Country = u.country
+			newUser.Address.State = ""
+			newUser.Address.City = u.city
+			created, err := rep.CreateUser(context.Background(), newUser, "hash")
+			if err != nil {
+				t.Fatalf("the creation of user is failed %v", err)
+			}
+			ids[u.name] = created.ID
+		}
+
+		suggestions, err := rep.GetSuggestions(context.Background(), ids["me"], 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(suggestions) != 1 || suggestions[0].ID != ids["neighbor"] || !suggestions[0].SameCity || suggestions[0].SameState {
+			t.Fatalf("unexpected suggestions %+v", suggestions)
+		}
+	})
+}
@@ -1,12 +1,17 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"log"
 	"reflect"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/pagination"
 	"testing"
+	"time"
 )
 
 type argGet struct {
@@ -29,6 +34,20 @@ type argEmail struct {
 	hasError error
 }
 
+type argRole struct {
+	name     string
+	id       int
+	output   string
+	hasError error
+}
+
+type argCredentials struct {
+	name     string
+	email    string
+	output   *Credentials
+	hasError error
+}
+
 type argCreate struct {
 	name     string
 	newUser  User
@@ -57,6 +76,14 @@ type argFollower struct {
 	hasError    error
 }
 
+type argIsFollowing struct {
+	name        string
+	idFollower  int
+	idFollowing int
+	output      bool
+	hasError    error
+}
+
 type argGetFollow struct {
 	name     string
 	id       int
@@ -75,11 +102,11 @@ func TestGetUser(t *testing.T) {
 
 		}
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	result := sqlmock.NewRows([]string{
 		"ID", "Name", "Age", "DocumentNumber", "Email", "Phone", "ZipCode", "Country", "State", "City", "Neighborhood", "Street", "Number", "Complement",
 	}).AddRow(1, "Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 12345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C")
-	mock.ExpectQuery("SELECT \\* FROM Users").WillReturnRows(result)
+	mock.ExpectQuery("SELECT (.+) FROM Users WHERE Users.ID > \\? AND Users.DeletedAt IS NULL ORDER BY Users.ID LIMIT \\?").WithArgs(0, 11).WillReturnRows(result)
 
 	test := []argGet{
 		{name: "GetUsers() from database succeed",
@@ -112,7 +139,7 @@ func TestGetUser(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			users, err := rep.GetUsers()
+			users, err := rep.GetUsers(context.Background(), pagination.Page{Limit: 10})
 			log.Printf("users: %+v, err: %+v", users, err)
 			if !reflect.DeepEqual(users, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, users)
@@ -135,11 +162,11 @@ func TestGetUserByID(t *testing.T) {
 
 		}
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	result := sqlmock.NewRows([]string{
 		"ID", "Name", "Age", "DocumentNumber", "Email", "Phone", "ZipCode", "Country", "State", "City", "Neighborhood", "Street", "Number", "Complement",
 	}).AddRow(1, "Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 12345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C")
-	mock.ExpectQuery("SELECT \\* FROM Users WHERE ID = ?").WithArgs(1).WillReturnRows(result)
+	mock.ExpectQuery("SELECT (.+) FROM Users WHERE Users.ID = \\?").WithArgs(1).WillReturnRows(result)
 	test := []argID{
 		{
 			name: "GetUserByID() is succeed",
@@ -172,7 +199,7 @@ func TestGetUserByID(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			users, err := rep.GetUserByID(tt.id)
+			users, err := rep.GetUserByID(context.Background(), tt.id)
 			log.Printf("users: %+v, err: %+v", users, err)
 			if !reflect.DeepEqual(users, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, users)
@@ -195,11 +222,11 @@ func TestGetUserByEmail(t *testing.T) {
 
 		}
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	result := sqlmock.NewRows([]string{
 		"ID", "Name", "Age", "DocumentNumber", "Email", "Phone", "ZipCode", "Country", "State", "City", "Neighborhood", "Street", "Number", "Complement",
 	}).AddRow(1, "Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 12345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C")
-	mock.ExpectQuery("SELECT \\* FROM Users WHERE Email = ?").WithArgs("name.first@gmail.com").WillReturnRows(result)
+	mock.ExpectQuery("SELECT (.+) FROM Users WHERE LOWER\\(Users.Email\\) = LOWER\\(\\?\\)").WithArgs("name.first@gmail.com").WillReturnRows(result)
 	test := []argEmail{
 		{
 			name:  "GetUserByEmail() is succeed",
@@ -232,7 +259,7 @@ func TestGetUserByEmail(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			users, err := rep.GetUserByEmail(tt.email)
+			users, err := rep.GetUserByEmail(context.Background(), tt.email)
 			log.Printf("users: %+v, err: %+v", users, err)
 			if !reflect.DeepEqual(users, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, users)
@@ -244,6 +271,96 @@ func TestGetUserByEmail(t *testing.T) {
 	}
 }
 
+func TestGetCredentials(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed: %+v", mockDB)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	result := sqlmock.NewRows([]string{"ID", "PasswordHash", "Role"}).AddRow(1, "hash", "user")
+	mock.ExpectQuery("SELECT ID, PasswordHash, Role FROM Users WHERE LOWER\\(Email\\) = LOWER\\(\\?\\)").WithArgs("name.first@gmail.com").WillReturnRows(result)
+	mock.ExpectQuery("SELECT ID, PasswordHash, Role FROM Users WHERE LOWER\\(Email\\) = LOWER\\(\\?\\)").WithArgs("name.second@gmail.com").WillReturnRows(sqlmock.NewRows([]string{"ID", "PasswordHash", "Role"}))
+	test := []argCredentials{
+		{
+			name:     "GetCredentials() is succeed",
+			email:    "name.first@gmail.com",
+			output:   &Credentials{ID: 1, PasswordHash: "hash", Role: "user"},
+			hasError: nil,
+		},
+		{
+			name:     "GetCredentials() when email wasn't found",
+			email:    "name.second@gmail.com",
+			output:   nil,
+			hasError: nil,
+		},
+		{
+			name:     "GetCredentials() is failed",
+			email:    "name.third@gmail.com",
+			output:   nil,
+			hasError: errors.New("the query is failed"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			credentials, err := rep.GetCredentials(context.Background(), tt.email)
+			log.Printf("credentials: %+v, err: %+v", credentials, err)
+			if !reflect.DeepEqual(credentials, tt.output) {
+				t.Fatalf("expected %+v, got %+v", tt.output, credentials)
+			}
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
+
+func TestGetRole(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed: %+v", mockDB)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectQuery("SELECT Role FROM Users WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"Role"}).AddRow("admin"))
+	mock.ExpectQuery("SELECT Role FROM Users WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"Role"}))
+	test := []argRole{
+		{
+			name:     "GetRole() is succeed",
+			id:       1,
+			output:   "admin",
+			hasError: nil,
+		},
+		{
+			name:     "GetRole() when id wasn't found",
+			id:       2,
+			output:   "",
+			hasError: nil,
+		},
+		{
+			name:     "GetRole() is failed",
+			id:       3,
+			output:   "",
+			hasError: errors.New("the query is failed"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			role, err := rep.GetRole(context.Background(), tt.id)
+			if role != tt.output {
+				t.Fatalf("expected %s, got %s", tt.output, role)
+			}
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
+
 func TestCreateUser(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	if err != nil {
@@ -254,12 +371,14 @@ func TestCreateUser(t *testing.T) {
 		if err != nil {
 		}
 	}(mockDB)
-	rep := NewRepository(mockDB)
-	mock.ExpectExec("INSERT INTO Users").WithArgs("Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 12345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C").WillReturnResult(sqlmock.NewResult(1, 1))
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO Users").WithArgs("Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 12345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C", "hash").WillReturnResult(sqlmock.NewResult(1, 1))
 	result := sqlmock.NewRows([]string{
 		"ID", "Name", "Age", "DocumentNumber", "Email", "Phone", "ZipCode", "Country", "State", "City", "Neighborhood", "Street", "Number", "Complement",
 	}).AddRow(1, "Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 12345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C")
-	mock.ExpectQuery("SELECT \\* FROM Users WHERE ID = ?").WithArgs(1).WillReturnRows(result)
+	mock.ExpectQuery("SELECT (.+) FROM Users WHERE Users.ID = \\?").WithArgs(1).WillReturnRows(result)
+	mock.ExpectCommit()
 	test := []argCreate{
 		{
 			name: "CreateUser() is succeed",
@@ -327,7 +446,7 @@ func TestCreateUser(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			users, err := rep.CreateUser(tt.newUser)
+			users, err := rep.CreateUser(context.Background(), tt.newUser, "hash")
 			log.Printf("user: %v, err: %v", users, err)
 			if !reflect.DeepEqual(users, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, users)
@@ -349,12 +468,14 @@ func TestUpdateUser(t *testing.T) {
 		if err != nil {
 		}
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
 	mock.ExpectExec("UPDATE Users SET Name = ?, Age = ?, DocumentNumber = ?, Email = ?, Phone = ?, ZipCode = ?, Country = ?, State = ?, City = ?, Neighborhood = ?, Street = ?, Number = ?, Complement = ? WHERE ID = ?").WithArgs("Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 92345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C", 1).WillReturnResult(sqlmock.NewResult(1, 1))
 	result := sqlmock.NewRows([]string{
 		"ID", "Name", "Age", "DocumentNumber", "Email", "Phone", "ZipCode", "Country", "State", "City", "Neighborhood", "Street", "Number", "Complement",
 	}).AddRow(1, "Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 92345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C")
-	mock.ExpectQuery("SELECT * FROM Users WHERE ID = ?").WithArgs(1).WillReturnRows(result)
+	mock.ExpectQuery(selectUser + " WHERE Users.ID = ? AND Users.DeletedAt IS NULL").WithArgs(1).WillReturnRows(result)
+	mock.ExpectCommit()
 
 	test := []argUpdate{
 		{
@@ -425,7 +546,7 @@ func TestUpdateUser(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			users, err := rep.UpdateUser(tt.newUser, tt.id)
+			users, err := rep.UpdateUser(context.Background(), tt.newUser, tt.id)
 			log.Printf("user: %v, err: %v", users, err)
 			if !reflect.DeepEqual(users, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, users)
@@ -447,9 +568,13 @@ func TestDeleteUser(t *testing.T) {
 		if err != nil {
 		}
 	}(mockDB)
-	rep := NewRepository(mockDB)
-	mock.ExpectExec("PRAGMA foreign_keys = ON").WithoutArgs().WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectExec("DELETE FROM Users WHERE ID = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE Users SET DeletedAt = \\? WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE Posts SET DeletedAt = \\? WHERE IDUser = \\? AND DeletedAt IS NULL").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE Comment SET DeletedAt = \\?").WithArgs(sqlmock.AnyArg(), 1, 1).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM RefreshToken WHERE IDUser = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 	test := []argDelete{
 		{
 			name:     "DeleteUser() is succeed",
@@ -464,7 +589,7 @@ func TestDeleteUser(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			err := rep.DeleteUser(tt.id)
+			err := rep.DeleteUser(context.Background(), tt.id, time.Now().UTC())
 			log.Printf("err: %v", err)
 			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
 				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
@@ -484,7 +609,7 @@ func TestFollowUser(t *testing.T) {
 
 		}
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	mock.ExpectExec("INSERT INTO Connection").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(1, 1))
 	result := sqlmock.NewRows([]string{"ID", "idFollower", "idFollowing"}).AddRow(1, 1, 2)
 	mock.ExpectQuery("SELECT \\* FROM Connection WHERE IdFollower = \\?").WithArgs(1).WillReturnRows(result)
@@ -504,7 +629,7 @@ func TestFollowUser(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			err := rep.FollowUser(tt.idFollower, tt.idFollowing)
+			err := rep.FollowUser(context.Background(), tt.idFollower, tt.idFollowing)
 			log.Printf("err: %v", err)
 			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
 				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
@@ -524,7 +649,7 @@ func TestDeleteConnection(t *testing.T) {
 
 		}
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	mock.ExpectExec("DELETE FROM Connection WHERE IdFollower = \\? AND IdFollowing = \\?").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(1, 1))
 	test := []argFollower{
 		{
@@ -542,7 +667,7 @@ func TestDeleteConnection(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			err := rep.DeleteConnection(tt.idFollower, tt.idFollowing)
+			err := rep.DeleteConnection(context.Background(), tt.idFollower, tt.idFollowing)
 			log.Printf("err: %v", err)
 			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
 				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
@@ -551,6 +676,54 @@ func TestDeleteConnection(t *testing.T) {
 	}
 }
 
+func TestIsFollowing(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM Connection WHERE IdFollower = \\? AND IdFollowing = \\?").WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM Connection WHERE IdFollower = \\? AND IdFollowing = \\?").WithArgs(1, 3).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+	test := []argIsFollowing{
+		{
+			name:        "IsFollowing() when the connection exists",
+			idFollower:  1,
+			idFollowing: 2,
+			output:      true,
+			hasError:    nil,
+		},
+		{
+			name:        "IsFollowing() when the connection does not exist",
+			idFollower:  1,
+			idFollowing: 3,
+			output:      false,
+			hasError:    nil,
+		},
+		{
+			name:        "IsFollowing() is failed",
+			idFollower:  1,
+			idFollowing: 4,
+			output:      false,
+			hasError:    errors.New("the query is failed"),
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			isFollowing, err := rep.IsFollowing(context.Background(), tt.idFollower, tt.idFollowing)
+			log.Printf("isFollowing: %v, err: %v", isFollowing, err)
+			if isFollowing != tt.output {
+				t.Fatalf("expected %v, got %v", tt.output, isFollowing)
+			}
+			if (err != nil && tt.hasError == nil) || (err == nil && tt.hasError != nil) {
+				t.Fatalf("expeced error %+v, got %+v", tt.hasError, err)
+			}
+		})
+	}
+}
+
 func TestGetFollowingByUserID(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	if err != nil {
@@ -562,11 +735,11 @@ func TestGetFollowingByUserID(t *testing.T) {
 
 		}
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 	result := sqlmock.NewRows([]string{
 		"ID", "Name", "Age", "DocumentNumber", "Email", "Phone", "ZipCode", "Country", "State", "City", "Neighborhood", "Street", "Number", "Complement",
 	}).AddRow(2, "Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 12345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C")
-	mock.ExpectQuery("SELECT Users.\\* FROM Users INNER JOIN Connection ON Users.ID = Connection.idFollowing WHERE Connection.idFollower = \\? ").WithArgs(3).WillReturnRows(result)
+	mock.ExpectQuery("SELECT (.+) FROM Users INNER JOIN Connection ON Users.ID = Connection.IdFollowing\\s+WHERE Connection.IdFollower = \\? AND Users.ID > \\? AND Users.DeletedAt IS NULL ORDER BY Users.ID LIMIT \\?").WithArgs(3, 0, 11).WillReturnRows(result)
 	test := []argGetFollow{
 		{
 			name: "GetFollowingByUserID() is succeed",
@@ -602,7 +775,7 @@ func TestGetFollowingByUserID(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			users, err := rep.GetFollowingByUserID(tt.id)
+			users, err := rep.GetFollowingByUserID(context.Background(), tt.id, pagination.Page{Limit: 10})
 			log.Printf("users: %+v, err: %+v", users, err)
 			if !reflect.DeepEqual(users, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, users)
@@ -625,12 +798,12 @@ func TestGetUserFollowers(t *testing.T) {
 
 		}
 	}(mockDB)
-	rep := NewRepository(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
 
 	result1 := sqlmock.NewRows([]string{
 		"ID", "Name", "Age", "DocumentNumber", "Email", "Phone", "ZipCode", "Country", "State", "City", "Neighborhood", "Street", "Number", "Complement",
 	}).AddRow(2, "Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 12345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C")
-	mock.ExpectQuery("SELECT Users.\\* FROM Users INNER JOIN Connection ON Users.ID = Connection.idFollower WHERE Connection.idFollowing = \\? ").WithArgs(1).WillReturnRows(result1)
+	mock.ExpectQuery("SELECT (.+) FROM Users INNER JOIN Connection ON Users.ID = Connection.IdFollower\\s+WHERE Connection.IdFollowing = \\? AND Users.ID > \\? AND Users.DeletedAt IS NULL ORDER BY Users.ID LIMIT \\?").WithArgs(1, 0, 11).WillReturnRows(result1)
 
 	test := []argGetFollow{
 		{
@@ -667,7 +840,7 @@ func TestGetUserFollowers(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			users, err := rep.GetUserFollowers(tt.id)
+			users, err := rep.GetUserFollowers(context.Background(), tt.id, pagination.Page{Limit: 10})
 			log.Printf("users: %+v, err: %+v", users, err)
 			if !reflect.DeepEqual(users, tt.output) {
 				t.Fatalf("expected %+v, got %+v", tt.output, users)
@@ -678,3 +851,137 @@ func TestGetUserFollowers(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUserByIDNotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectQuery("WHERE Users.ID = \\?").WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"ID"}))
+	user, err := rep.GetUserByID(context.Background(), 3)
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("expected not found error, got %+v", err)
+	}
+	if user != nil {
+		t.Fatalf("expected no user, got %+v", user)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetUserByEmailNotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectQuery("WHERE LOWER\\(Users.Email\\) = LOWER\\(\\?\\)").WithArgs("nobody@gmail.com").WillReturnRows(sqlmock.NewRows([]string{"ID"}))
+	user, err := rep.GetUserByEmail(context.Background(), "nobody@gmail.com")
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("expected not found error, got %+v", err)
+	}
+	if user != nil {
+		t.Fatalf("expected no user, got %+v", user)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestDeleteUserNotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE Users SET DeletedAt = \\? WHERE ID = \\? AND DeletedAt IS NULL").WithArgs(sqlmock.AnyArg(), 3).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = rep.DeleteUser(context.Background(), 3, time.Now().UTC())
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("expected not found error, got %+v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetMutuals(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	result := sqlmock.NewRows([]string{
+		"ID", "Name", "Age", "DocumentNumber", "Email", "Phone", "ZipCode", "Country", "State", "City", "Neighborhood", "Street", "Number", "Complement",
+	}).AddRow(2, "Name First", 35, "123.345.567-89", "name.first@gmail.com", "+55 11 12345 6789", "12246-260", "Brasil", "SP", "São José dos Campos", "Parque Residencial Aquarius", "Avenida Salmão", "456", "C")
+	mock.ExpectQuery("SELECT (.+) FROM Users INNER JOIN Connection AS Following ON Users.ID = Following.IdFollowing\\s+"+
+		"INNER JOIN Connection AS Follower ON Users.ID = Follower.IdFollower AND Follower.IdFollowing = Following.IdFollower\\s+"+
+		"WHERE Following.IdFollower = \\? AND Users.ID > \\? AND Users.DeletedAt IS NULL ORDER BY Users.ID LIMIT \\?").WithArgs(1, 0, 11).WillReturnRows(result)
+	users, err := rep.GetMutuals(context.Background(), 1, pagination.Page{Limit: 10})
+	if err != nil || len(users) != 1 || users[0].ID != 2 {
+		t.Fatalf("unexpected mutuals %+v, err %+v", users, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCountConnections(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	mock.ExpectQuery("SELECT\\s+\\(SELECT COUNT\\(\\*\\) FROM Connection").WithArgs(1, 1).WillReturnRows(sqlmock.NewRows([]string{"followers", "following"}).AddRow(3, 2))
+	followers, following, err := rep.CountConnections(context.Background(), 1)
+	if err != nil || followers != 3 || following != 2 {
+		t.Fatalf("expected 3 followers and 2 following, got %d and %d, err %+v", followers, following, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetSuggestions(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("the creation of mock is failed %v", err)
+	}
+	defer func(mockDB *sql.DB) {
+		_ = mockDB.Close()
+	}(mockDB)
+	rep := NewRepository(dialect.New(mockDB, dialect.SQLite()))
+	result := sqlmock.NewRows([]string{
+		"ID", "Name", "State", "City", "Mutuals", "SameCity", "SameState",
+	}).AddRow(2, "Name First", "SP", "São José dos Campos", 3, 1, 1)
+	mock.ExpectQuery("WITH Followed AS (.+) SELECT Users.ID, Users.Name, Users.State, Users.City, COALESCE(.+) ORDER BY Mutuals DESC, SameCity DESC, SameState DESC, LastActive DESC NULLS LAST, ID LIMIT \\?").WithArgs(1, 1, 5).WillReturnRows(result)
+	suggestions, err := rep.GetSuggestions(context.Background(), 1, 5)
+	if err != nil || len(suggestions) != 1 || suggestions[0].ID != 2 || suggestions[0].Mutuals != 3 || !suggestions[0].SameCity || !suggestions[0].SameState {
+		t.Fatalf("unexpected suggestions %+v, err %+v", suggestions, err)
+	}
+	expected := Suggestion{ID: 2, Name: "Name First", State: "SP", City: "São José dos Campos", Mutuals: 3, SameCity: true, SameState: true}
+	if !reflect.DeepEqual(suggestions[0], expected) {
+		t.Fatalf("expected only the public profile %+v, got %+v", expected, suggestions[0])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %v", err)
+	}
+}
@@ -3,7 +3,11 @@ package user
 import (
 	"encoding/json"
 	"github.com/go-chi/chi/v5"
+	"io"
 	"net/http"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/pagination"
 	"strconv"
 )
 
@@ -11,16 +15,21 @@ type Server struct {
 	userService Service
 }
 
-func (s *Server) GetUsers(w http.ResponseWriter, _ *http.Request) {
-	user, err := s.userService.GetUsers()
+func (s *Server) GetUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	user, err := s.userService.GetUsers(r.Context(), page)
+	if err != nil {
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(user)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(user, page, userID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
@@ -29,23 +38,23 @@ func (s *Server) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var newUser User
 	err := json.NewDecoder(r.Body).Decode(&newUser)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	err = r.Body.Close()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 
-	user, err := s.userService.CreateUser(newUser)
+	user, err := s.userService.CreateUser(r.Context(), newUser)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	err = json.NewEncoder(w).Encode(user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
@@ -54,127 +63,208 @@ func (s *Server) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	userId := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(userId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	user, err := s.userService.GetUserByID(id)
+	profile, err := s.userService.GetProfile(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(user)
+	err = json.NewEncoder(w).Encode(profile)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
 
 func (s *Server) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 	userEmail := chi.URLParam(r, "email")
-	user, err := s.userService.GetUserByEmail(userEmail)
+	user, err := s.userService.GetUserByEmail(r.Context(), userEmail)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
 
 func (s *Server) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
 	userId := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(userId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	var userUp User
 	err = json.NewDecoder(r.Body).Decode(&userUp)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	err = r.Body.Close()
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	user, err := s.userService.UpdateUser(r.Context(), userUp, id, identity)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(user)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func (s *Server) PatchUser(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
+	userId := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(userId)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 	err = r.Body.Close()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
-	user, err := s.userService.UpdateUser(userUp, id)
+	user, err := s.userService.PatchUser(r.Context(), patch, id, identity)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
 
 func (s *Server) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
 	userId := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(userId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	err = s.userService.DeleteUser(id)
+	err = s.userService.DeleteUser(r.Context(), id, identity)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *Server) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
+	userId := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(userId)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	user, err := s.userService.RestoreUser(r.Context(), id, identity)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(user)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
 func (s *Server) FollowUser(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
 	idFollower := chi.URLParam(r, "id")
 	follower, err := strconv.Atoi(idFollower)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 
 	idFollowing := chi.URLParam(r, "following_id")
 	following, err := strconv.Atoi(idFollowing)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 
-	err = s.userService.FollowUser(follower, following)
+	err = s.userService.FollowUser(r.Context(), follower, following, identity)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	err = json.NewEncoder(w).Encode(err)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
 
 func (s *Server) DeleteConnection(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
 	idFollower := chi.URLParam(r, "id")
 	follower, err := strconv.Atoi(idFollower)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 
 	idFollowing := chi.URLParam(r, "following_id")
 	following, err := strconv.Atoi(idFollowing)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 
-	err = s.userService.DeleteConnection(follower, following)
+	err = s.userService.DeleteConnection(r.Context(), follower, following, identity)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -184,20 +274,25 @@ func (s *Server) GetFollowingByUserID(w http.ResponseWriter, r *http.Request) {
 	userId := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(userId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
 
-	user, err := s.userService.GetFollowingByUserID(id)
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	user, err := s.userService.GetFollowingByUserID(r.Context(), id, page)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(user)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(user, page, userID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
 		return
 	}
 }
@@ -206,36 +301,112 @@ func (s *Server) GetUserFollowers(w http.ResponseWriter, r *http.Request) {
 	userId := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(userId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, apperror.Validation(err.Error()))
 		return
 	}
-	user, err := s.userService.GetUserFollowers(id)
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	user, err := s.userService.GetUserFollowers(r.Context(), id, page)
+	if err != nil {
+		apperror.Write(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(user)
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(user, page, userID))
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func (s *Server) GetMutuals(w http.ResponseWriter, r *http.Request) {
+	userId := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(userId)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	user, err := s.userService.GetMutuals(r.Context(), id, page)
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(pagination.NewResponse(user, page, userID))
+	if err != nil {
+		apperror.Write(w, err)
+		return
+	}
+}
+
+func (s *Server) GetSuggestions(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		apperror.Write(w, apperror.Unauthorized("the request is not authenticated"))
+		return
+	}
+	userId := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(userId)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	suggestions, err := s.userService.GetSuggestions(r.Context(), id, identity)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, err)
+		return
+	}
+	if suggestions == nil {
+		suggestions = []Suggestion{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(pagination.Response[Suggestion]{Data: suggestions})
+	if err != nil {
+		apperror.Write(w, err)
 		return
 	}
 }
 
-func (s *Server) GetFollow(w http.ResponseWriter, r *http.Request) {
-	follower := r.URL.Query().Get("follower")
-	isFollowing, err := strconv.ParseBool(follower)
+func (s *Server) IsFollowing(w http.ResponseWriter, r *http.Request) {
+	idFollower := chi.URLParam(r, "id")
+	follower, err := strconv.Atoi(idFollower)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	idFollowing := chi.URLParam(r, "following_id")
+	following, err := strconv.Atoi(idFollowing)
+	if err != nil {
+		apperror.Write(w, apperror.Validation(err.Error()))
+		return
+	}
+	isFollowing, err := s.userService.IsFollowing(r.Context(), follower, following)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperror.Write(w, err)
 		return
 	}
-	if isFollowing {
-		s.GetFollowingByUserID(w, r)
-	} else {
-		s.GetUserFollowers(w, r)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(struct {
+		Following bool `json:"following"`
+	}{isFollowing})
+	if err != nil {
+		apperror.Write(w, err)
+		return
 	}
 }
 
+func userID(user User) int {
+	return user.ID
+}
+
 func NewServer(userService Service) *Server {
 	return &Server{userService}
 }
@@ -1,177 +1,351 @@
 package user
 
 import (
-	"errors"
+	"context"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/deletion"
+	"socialBuddy/internal/dialect"
+	"socialBuddy/internal/mergepatch"
+	"socialBuddy/internal/pagination"
+	"time"
 )
 
 type service struct {
 	UserRepository Repository
 	UserFacade     Facade
+	UserPolicy     auth.Policy
+	UnitOfWork     dialect.UnitOfWork
+	Deletion       deletion.Policy
+	Suggestions    SuggestionOptions
+	suggestions    *suggestionCache
 }
 
 type Service interface {
-	CreateUser(user User) (*User, error)
-	GetUsers() ([]User, error)
-	GetUserByID(idUser int) (*User, error)
-	GetUserByEmail(emailUser string) (*User, error)
-	UpdateUser(user User, idUser int) (*User, error)
-	DeleteUser(idUser int) error
-	FollowUser(idFollower int, idFollowing int) error
-	DeleteConnection(idFollower int, idFollowing int) error
-	GetFollowingByUserID(idUser int) ([]User, error)
-	GetUserFollowers(idUser int) ([]User, error)
+	CreateUser(ctx context.Context, user User) (*User, error)
+	GetUsers(ctx context.Context, page pagination.Page) ([]User, error)
+	GetUserByID(ctx context.Context, idUser int) (*User, error)
+	GetProfile(ctx context.Context, idUser int) (*Profile, error)
+	GetUserByEmail(ctx context.Context, emailUser string) (*User, error)
+	Authenticate(ctx context.Context, emailUser string, password string) (*auth.Identity, error)
+	GetIdentity(ctx context.Context, idUser int) (*auth.Identity, error)
+	UpdateUser(ctx context.Context, user User, idUser int, actor auth.Identity) (*User, error)
+	PatchUser(ctx context.Context, patch []byte, idUser int, actor auth.Identity) (*User, error)
+	DeleteUser(ctx context.Context, idUser int, actor auth.Identity) error
+	RestoreUser(ctx context.Context, idUser int, actor auth.Identity) (*User, error)
+	FollowUser(ctx context.Context, idFollower int, idFollowing int, actor auth.Identity) error
+	DeleteConnection(ctx context.Context, idFollower int, idFollowing int, actor auth.Identity) error
+	GetFollowingByUserID(ctx context.Context, idUser int, page pagination.Page) ([]User, error)
+	GetUserFollowers(ctx context.Context, idUser int, page pagination.Page) ([]User, error)
+	GetMutuals(ctx context.Context, idUser int, page pagination.Page) ([]User, error)
+	IsFollowing(ctx context.Context, idFollower int, idFollowing int) (bool, error)
+	GetSuggestions(ctx context.Context, idUser int, actor auth.Identity) ([]Suggestion, error)
 }
 
-func (s *service) CreateUser(user User) (*User, error) {
-
-	err := nameValidation(user.Name)
+func (s *service) CreateUser(ctx context.Context, user User) (*User, error) {
+	user.Email = normalizeEmail(user.Email)
+	country, err := userValidation(user, true)
 	if err != nil {
 		return nil, err
 	}
-	err = ageValidation(user.Age)
+
+	user.Address, err = s.resolveAddress(ctx, country, user.Address)
 	if err != nil {
 		return nil, err
 	}
-	err = documentValidation(user.DocumentNumber)
+
+	passwordHash, err := hashPassword(user.Password)
 	if err != nil {
 		return nil, err
 	}
-	err = emailValidation(user.Email)
+	user.Password = ""
+
+	newUser, err := s.UserRepository.CreateUser(ctx, user, passwordHash)
 	if err != nil {
 		return nil, err
 	}
-	err = phoneValidation(user.Phone)
+	return newUser, nil
+
+}
+
+func (s *service) GetUsers(ctx context.Context, page pagination.Page) ([]User, error) {
+	users, err := s.UserRepository.GetUsers(ctx, page)
 	if err != nil {
 		return nil, err
 	}
-	err = zipCodeValidation(user.Address.ZipCode)
+	return users, nil
+}
+func (s *service) GetUserByID(ctx context.Context, idUser int) (*User, error) {
+	users, err := s.UserRepository.GetUserByID(ctx, idUser)
 	if err != nil {
 		return nil, err
 	}
-	err = countryValidation(user.Address.Country)
+	return users, nil
+}
+
+func (s *service) GetProfile(ctx context.Context, idUser int) (*Profile, error) {
+	user, err := s.UserRepository.GetUserByID(ctx, idUser)
 	if err != nil {
 		return nil, err
 	}
-	err = numberValidation(user.Address.Number)
+	followers, following, err := s.UserRepository.CountConnections(ctx, idUser)
 	if err != nil {
 		return nil, err
 	}
+	return &Profile{User: *user, Followers: followers, Following: following}, nil
+}
 
-	addressUser, err := s.UserFacade.FindCep(user.Address.ZipCode, user.Address.Number, user.Address.Complement)
+func (s *service) GetUserByEmail(ctx context.Context, emailUser string) (*User, error) {
+	users, err := s.UserRepository.GetUserByEmail(ctx, normalizeEmail(emailUser))
 	if err != nil {
 		return nil, err
 	}
-	user.Address = *addressUser
+	return users, nil
+}
 
-	newUser, err := s.UserRepository.CreateUser(user)
+func (s *service) Authenticate(ctx context.Context, emailUser string, password string) (*auth.Identity, error) {
+	credentials, err := s.UserRepository.GetCredentials(ctx, normalizeEmail(emailUser))
 	if err != nil {
 		return nil, err
 	}
-	return newUser, nil
-
+	if credentials == nil || credentials.PasswordHash == "" {
+		return nil, auth.ErrInvalidCredentials
+	}
+	err = checkPassword(credentials.PasswordHash, password)
+	if err != nil {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return &auth.Identity{ID: credentials.ID, Role: credentials.Role}, nil
 }
 
-func (s *service) GetUsers() ([]User, error) {
-	users, err := s.UserRepository.GetUsers()
+func (s *service) GetIdentity(ctx context.Context, idUser int) (*auth.Identity, error) {
+	role, err := s.UserRepository.GetRole(ctx, idUser)
 	if err != nil {
 		return nil, err
 	}
-	return users, nil
+	if role == "" {
+		return nil, nil
+	}
+	return &auth.Identity{ID: idUser, Role: role}, nil
 }
-func (s *service) GetUserByID(idUser int) (*User, error) {
-	users, err := s.UserRepository.GetUserByID(idUser)
+
+func (s *service) UpdateUser(ctx context.Context, user User, idUser int, actor auth.Identity) (*User, error) {
+	err := s.authorize(actor, auth.ActionEdit, idUser)
 	if err != nil {
 		return nil, err
 	}
-	return users, nil
+	return s.update(ctx, user, idUser)
 }
 
-func (s *service) GetUserByEmail(emailUser string) (*User, error) {
-	users, err := s.UserRepository.GetUserByEmail(emailUser)
+// PatchUser applies a JSON Merge Patch to the stored user, the result is validated like a full update.
+func (s *service) PatchUser(ctx context.Context, patch []byte, idUser int, actor auth.Identity) (*User, error) {
+	err := s.authorize(actor, auth.ActionEdit, idUser)
 	if err != nil {
 		return nil, err
 	}
-	return users, nil
+	user, err := s.UserRepository.GetUserByID(ctx, idUser)
+	if err != nil {
+		return nil, err
+	}
+	err = mergepatch.Apply(user, patch)
+	if err != nil {
+		return nil, err
+	}
+	user.ID = idUser
+	return s.update(ctx, *user, idUser)
 }
 
-func (s *service) UpdateUser(user User, idUser int) (*User, error) {
-	addressUser, err := s.UserFacade.FindCep(user.Address.ZipCode, user.Address.Number, user.Address.Complement)
+func (s *service) update(ctx context.Context, user User, idUser int) (*User, error) {
+	user.Email = normalizeEmail(user.Email)
+	country, err := userValidation(user, false)
+	if err != nil {
+		return nil, err
+	}
+	user.Address, err = s.resolveAddress(ctx, country, user.Address)
 	if err != nil {
 		return nil, err
 	}
-	user.Address = *addressUser
-	users, err := s.UserRepository.UpdateUser(user, idUser)
+	user.Password = ""
+	users, err := s.UserRepository.UpdateUser(ctx, user, idUser)
 	if err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-func (s *service) DeleteUser(idUser int) error {
-	err := s.UserRepository.DeleteUser(idUser)
+func (s *service) DeleteUser(ctx context.Context, idUser int, actor auth.Identity) error {
+	err := s.authorize(actor, auth.ActionDelete, idUser)
 	if err != nil {
 		return err
 	}
-
-	return nil
+	return s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		if s.Deletion.Restricted() {
+			hasContent, err := s.UserRepository.HasContent(ctx, idUser)
+			if err != nil {
+				return err
+			}
+			if hasContent {
+				return apperror.Conflict("the user still has posts or comments")
+			}
+		}
+		return s.UserRepository.DeleteUser(ctx, idUser, time.Now().UTC())
+	})
 }
 
-func (s *service) FollowUser(idFollower int, idFollowing int) error {
-	if idFollower == idFollowing {
-		return errors.New("the id cannot follow itself")
-	}
-	accountFollower, err := s.UserRepository.GetUserByID(idFollower)
+// RestoreUser brings back a deleted user and what was deleted with them, until the grace period is over.
+// It is the one route open to deleted accounts, so an actor restoring another account must be active.
+func (s *service) RestoreUser(ctx context.Context, idUser int, actor auth.Identity) (*User, error) {
+	err := s.authorize(actor, auth.ActionRestore, idUser)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if accountFollower == nil {
-		return errors.New("id has no account")
+	if actor.ID != idUser {
+		active, err := s.GetIdentity(ctx, actor.ID)
+		if err != nil {
+			return nil, err
+		}
+		if active == nil {
+			return nil, auth.ErrDeletedAccount
+		}
 	}
-	accountFollowing, err := s.UserRepository.GetUserByID(idFollowing)
+	var user *User
+	err = s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		deletedAt, err := s.UserRepository.GetDeletedAt(ctx, idUser)
+		if err != nil {
+			return err
+		}
+		if !s.Deletion.Restorable(deletedAt) {
+			return apperror.Gone("the user can no longer be restored")
+		}
+		err = s.UserRepository.RestoreUser(ctx, idUser)
+		if err != nil {
+			return err
+		}
+		user, err = s.UserRepository.GetUserByID(ctx, idUser)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *service) FollowUser(ctx context.Context, idFollower int, idFollowing int, actor auth.Identity) error {
+	err := s.authorize(actor, auth.ActionEdit, idFollower)
 	if err != nil {
 		return err
 	}
-	if accountFollowing == nil {
-		return errors.New("id cannot follow user with no account")
+	if idFollower == idFollowing {
+		return apperror.Validation("the id cannot follow itself")
 	}
-
-	followers, err := s.UserRepository.GetFollowingByUserID(idFollower)
-	for i := 0; i < len(followers); i++ {
-		if idFollowing == followers[i].ID {
-			return errors.New("the id cannot follow user more than once")
+	err = s.UnitOfWork.Transact(ctx, func(ctx context.Context) error {
+		_, err := s.UserRepository.GetUserByID(ctx, idFollower)
+		if err != nil {
+			return err
 		}
-	}
-
-	//time.Sleep(2 * time.Second)
-	err = s.UserRepository.FollowUser(idFollower, idFollowing)
+		_, err = s.UserRepository.GetUserByID(ctx, idFollowing)
+		if err != nil {
+			return err
+		}
+		return s.UserRepository.FollowUser(ctx, idFollower, idFollowing)
+	})
 	if err != nil {
 		return err
 	}
+	s.suggestions.invalidate(idFollower)
 	return nil
 }
 
-func (s *service) DeleteConnection(idFollower int, idFollowing int) error {
-	err := s.UserRepository.DeleteConnection(idFollower, idFollowing)
+func (s *service) DeleteConnection(ctx context.Context, idFollower int, idFollowing int, actor auth.Identity) error {
+	err := s.authorize(actor, auth.ActionEdit, idFollower)
 	if err != nil {
 		return err
 	}
+	err = s.UserRepository.DeleteConnection(ctx, idFollower, idFollowing)
+	if err != nil {
+		return err
+	}
+	s.suggestions.invalidate(idFollower)
 	return nil
 }
-func (s *service) GetFollowingByUserID(idUser int) ([]User, error) {
-	users, err := s.UserRepository.GetFollowingByUserID(idUser)
+func (s *service) GetFollowingByUserID(ctx context.Context, idUser int, page pagination.Page) ([]User, error) {
+	users, err := s.UserRepository.GetFollowingByUserID(ctx, idUser, page)
 	if err != nil {
 		return nil, err
 	}
 	return users, nil
 }
-func (s *service) GetUserFollowers(idUser int) ([]User, error) {
-	users, err := s.UserRepository.GetUserFollowers(idUser)
+func (s *service) GetUserFollowers(ctx context.Context, idUser int, page pagination.Page) ([]User, error) {
+	users, err := s.UserRepository.GetUserFollowers(ctx, idUser, page)
 	if err != nil {
 		return nil, err
 	}
 	return users, nil
 }
+func (s *service) GetMutuals(ctx context.Context, idUser int, page pagination.Page) ([]User, error) {
+	users, err := s.UserRepository.GetMutuals(ctx, idUser, page)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// IsFollowing tells whether idFollower follows idFollowing, both users must exist.
+func (s *service) IsFollowing(ctx context.Context, idFollower int, idFollowing int) (bool, error) {
+	_, err := s.UserRepository.GetUserByID(ctx, idFollower)
+	if err != nil {
+		return false, err
+	}
+	_, err = s.UserRepository.GetUserByID(ctx, idFollowing)
+	if err != nil {
+		return false, err
+	}
+	return s.UserRepository.IsFollowing(ctx, idFollower, idFollowing)
+}
+
+// GetSuggestions ranks who idUser could follow. They are cached until idUser follows or unfollows someone,
+// the changes in the connections of others only show once the cache expires.
+func (s *service) GetSuggestions(ctx context.Context, idUser int, actor auth.Identity) ([]Suggestion, error) {
+	err := s.authorize(actor, auth.ActionView, idUser)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.UserRepository.GetUserByID(ctx, idUser)
+	if err != nil {
+		return nil, err
+	}
+	if suggestions, ok := s.suggestions.get(idUser); ok {
+		return suggestions, nil
+	}
+	suggestions, err := s.UserRepository.GetSuggestions(ctx, idUser, s.Suggestions.Limit)
+	if err != nil {
+		return nil, err
+	}
+	s.suggestions.put(idUser, suggestions)
+	return suggestions, nil
+}
+
+// authorize checks that the actor owns the account, only the owner may change it.
+func (s *service) authorize(actor auth.Identity, action string, idUser int) error {
+	return s.UserPolicy.Authorize(actor, action, auth.Resource{Kind: auth.ResourceUser, ID: idUser, IDOwner: idUser})
+}
+
+// resolveAddress looks the postal code up in the countries that support it, the others keep the address
+// the user typed in.
+func (s *service) resolveAddress(ctx context.Context, country Country, address Address) (Address, error) {
+	if !country.LookupAddress {
+		address.Country = country.Name
+		return address, nil
+	}
+	found, err := s.UserFacade.FindCep(ctx, address.ZipCode, address.Number, address.Complement)
+	if err != nil {
+		return Address{}, err
+	}
+	return *found, nil
+}
 
-func NewService(userRepository Repository, userFacade Facade) Service {
-	return &service{userRepository, userFacade}
+func NewService(userRepository Repository, userFacade Facade, userPolicy auth.Policy, unitOfWork dialect.UnitOfWork,
+	deletionPolicy deletion.Policy, suggestions SuggestionOptions) Service {
+	return &service{userRepository, userFacade, userPolicy, unitOfWork, deletionPolicy, suggestions,
+		newSuggestionCache(suggestions.CacheSize, suggestions.CacheTTL)}
 }
@@ -1,10 +1,17 @@
 package user
 
 import (
+	"context"
 	"errors"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/stretchr/testify/mock"
+	"socialBuddy/internal/apperror"
+	"socialBuddy/internal/auth"
+	"socialBuddy/internal/deletion"
+	"socialBuddy/internal/dialect/dialecttest"
+	"socialBuddy/internal/pagination"
+	"time"
 )
 
 type mockRepository struct {
@@ -15,43 +22,70 @@ type mockFacade struct {
 	mock.Mock
 }
 
-func (m *mockFacade) FindCep(cepUser string, number string, complement string) (*Address, error) {
+func (m *mockFacade) FindCep(ctx context.Context, cepUser string, number string, complement string) (*Address, error) {
 	args := m.Called(cepUser, number, complement)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*Address), args.Error(1)
 }
-func (m *mockRepository) CreateUser(user User) (*User, error) {
-	args := m.Called(user)
+func (m *mockRepository) CreateUser(ctx context.Context, user User, passwordHash string) (*User, error) {
+	args := m.Called(user, passwordHash)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*User), args.Error(1)
 }
-func (m *mockRepository) GetUsers() ([]User, error) {
-	args := m.Called()
+func (m *mockRepository) GetUsers(ctx context.Context, page pagination.Page) ([]User, error) {
+	args := m.Called(page)
 	return args.Get(0).([]User), args.Error(1)
 }
-func (m *mockRepository) GetUserByID(idUser int) (*User, error) {
+func (m *mockRepository) GetUserByID(ctx context.Context, idUser int) (*User, error) {
 	args := m.Called(idUser)
 	return args.Get(0).(*User), args.Error(1)
 }
-func (m *mockRepository) GetUserByEmail(emailUser string) (*User, error) {
+func (m *mockRepository) GetUserByEmail(ctx context.Context, emailUser string) (*User, error) {
 	args := m.Called(emailUser)
 	return args.Get(0).(*User), args.Error(1)
 }
-func (m *mockRepository) UpdateUser(user User, idUser int) (*User, error) {
+func (m *mockRepository) GetCredentials(ctx context.Context, emailUser string) (*Credentials, error) {
+	args := m.Called(emailUser)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Credentials), args.Error(1)
+}
+func (m *mockRepository) GetRole(ctx context.Context, idUser int) (string, error) {
+	args := m.Called(idUser)
+	return args.String(0), args.Error(1)
+}
+func (m *mockRepository) UpdateUser(ctx context.Context, user User, idUser int) (*User, error) {
 	args := m.Called(user, idUser)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*User), args.Error(1)
 }
-func (m *mockRepository) DeleteUser(idUser int) error {
+func (m *mockRepository) DeleteUser(ctx context.Context, idUser int, deletedAt time.Time) error {
+	args := m.Called(idUser, deletedAt)
+	return args.Error(0)
+}
+func (m *mockRepository) GetDeletedAt(ctx context.Context, idUser int) (time.Time, error) {
+	args := m.Called(idUser)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+func (m *mockRepository) RestoreUser(ctx context.Context, idUser int) error {
 	args := m.Called(idUser)
 	return args.Error(0)
 }
+func (m *mockRepository) HasContent(ctx context.Context, idUser int) (bool, error) {
+	args := m.Called(idUser)
+	return args.Bool(0), args.Error(1)
+}
+func (m *mockRepository) PurgeUsers(ctx context.Context, before time.Time, restrict bool) (int64, error) {
+	args := m.Called(before, restrict)
+	return args.Get(0).(int64), args.Error(1)
+}
 func (m *mockRepository) DeleteALLFollowerConnections(idFollower int) error {
 	args := m.Called(idFollower)
 	return args.Error(0)
@@ -60,22 +94,42 @@ func (m *mockRepository) DeleteALLFollowingConnections(idFollowing int) error {
 	args := m.Called(idFollowing)
 	return args.Error(0)
 }
-func (m *mockRepository) FollowUser(idFollower int, idFollowing int) error {
+func (m *mockRepository) FollowUser(ctx context.Context, idFollower int, idFollowing int) error {
 	args := m.Called(idFollower, idFollowing)
 	return args.Error(0)
 }
-func (m *mockRepository) DeleteConnection(idFollower int, idFollowing int) error {
+func (m *mockRepository) DeleteConnection(ctx context.Context, idFollower int, idFollowing int) error {
 	args := m.Called(idFollower, idFollowing)
 	return args.Error(0)
 }
-func (m *mockRepository) GetFollowingByUserID(idUser int) ([]User, error) {
-	args := m.Called(idUser)
+func (m *mockRepository) IsFollowing(ctx context.Context, idFollower int, idFollowing int) (bool, error) {
+	args := m.Called(idFollower, idFollowing)
+	return args.Bool(0), args.Error(1)
+}
+func (m *mockRepository) GetFollowingByUserID(ctx context.Context, idUser int, page pagination.Page) ([]User, error) {
+	args := m.Called(idUser, page)
 	return args.Get(0).([]User), args.Error(1)
 }
-func (m *mockRepository) GetUserFollowers(idUser int) ([]User, error) {
-	args := m.Called(idUser)
+func (m *mockRepository) GetUserFollowers(ctx context.Context, idUser int, page pagination.Page) ([]User, error) {
+	args := m.Called(idUser, page)
 	return args.Get(0).([]User), args.Error(1)
 }
+func (m *mockRepository) GetMutuals(ctx context.Context, idUser int, page pagination.Page) ([]User, error) {
+	args := m.Called(idUser, page)
+	return args.Get(0).([]User), args.Error(1)
+}
+func (m *mockRepository) CountConnections(ctx context.Context, idUser int) (int, int, error) {
+	args := m.Called(idUser)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+func (m *mockRepository) GetSuggestions(ctx context.Context, idUser int, limit int) ([]Suggestion, error) {
+	args := m.Called(idUser, limit)
+	return args.Get(0).([]Suggestion), args.Error(1)
+}
+
+var deletions = deletion.Policy{Cascade: deletion.Cascade, GracePeriod: time.Hour}
+
+var suggestions = SuggestionOptions{Limit: 5, CacheSize: 10, CacheTTL: time.Minute}
 
 var _ = Describe("The Service Test", func() {
 	var (
@@ -91,7 +145,7 @@ var _ = Describe("The Service Test", func() {
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 92345 6789",
 			Address: Address{
@@ -103,11 +157,11 @@ var _ = Describe("The Service Test", func() {
 				Street:       "Avenida Salmão",
 				Number:       "456",
 				Complement:   "C"},
-		}).Return(&User{
+		}, mock.AnythingOfType("string")).Return(&User{
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 92345 6789",
 			Address: Address{
@@ -130,12 +184,12 @@ var _ = Describe("The Service Test", func() {
 			Number:       "456",
 			Complement:   "C",
 		}, nil)
-		newService := NewService(mockUserRepository, mockUserFacade)
-		user, err := newService.CreateUser(User{
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.CreateUser(context.Background(), User{
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 92345 6789",
 			Address: Address{
@@ -148,18 +202,147 @@ var _ = Describe("The Service Test", func() {
 				Number:       "456",
 				Complement:   "C",
 			},
+			Password: "secret123",
 		})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(user.ID).Should(Equal(1))
 		Expect(user.Name).Should(Equal("Name First"))
 	})
+	It("should CreateUser unsuccessfully with a registered email", func() {
+		mockUserRepository.On("CreateUser", mock.AnythingOfType("User"), mock.AnythingOfType("string")).Return(nil, apperror.Conflict("the email is already registered"))
+		mockUserFacade.On("FindCep", "12246-260", "456", "C").Return(&Address{ZipCode: "12246-260", Country: "Brasil", Number: "456", Complement: "C"}, nil)
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.CreateUser(context.Background(), User{
+			Name:           "Name First",
+			Age:            35,
+			DocumentNumber: "529.982.247-25",
+			Email:          "name.first@gmail.com",
+			Phone:          "+55 11 92345 6789",
+			Address:        Address{ZipCode: "12246-260", Country: "Brasil", Number: "456", Complement: "C"},
+			Password:       "secret123",
+		})
+		Expect(errors.Is(err, apperror.ErrConflict)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+	})
+	It("should CreateUser unsuccessfully with a registered email in another case", func() {
+		mockUserRepository.On("CreateUser", mock.MatchedBy(func(user User) bool {
+			return user.Email == "name.first@gmail.com"
+		}), mock.AnythingOfType("string")).Return(nil, apperror.Conflict("the email is already registered"))
+		mockUserFacade.On("FindCep", "12246-260", "456", "C").Return(&Address{ZipCode: "12246-260", Country: "Brasil", Number: "456", Complement: "C"}, nil)
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.CreateUser(context.Background(), User{
+			Name:           "Name First",
+			Age:            35,
+			DocumentNumber: "529.982.247-25",
+			Email:          " Name.FIRST@Gmail.com ",
+			Phone:          "+55 11 92345 6789",
+			Address:        Address{ZipCode: "12246-260", Country: "Brasil", Number: "456", Complement: "C"},
+			Password:       "secret123",
+		})
+		Expect(errors.Is(err, apperror.ErrConflict)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+	})
+	It("should CreateUser unsuccessfully with a CPF of repeated digits", func() {
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.CreateUser(context.Background(), User{
+			Name:           "Name First",
+			Age:            35,
+			DocumentNumber: "111.111.111-11",
+			Email:          "name.first@gmail.com",
+			Phone:          "+55 11 92345 6789",
+			Address:        Address{ZipCode: "12246-260", Country: "Brasil", Number: "456", Complement: "C"},
+			Password:       "secret123",
+		})
+		Expect(err).Should(MatchError("CPF is not valid"))
+		Expect(user).Should(BeNil())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "CreateUser", mock.Anything, mock.Anything)
+	})
+	It("should CreateUser abroad without looking the zip code up", func() {
+		abroad := User{
+			Name:           "Name First",
+			Age:            35,
+			DocumentNumber: "123456789",
+			Email:          "name.first@gmail.com",
+			Phone:          "+351 912 345 678",
+			Address: Address{
+				ZipCode: "1100-148",
+				Country: "portugal",
+				City:    "Lisboa",
+				Street:  "Rua Augusta",
+				Number:  "45",
+			},
+			Password: "secret123",
+		}
+		stored := abroad
+		stored.Address.Country = "Portugal"
+		stored.Password = ""
+		mockUserRepository.On("CreateUser", stored, mock.AnythingOfType("string")).Return(&stored, nil)
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.CreateUser(context.Background(), abroad)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(user.Address.Country).Should(Equal("Portugal"))
+		mockUserFacade.AssertNotCalled(GinkgoT(), "FindCep", mock.Anything, mock.Anything, mock.Anything)
+	})
+	It("should CreateUser abroad unsuccessfully without a street", func() {
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.CreateUser(context.Background(), User{
+			Name:           "Name First",
+			Age:            35,
+			DocumentNumber: "123456789",
+			Email:          "name.first@gmail.com",
+			Phone:          "+351 912 345 678",
+			Address:        Address{ZipCode: "1100-148", Country: "Portugal", City: "Lisboa", Number: "45"},
+			Password:       "secret123",
+		})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+	})
+	It("should not CreateUser reporting every invalid field", func() {
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.CreateUser(context.Background(), User{
+			Name:           "Name First",
+			Age:            12,
+			DocumentNumber: "529.982.247-25",
+			Email:          "name.first@gmail.com",
+			Phone:          "+55 11 92345 6789",
+			Address:        Address{ZipCode: "12246", Country: "Brasil", Number: "0"},
+			Password:       "short",
+		})
+		Expect(user).Should(BeNil())
+		var invalid *apperror.InvalidFields
+		Expect(errors.As(err, &invalid)).Should(BeTrue())
+		Expect(invalid.Fields).Should(Equal([]apperror.FieldError{
+			{Field: "age", Code: apperror.CodeOutOfRange, Message: "age is not valid"},
+			{Field: "address.zip_code", Code: apperror.CodeInvalid, Message: "the zip code is not valid"},
+			{Field: "address.number", Code: apperror.CodeInvalid, Message: "the number is not valid"},
+			{Field: "password", Code: apperror.CodeOutOfRange, Message: "the password must have between 8 and 72 characters"},
+		}))
+		mockUserRepository.AssertNotCalled(GinkgoT(), "CreateUser", mock.Anything, mock.Anything)
+	})
+	It("should not CreateUser checking the address of a country without lookup", func() {
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		_, err := newService.CreateUser(context.Background(), User{
+			Name:           "Name First",
+			Age:            35,
+			DocumentNumber: "123456789",
+			Email:          "name.first@gmail.com",
+			Phone:          "+351 912 345 678",
+			Address:        Address{ZipCode: "1100-148", Country: "Portugal", Number: "45"},
+			Password:       "secret123",
+		})
+		var invalid *apperror.InvalidFields
+		Expect(errors.As(err, &invalid)).Should(BeTrue())
+		Expect(invalid.Fields).Should(HaveLen(2))
+		Expect(invalid.Fields[0].Field).Should(Equal("address.city"))
+		Expect(invalid.Fields[1].Field).Should(Equal("address.street"))
+	})
 	It("should CreateUser unsuccessfully", func() {
 		mockUserFacade := new(mockFacade)
 		mockUserRepository.On("CreateUser", User{
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 92345 6789",
 			Address: Address{
@@ -172,14 +355,14 @@ var _ = Describe("The Service Test", func() {
 				Number:       "456",
 				Complement:   "C",
 			},
-		}).Return(nil, errors.New("error while CreateUser()"))
+		}, mock.AnythingOfType("string")).Return(nil, errors.New("error while CreateUser()"))
 		mockUserFacade.On("FindCep", "12246-260", "456", "C").Return(nil, errors.New("error while FindCep()"))
-		newService := NewService(mockUserRepository, mockUserFacade)
-		user, err := newService.CreateUser(User{
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.CreateUser(context.Background(), User{
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 92345 6789",
 			Address: Address{
@@ -192,16 +375,17 @@ var _ = Describe("The Service Test", func() {
 				Number:       "456",
 				Complement:   "C",
 			},
+			Password: "secret123",
 		})
 		Expect(err).Should(HaveOccurred())
 		Expect(user).Should(BeNil())
 	})
 	It("should GetUsers successfully", func() {
-		mockUserRepository.On("GetUsers").Return([]User{
+		mockUserRepository.On("GetUsers", pagination.Page{Limit: 10}).Return([]User{
 			{ID: 1,
 				Name:           "Name First",
 				Age:            35,
-				DocumentNumber: "123.345.567-89",
+				DocumentNumber: "529.982.247-25",
 				Email:          "name.first@gmail.com",
 				Phone:          "+55 11 12345 6789",
 				Address: Address{
@@ -215,16 +399,16 @@ var _ = Describe("The Service Test", func() {
 					Complement:   "C"},
 			},
 		}, nil)
-		newService := NewService(mockUserRepository, nil)
-		users, err := newService.GetUsers()
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		users, err := newService.GetUsers(context.Background(), pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(users[0].ID).Should(Equal(1))
 		Expect(users[0].Name).Should(Equal("Name First"))
 	})
 	It("should GetUsers unsuccessfully", func() {
-		mockUserRepository.On("GetUsers").Return([]User{}, errors.New("error while GetUsers()"))
-		newService := NewService(mockUserRepository, nil)
-		users, err := newService.GetUsers()
+		mockUserRepository.On("GetUsers", pagination.Page{Limit: 10}).Return([]User{}, errors.New("error while GetUsers()"))
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		users, err := newService.GetUsers(context.Background(), pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 		Expect(len(users)).Should(Equal(0))
 	})
@@ -233,7 +417,7 @@ var _ = Describe("The Service Test", func() {
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 12345 6789",
 			Address: Address{
@@ -247,16 +431,16 @@ var _ = Describe("The Service Test", func() {
 				Complement:   "C",
 			},
 		}, nil)
-		newService := NewService(mockUserRepository, nil)
-		user, err := newService.GetUserByID(1)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.GetUserByID(context.Background(), 1)
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(user.ID).Should(Equal(1))
 		Expect(user.Name).Should(Equal("Name First"))
 	})
 	It("should GetUserByID unsuccessfully", func() {
 		mockUserRepository.On("GetUserByID", 2).Return(&User{}, errors.New("error while GetUserByID()"))
-		newService := NewService(mockUserRepository, nil)
-		_, err := newService.GetUserByID(2)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		_, err := newService.GetUserByID(context.Background(), 2)
 		Expect(err).Should(HaveOccurred())
 	})
 	It("should GetUserByEmail successfully", func() {
@@ -264,7 +448,7 @@ var _ = Describe("The Service Test", func() {
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 12345 6789",
 			Address: Address{
@@ -278,16 +462,16 @@ var _ = Describe("The Service Test", func() {
 				Complement:   "C",
 			},
 		}, nil)
-		newService := NewService(mockUserRepository, nil)
-		user, err := newService.GetUserByEmail("name.first@gmail.com")
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.GetUserByEmail(context.Background(), "name.first@gmail.com")
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(user.ID).Should(Equal(1))
 		Expect(user.Name).Should(Equal("Name First"))
 	})
 	It("should GetUserByEmail unsuccessfully", func() {
 		mockUserRepository.On("GetUserByEmail", "name.1@gmail.com").Return(&User{}, errors.New("error while GetUserByEmail()"))
-		newService := NewService(mockUserRepository, nil)
-		_, err := newService.GetUserByEmail("name.1@gmail.com")
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		_, err := newService.GetUserByEmail(context.Background(), "name.1@gmail.com")
 		Expect(err).Should(HaveOccurred())
 	})
 	It("should UpdateUser successfully", func() {
@@ -295,7 +479,7 @@ var _ = Describe("The Service Test", func() {
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 92345 6789",
 			Address: Address{
@@ -311,7 +495,7 @@ var _ = Describe("The Service Test", func() {
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 92345 6789",
 			Address: Address{
@@ -334,12 +518,12 @@ var _ = Describe("The Service Test", func() {
 			Number:       "456",
 			Complement:   "C",
 		}, nil)
-		newService := NewService(mockUserRepository, mockUserFacade)
-		user, err := newService.UpdateUser(User{
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.UpdateUser(context.Background(), User{
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 92345 6789",
 			Address: Address{
@@ -352,7 +536,7 @@ var _ = Describe("The Service Test", func() {
 				Number:       "456",
 				Complement:   "C",
 			},
-		}, 1)
+		}, 1, auth.Identity{ID: 1})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(user.ID).Should(Equal(1))
 		Expect(user.Name).Should(Equal("Name First"))
@@ -363,7 +547,7 @@ var _ = Describe("The Service Test", func() {
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 92345 6789",
 			Address: Address{
@@ -378,12 +562,12 @@ var _ = Describe("The Service Test", func() {
 			},
 		}, 1).Return(nil, errors.New("error while UpdateUser()"))
 		mockUserFacade.On("FindCep", "12246-260", "456", "C").Return(nil, errors.New("error while FindCep()"))
-		newService := NewService(mockUserRepository, mockUserFacade)
-		user, err := newService.UpdateUser(User{
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.UpdateUser(context.Background(), User{
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 92345 6789",
 			Address: Address{
@@ -396,24 +580,99 @@ var _ = Describe("The Service Test", func() {
 				Number:       "456",
 				Complement:   "C",
 			},
-		}, 1)
+		}, 1, auth.Identity{ID: 1})
 		Expect(err).Should(HaveOccurred())
 		Expect(user).Should(BeNil())
 	})
+	It("should PatchUser successfully", func() {
+		stored := User{
+			ID:             1,
+			Name:           "Name First",
+			Age:            35,
+			DocumentNumber: "529.982.247-25",
+			Email:          "name.first@gmail.com",
+			Phone:          "+55 11 92345 6789",
+			Address: Address{
+				ZipCode:      "12246-260",
+				Country:      "Brasil",
+				State:        "SP",
+				City:         "São José dos Campos",
+				Neighborhood: "Parque Residencial Aquarius",
+				Street:       "Avenida Salmão",
+				Number:       "456",
+				Complement:   "C"},
+		}
+		patched := stored
+		patched.Name = "Name Patched"
+		patched.Address.Complement = ""
+		mockUserRepository.On("GetUserByID", 1).Return(&stored, nil)
+		mockUserFacade.On("FindCep", "12246-260", "456", "").Return(&patched.Address, nil)
+		mockUserRepository.On("UpdateUser", patched, 1).Return(&patched, nil)
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.PatchUser(context.Background(), []byte(`{"ID":7,"name":"Name Patched","address":{"complement":null}}`), 1, auth.Identity{ID: 1})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(user.ID).Should(Equal(1))
+		Expect(user.Name).Should(Equal("Name Patched"))
+		Expect(user.Email).Should(Equal("name.first@gmail.com"))
+	})
+	It("should not PatchUser with an invalid email", func() {
+		mockUserRepository.On("GetUserByID", 1).Return(&User{
+			ID:             1,
+			Name:           "Name First",
+			Age:            35,
+			DocumentNumber: "529.982.247-25",
+			Email:          "name.first@gmail.com",
+			Phone:          "+55 11 92345 6789",
+			Address:        Address{ZipCode: "12246-260", Country: "Brasil", Number: "456"},
+		}, nil)
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.PatchUser(context.Background(), []byte(`{"email":"name.first"}`), 1, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "UpdateUser", mock.Anything, mock.Anything)
+	})
+	It("should not PatchUser with a patch that is not an object", func() {
+		mockUserRepository.On("GetUserByID", 1).Return(&User{ID: 1}, nil)
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.PatchUser(context.Background(), []byte(`["name"]`), 1, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+	})
+	It("should not PatchUser of another user", func() {
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.PatchUser(context.Background(), []byte(`{"name":"Name Patched"}`), 1, auth.Identity{ID: 2})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "GetUserByID", mock.Anything)
+	})
+	It("should not UpdateUser with an invalid age", func() {
+		newService := NewService(mockUserRepository, mockUserFacade, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.UpdateUser(context.Background(), User{
+			Name:           "Name First",
+			Age:            -1,
+			DocumentNumber: "529.982.247-25",
+			Email:          "name.first@gmail.com",
+			Phone:          "+55 11 92345 6789",
+			Address:        Address{ZipCode: "12246-260", Country: "Brasil", Number: "456"},
+		}, 1, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "UpdateUser", mock.Anything, mock.Anything)
+	})
 	It("should DeleteUser successfully", func() {
-		mockUserRepository.On("DeleteUser", 1).Return(nil)
+		mockUserRepository.On("DeleteUser", 1, mock.AnythingOfType("time.Time")).Return(nil)
 		mockUserRepository.On("DeleteALLFollowerConnections", 1).Return(nil)
 		mockUserRepository.On("DeleteALLFollowingConnections", 1).Return(nil)
-		newService := NewService(mockUserRepository, nil)
-		err := newService.DeleteUser(1)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		err := newService.DeleteUser(context.Background(), 1, auth.Identity{ID: 1})
 		Expect(err).ShouldNot(HaveOccurred())
 	})
 	It("should DeleteUser unsuccessfully", func() {
-		mockUserRepository.On("DeleteUser", 1).Return(errors.New("error while DeleteUser()"))
+		mockUserRepository.On("DeleteUser", 1, mock.AnythingOfType("time.Time")).Return(errors.New("error while DeleteUser()"))
 		mockUserRepository.On("DeleteALLFollowerConnections", 1).Return(errors.New("error while DeleteALLFollowerConnections()"))
 		mockUserRepository.On("DeleteALLFollowingConnections", 1).Return(errors.New("error while DeleteALLFollowingConnections()"))
-		newService := NewService(mockUserRepository, nil)
-		err := newService.DeleteUser(1)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		err := newService.DeleteUser(context.Background(), 1, auth.Identity{ID: 1})
 		Expect(err).Should(HaveOccurred())
 	})
 	It("should FollowUser successfully", func() {
@@ -421,7 +680,7 @@ var _ = Describe("The Service Test", func() {
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 12345 6789",
 			Address: Address{
@@ -439,7 +698,7 @@ var _ = Describe("The Service Test", func() {
 			ID:             1,
 			Name:           "Name First",
 			Age:            35,
-			DocumentNumber: "123.345.567-89",
+			DocumentNumber: "529.982.247-25",
 			Email:          "name.first@gmail.com",
 			Phone:          "+55 11 12345 6789",
 			Address: Address{
@@ -453,56 +712,37 @@ var _ = Describe("The Service Test", func() {
 				Complement:   "C",
 			},
 		}, nil)
-		mockUserRepository.On("GetFollowingByUserID", 1).Return([]User{
-			{ID: 1,
-				Name:           "Name First",
-				Age:            35,
-				DocumentNumber: "123.345.567-89",
-				Email:          "name.first@gmail.com",
-				Phone:          "+55 11 12345 6789",
-				Address: Address{
-					ZipCode:      "12246-260",
-					Country:      "Brasil",
-					State:        "SP",
-					City:         "São José dos Campos",
-					Neighborhood: "Parque Residencial Aquarius",
-					Street:       "Avenida Salmão",
-					Number:       "456",
-					Complement:   "C"},
-			},
-		}, nil)
 		mockUserRepository.On("FollowUser", 1, 2).Return(nil)
-		newService := NewService(mockUserRepository, nil)
-		err := newService.FollowUser(1, 2)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		err := newService.FollowUser(context.Background(), 1, 2, auth.Identity{ID: 1})
 		Expect(err).ShouldNot(HaveOccurred())
 	})
 	It("should FollowUser unsuccessfully", func() {
 		mockUserRepository.On("GetUserByID", 1).Return(&User{}, errors.New("error while GetUserByID(follower)"))
 		mockUserRepository.On("GetUserByID", 2).Return(&User{}, errors.New("error while GetUserByID(following)"))
-		mockUserRepository.On("GetFollowingByUserID", 1).Return([]User{}, errors.New("error while GetFollowingByUserID()"))
 		mockUserRepository.On("FollowUser", 1, 2).Return(errors.New("error while FollowUser()"))
-		newService := NewService(mockUserRepository, nil)
-		err := newService.FollowUser(1, 2)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		err := newService.FollowUser(context.Background(), 1, 2, auth.Identity{ID: 1})
 		Expect(err).Should(HaveOccurred())
 	})
 	It("should DeleteConnection successfully", func() {
 		mockUserRepository.On("DeleteConnection", 1, 2).Return(nil)
-		newService := NewService(mockUserRepository, nil)
-		err := newService.DeleteConnection(1, 2)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		err := newService.DeleteConnection(context.Background(), 1, 2, auth.Identity{ID: 1})
 		Expect(err).ShouldNot(HaveOccurred())
 	})
 	It("should DeleteConnection unsuccessfully", func() {
 		mockUserRepository.On("DeleteConnection", 1, 2).Return(errors.New("error while DeleteConnection()"))
-		newService := NewService(mockUserRepository, nil)
-		err := newService.DeleteConnection(1, 2)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		err := newService.DeleteConnection(context.Background(), 1, 2, auth.Identity{ID: 1})
 		Expect(err).Should(HaveOccurred())
 	})
 	It("should GetFollowingByUserID successfully", func() {
-		mockUserRepository.On("GetFollowingByUserID", 2).Return([]User{
+		mockUserRepository.On("GetFollowingByUserID", 2, pagination.Page{Limit: 10}).Return([]User{
 			{ID: 1,
 				Name:           "Name First",
 				Age:            35,
-				DocumentNumber: "123.345.567-89",
+				DocumentNumber: "529.982.247-25",
 				Email:          "name.first@gmail.com",
 				Phone:          "+55 11 12345 6789",
 				Address: Address{
@@ -516,25 +756,25 @@ var _ = Describe("The Service Test", func() {
 					Complement:   "C"},
 			},
 		}, nil)
-		newService := NewService(mockUserRepository, nil)
-		users, err := newService.GetFollowingByUserID(2)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		users, err := newService.GetFollowingByUserID(context.Background(), 2, pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(users[0].ID).Should(Equal(1))
 		Expect(users[0].Name).Should(Equal("Name First"))
 	})
 	It("should GetFollowingByUserID unsuccessfully", func() {
-		mockUserRepository.On("GetFollowingByUserID", 2).Return([]User{}, errors.New("error while GetFollowingByUserID()"))
-		newService := NewService(mockUserRepository, nil)
-		users, err := newService.GetFollowingByUserID(2)
+		mockUserRepository.On("GetFollowingByUserID", 2, pagination.Page{Limit: 10}).Return([]User{}, errors.New("error while GetFollowingByUserID()"))
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		users, err := newService.GetFollowingByUserID(context.Background(), 2, pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 		Expect(len(users)).Should(Equal(0))
 	})
 	It("should GetUserFollowers successfully", func() {
-		mockUserRepository.On("GetUserFollowers", 2).Return([]User{
+		mockUserRepository.On("GetUserFollowers", 2, pagination.Page{Limit: 10}).Return([]User{
 			{ID: 1,
 				Name:           "Name First",
 				Age:            35,
-				DocumentNumber: "123.345.567-89",
+				DocumentNumber: "529.982.247-25",
 				Email:          "name.first@gmail.com",
 				Phone:          "+55 11 12345 6789",
 				Address: Address{
@@ -548,17 +788,221 @@ var _ = Describe("The Service Test", func() {
 					Complement:   "C"},
 			},
 		}, nil)
-		newService := NewService(mockUserRepository, nil)
-		users, err := newService.GetUserFollowers(2)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		users, err := newService.GetUserFollowers(context.Background(), 2, pagination.Page{Limit: 10})
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(users[0].ID).Should(Equal(1))
 		Expect(users[0].Name).Should(Equal("Name First"))
 	})
 	It("should GetUserFollowers unsuccessfully", func() {
-		mockUserRepository.On("GetUserFollowers", 2).Return([]User{}, errors.New("error while GetUserFollowers()"))
-		newService := NewService(mockUserRepository, nil)
-		users, err := newService.GetUserFollowers(2)
+		mockUserRepository.On("GetUserFollowers", 2, pagination.Page{Limit: 10}).Return([]User{}, errors.New("error while GetUserFollowers()"))
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		users, err := newService.GetUserFollowers(context.Background(), 2, pagination.Page{Limit: 10})
 		Expect(err).Should(HaveOccurred())
 		Expect(len(users)).Should(Equal(0))
 	})
+	It("should Authenticate successfully", func() {
+		passwordHash, err := hashPassword("secret123")
+		Expect(err).ShouldNot(HaveOccurred())
+		mockUserRepository.On("GetCredentials", "name.first@gmail.com").Return(&Credentials{ID: 1, PasswordHash: passwordHash, Role: auth.RoleUser}, nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		identity, err := newService.Authenticate(context.Background(), "name.first@gmail.com", "secret123")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(*identity).Should(Equal(auth.Identity{ID: 1, Role: auth.RoleUser}))
+	})
+	It("should Authenticate unsuccessfully with a wrong password", func() {
+		passwordHash, err := hashPassword("secret123")
+		Expect(err).ShouldNot(HaveOccurred())
+		mockUserRepository.On("GetCredentials", "name.first@gmail.com").Return(&Credentials{ID: 1, PasswordHash: passwordHash}, nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.Authenticate(context.Background(), "name.first@gmail.com", "wrong-password")
+		Expect(err).Should(MatchError(auth.ErrInvalidCredentials))
+		Expect(user).Should(BeNil())
+	})
+	It("should Authenticate unsuccessfully with an unknown email", func() {
+		mockUserRepository.On("GetCredentials", "nobody@gmail.com").Return(nil, nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.Authenticate(context.Background(), "nobody@gmail.com", "secret123")
+		Expect(err).Should(MatchError(auth.ErrInvalidCredentials))
+		Expect(user).Should(BeNil())
+	})
+	It("should GetIdentity successfully", func() {
+		mockUserRepository.On("GetRole", 1).Return(auth.RoleAdmin, nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		identity, err := newService.GetIdentity(context.Background(), 1)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(*identity).Should(Equal(auth.Identity{ID: 1, Role: auth.RoleAdmin}))
+	})
+	It("should GetIdentity of an unknown user", func() {
+		mockUserRepository.On("GetRole", 9).Return("", nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		identity, err := newService.GetIdentity(context.Background(), 9)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(identity).Should(BeNil())
+	})
+	It("should not UpdateUser of another account", func() {
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.UpdateUser(context.Background(), User{Name: "Name First"}, 1, auth.Identity{ID: 2, Role: auth.RoleAdmin})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "UpdateUser", mock.Anything, mock.Anything)
+	})
+	It("should not DeleteUser of another account", func() {
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		err := newService.DeleteUser(context.Background(), 1, auth.Identity{ID: 2})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "DeleteUser", mock.Anything, mock.Anything)
+	})
+	It("should not DeleteUser with posts when the policy restricts it", func() {
+		mockUserRepository.On("HasContent", 1).Return(true, nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletion.Policy{Cascade: deletion.Restrict}, suggestions)
+		err := newService.DeleteUser(context.Background(), 1, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrConflict)).Should(BeTrue())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "DeleteUser", mock.Anything, mock.Anything)
+	})
+	It("should RestoreUser successfully", func() {
+		mockUserRepository.On("GetRole", 2).Return(auth.RoleAdmin, nil)
+		mockUserRepository.On("GetDeletedAt", 1).Return(time.Now().Add(-time.Minute), nil)
+		mockUserRepository.On("RestoreUser", 1).Return(nil)
+		mockUserRepository.On("GetUserByID", 1).Return(&User{ID: 1, Name: "Name First"}, nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.RestoreUser(context.Background(), 1, auth.Identity{ID: 2, Role: auth.RoleAdmin})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(user).Should(Equal(&User{ID: 1, Name: "Name First"}))
+	})
+	It("should RestoreUser of the deleted account itself", func() {
+		mockUserRepository.On("GetDeletedAt", 1).Return(time.Now().Add(-time.Minute), nil)
+		mockUserRepository.On("RestoreUser", 1).Return(nil)
+		mockUserRepository.On("GetUserByID", 1).Return(&User{ID: 1, Name: "Name First"}, nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.RestoreUser(context.Background(), 1, auth.Identity{ID: 1, Role: auth.RoleUser})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(user).Should(Equal(&User{ID: 1, Name: "Name First"}))
+		mockUserRepository.AssertNotCalled(GinkgoT(), "GetRole", mock.Anything)
+	})
+	It("should not RestoreUser by a deleted admin", func() {
+		mockUserRepository.On("GetRole", 2).Return("", nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.RestoreUser(context.Background(), 1, auth.Identity{ID: 2, Role: auth.RoleAdmin})
+		Expect(errors.Is(err, apperror.ErrUnauthorized)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "RestoreUser", mock.Anything)
+	})
+	It("should not RestoreUser after the grace period", func() {
+		mockUserRepository.On("GetDeletedAt", 1).Return(time.Now().Add(-2*time.Hour), nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.RestoreUser(context.Background(), 1, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrGone)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "RestoreUser", mock.Anything)
+	})
+	It("should not RestoreUser of another account", func() {
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		user, err := newService.RestoreUser(context.Background(), 1, auth.Identity{ID: 2})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+		Expect(user).Should(BeNil())
+	})
+	It("should not FollowUser on behalf of another account", func() {
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		err := newService.FollowUser(context.Background(), 1, 3, auth.Identity{ID: 2})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+	})
+	It("should FollowUser unsuccessfully when already following", func() {
+		mockUserRepository.On("GetUserByID", 1).Return(&User{ID: 1}, nil)
+		mockUserRepository.On("GetUserByID", 2).Return(&User{ID: 2}, nil)
+		mockUserRepository.On("FollowUser", 1, 2).Return(apperror.Conflict("the id cannot follow user more than once"))
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		err := newService.FollowUser(context.Background(), 1, 2, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrConflict)).Should(BeTrue())
+	})
+	It("should GetProfile with the follow counts", func() {
+		mockUserRepository.On("GetUserByID", 1).Return(&User{ID: 1, Name: "Name First"}, nil)
+		mockUserRepository.On("CountConnections", 1).Return(3, 2, nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		profile, err := newService.GetProfile(context.Background(), 1)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(profile.Name).Should(Equal("Name First"))
+		Expect(profile.Followers).Should(Equal(3))
+		Expect(profile.Following).Should(Equal(2))
+	})
+	It("should GetProfile unsuccessfully when the user doesn't exist", func() {
+		mockUserRepository.On("GetUserByID", 9).Return((*User)(nil), apperror.NotFound("the user is not in database"))
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		profile, err := newService.GetProfile(context.Background(), 9)
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+		Expect(profile).Should(BeNil())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "CountConnections", mock.Anything)
+	})
+	It("should GetMutuals successfully", func() {
+		mockUserRepository.On("GetMutuals", 1, pagination.Page{Limit: 10}).Return([]User{{ID: 2}}, nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		users, err := newService.GetMutuals(context.Background(), 1, pagination.Page{Limit: 10})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(users).Should(HaveLen(1))
+	})
+	It("should tell whether a user IsFollowing another", func() {
+		mockUserRepository.On("GetUserByID", 1).Return(&User{ID: 1}, nil)
+		mockUserRepository.On("GetUserByID", 2).Return(&User{ID: 2}, nil)
+		mockUserRepository.On("IsFollowing", 1, 2).Return(true, nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		isFollowing, err := newService.IsFollowing(context.Background(), 1, 2)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(isFollowing).Should(BeTrue())
+	})
+	It("should not check IsFollowing for a user that doesn't exist", func() {
+		mockUserRepository.On("GetUserByID", 1).Return(&User{ID: 1}, nil)
+		mockUserRepository.On("GetUserByID", 9).Return((*User)(nil), apperror.NotFound("the user is not in database"))
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		_, err := newService.IsFollowing(context.Background(), 1, 9)
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "IsFollowing", mock.Anything, mock.Anything)
+	})
+	It("should FollowUser unsuccessfully when following itself", func() {
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		err := newService.FollowUser(context.Background(), 1, 1, auth.Identity{ID: 1})
+		Expect(errors.Is(err, apperror.ErrValidation)).Should(BeTrue())
+	})
+	It("should GetSuggestions once and serve them from the cache", func() {
+		mockUserRepository.On("GetUserByID", 1).Return(&User{ID: 1}, nil)
+		mockUserRepository.On("GetSuggestions", 1, 5).Return([]Suggestion{{ID: 3, Mutuals: 2}}, nil).Once()
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		for i := 0; i < 2; i++ {
+			suggested, err := newService.GetSuggestions(context.Background(), 1, auth.Identity{ID: 1})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(suggested).Should(HaveLen(1))
+			Expect(suggested[0].Mutuals).Should(Equal(2))
+		}
+		mockUserRepository.AssertNumberOfCalls(GinkgoT(), "GetSuggestions", 1)
+	})
+	It("should GetSuggestions again after FollowUser", func() {
+		mockUserRepository.On("GetUserByID", 1).Return(&User{ID: 1}, nil)
+		mockUserRepository.On("GetUserByID", 3).Return(&User{ID: 3}, nil)
+		mockUserRepository.On("GetSuggestions", 1, 5).Return([]Suggestion{{ID: 3}}, nil).Once()
+		mockUserRepository.On("GetSuggestions", 1, 5).Return([]Suggestion{}, nil).Once()
+		mockUserRepository.On("FollowUser", 1, 3).Return(nil)
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		suggested, err := newService.GetSuggestions(context.Background(), 1, auth.Identity{ID: 1})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(suggested).Should(HaveLen(1))
+		err = newService.FollowUser(context.Background(), 1, 3, auth.Identity{ID: 1})
+		Expect(err).ShouldNot(HaveOccurred())
+		suggested, err = newService.GetSuggestions(context.Background(), 1, auth.Identity{ID: 1})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(suggested).Should(BeEmpty())
+	})
+	It("should not GetSuggestions of another account", func() {
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		suggested, err := newService.GetSuggestions(context.Background(), 1, auth.Identity{ID: 2})
+		Expect(errors.Is(err, apperror.ErrForbidden)).Should(BeTrue())
+		Expect(suggested).Should(BeNil())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "GetSuggestions", mock.Anything, mock.Anything)
+	})
+	It("should not GetSuggestions for a user that doesn't exist", func() {
+		mockUserRepository.On("GetUserByID", 9).Return((*User)(nil), apperror.NotFound("the user is not in database"))
+		newService := NewService(mockUserRepository, nil, auth.NewPolicy(), dialecttest.UnitOfWork{}, deletions, suggestions)
+		suggested, err := newService.GetSuggestions(context.Background(), 9, auth.Identity{ID: 9})
+		Expect(errors.Is(err, apperror.ErrNotFound)).Should(BeTrue())
+		Expect(suggested).Should(BeNil())
+		mockUserRepository.AssertNotCalled(GinkgoT(), "GetSuggestions", mock.Anything, mock.Anything)
+	})
 })
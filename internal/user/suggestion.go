@@ -0,0 +1,93 @@
+package user
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Suggestion is the public profile of a user that idUser does not follow yet, with the reasons it was
+// ranked for them. The document, email, phone and the rest of the address are left out.
+type Suggestion struct {
+	ID    int
+	Name  string `json:"name"`
+	State string `json:"state"`
+	City  string `json:"city"`
+	// Mutuals is how many of the users idUser follows already follow this one.
+	Mutuals   int  `json:"mutuals"`
+	SameCity  bool `json:"same_city"`
+	SameState bool `json:"same_state"`
+}
+
+type SuggestionOptions struct {
+	// Limit is how many suggestions are computed and cached for each user.
+	Limit     int
+	CacheSize int
+	CacheTTL  time.Duration
+}
+
+type suggestionEntry struct {
+	idUser      int
+	suggestions []Suggestion
+	cachedAt    time.Time
+}
+
+// suggestionCache keeps the last suggestions computed, the least recently used user is evicted when it is full.
+type suggestionCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	order   *list.List
+	entries map[int]*list.Element
+}
+
+func newSuggestionCache(size int, ttl time.Duration) *suggestionCache {
+	return &suggestionCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: map[int]*list.Element{},
+	}
+}
+
+func (c *suggestionCache) get(idUser int) ([]Suggestion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[idUser]
+	if !ok {
+		return nil, false
+	}
+	cached := element.Value.(*suggestionEntry)
+	if c.now().Sub(cached.cachedAt) >= c.ttl {
+		c.order.Remove(element)
+		delete(c.entries, idUser)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return cached.suggestions, true
+}
+
+func (c *suggestionCache) put(idUser int, suggestions []Suggestion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[idUser]; ok {
+		c.order.Remove(element)
+	}
+	c.entries[idUser] = c.order.PushFront(&suggestionEntry{idUser, suggestions, c.now()})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*suggestionEntry).idUser)
+	}
+}
+
+func (c *suggestionCache) invalidate(idUser int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[idUser]; ok {
+		c.order.Remove(element)
+		delete(c.entries, idUser)
+	}
+}
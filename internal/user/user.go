@@ -1,8 +1,10 @@
 package user
 
 import (
-	"errors"
+	"golang.org/x/crypto/bcrypt"
 	"regexp"
+	"socialBuddy/internal/apperror"
+	"strings"
 )
 
 type User struct {
@@ -13,49 +15,77 @@ type User struct {
 	Email          string  `json:"email"`
 	Phone          string  `json:"phone"`
 	Address        Address `json:"address"`
+	Password       string  `json:"password,omitempty"`
 }
 type Address struct {
 	ZipCode      string `json:"zip_code"`
 	Country      string `json:"country"`
-	State        string
-	City         string
-	Neighborhood string
-	Street       string
+	State        string `json:"state"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood"`
+	Street       string `json:"street"`
 	Number       string `json:"number"`
 	Complement   string `json:"complement"`
 }
 
+// Profile is a user as shown on their page, with the size of their follow graph.
+type Profile struct {
+	User
+	Followers int `json:"followers"`
+	Following int `json:"following"`
+}
+
+type Credentials struct {
+	ID           int
+	PasswordHash string
+	Role         string
+}
+
 type Connection struct {
 	ID          int
 	IdFollower  int
 	IdFollowing int
 }
 
+// userValidation runs every check of a user and reports all the failed ones together, the password
+// is only checked on creation. The checks that depend on the country are skipped when it is unknown.
+func userValidation(user User, withPassword bool) (Country, error) {
+	var fields apperror.Fields
+	fields.Check("name", apperror.CodeInvalid, nameValidation(user.Name))
+	fields.Check("age", apperror.CodeOutOfRange, ageValidation(user.Age))
+	fields.Check("email", apperror.CodeInvalid, emailValidation(user.Email))
+	country, err := countryValidation(user.Address.Country)
+	fields.Check("address.country", apperror.CodeInvalid, err)
+	if err == nil {
+		fields.Check("document_number", apperror.CodeInvalid, documentValidation(country, user.DocumentNumber))
+		fields.Check("phone", apperror.CodeInvalid, phoneValidation(country, user.Phone))
+		fields.Check("address.zip_code", apperror.CodeInvalid, zipCodeValidation(country, user.Address.ZipCode))
+		fields.Check("address.number", apperror.CodeInvalid, numberValidation(country, user.Address.Number))
+		if !country.LookupAddress {
+			fields.Check("address.city", apperror.CodeRequired, requiredValidation(user.Address.City, "the city is required"))
+			fields.Check("address.street", apperror.CodeRequired, requiredValidation(user.Address.Street, "the street is required"))
+		}
+	}
+	if withPassword {
+		fields.Check("password", apperror.CodeOutOfRange, passwordValidation(user.Password))
+	}
+	return country, fields.Err()
+}
+
 func nameValidation(name string) error {
 	isValid, err := regexp.MatchString("[A-Z][a-zA-Z]{2,} [A-Z][a-zA-Z ]+", name)
 	if err != nil {
 		return err
 	}
 	if !isValid {
-		return errors.New("name is not valid")
+		return apperror.Validation("name is not valid")
 	}
 	return nil
 }
 
 func ageValidation(age int) error {
 	if age < 18 || age > 100 {
-		return errors.New("age is not valid")
-	}
-	return nil
-}
-
-func documentValidation(documentNumber string) error {
-	isValid, err := regexp.MatchString("^\\d{3}\\.\\d{3}\\.\\d{3}-\\d{2}$", documentNumber)
-	if err != nil {
-		return err
-	}
-	if !isValid {
-		return errors.New("CPF is not valid")
+		return apperror.Validation("age is not valid")
 	}
 	return nil
 }
@@ -66,51 +96,31 @@ func emailValidation(email string) error {
 		return err
 	}
 	if !isValid {
-		return errors.New("email is not valid")
+		return apperror.Validation("email is not valid")
 	}
 	return nil
 }
 
-func phoneValidation(phone string) error {
-	isValid, err := regexp.MatchString("^\\+55 \\d{2} 9\\d{4} \\d{4}$", phone)
-	if err != nil {
-		return err
-	}
-	if !isValid {
-		return errors.New("the phone number is not valid")
-	}
-	return nil
+// normalizeEmail is how an email is stored and looked up, the unique index of Users compares it lowercased.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
-func zipCodeValidation(zipCode string) error {
-	isValid, err := regexp.MatchString("^[0-9]{5}-[0-9]{3}$", zipCode)
-	if err != nil {
-		return err
-	}
-	if !isValid {
-		return errors.New("the zip code is not valid")
+func passwordValidation(password string) error {
+	if len(password) < 8 || len(password) > 72 {
+		return apperror.Validation("the password must have between 8 and 72 characters")
 	}
 	return nil
 }
 
-func countryValidation(country string) error {
-	isValid, err := regexp.MatchString("^Brasil$", country)
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return err
+		return "", err
 	}
-	if !isValid {
-		return errors.New("the country is not valid")
-	}
-	return nil
+	return string(hash), nil
 }
 
-func numberValidation(number string) error {
-	isValid, err := regexp.MatchString("^[1-9]{1,4}$", number)
-	if err != nil {
-		return err
-	}
-	if !isValid {
-		return errors.New("the number is not valid")
-	}
-	return nil
+func checkPassword(passwordHash string, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password))
 }
@@ -14,6 +14,20 @@ type argsInt struct {
 	hasError bool
 }
 
+type argsCountry struct {
+	country  string
+	input    string
+	hasError bool
+}
+
+func mustCountry(t *testing.T, name string) Country {
+	country, ok := LookupCountry(name)
+	if !ok {
+		t.Fatalf("the country %s is not registered", name)
+	}
+	return country
+}
+
 func TestValidationName(t *testing.T) {
 	tests := []argsStr{
 		{
@@ -81,43 +95,86 @@ func TestValidationAge(t *testing.T) {
 }
 
 func TestValidationDocument(t *testing.T) {
-	tests := []argsStr{
+	tests := []argsCountry{
 		{
-			input:    "777.666.555-44",
+			country:  "Brasil",
+			input:    "529.982.247-25",
+			hasError: false,
+		},
+		{
+			country:  "Brasil",
+			input:    "529.982.247-52",
+			hasError: true,
+		},
+		{
+			country:  "Brasil",
+			input:    "111.111.111-11",
+			hasError: true,
+		},
+		{
+			country:  "Brasil",
+			input:    "11.222.333/0001-81",
 			hasError: false,
 		},
 		{
+			country:  "Brasil",
+			input:    "11.222.333/0001-18",
+			hasError: true,
+		},
+		{
+			country:  "Brasil",
 			input:    "555.888.100",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "1234.123.123-12",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "123.12.123-12",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "123.123.123-1",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "1.1.1-1",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "i22.1oo.222-11",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "",
 			hasError: true,
 		},
+		{
+			country:  "Portugal",
+			input:    "123456789",
+			hasError: false,
+		},
+		{
+			country:  "Portugal",
+			input:    "777.666.555-44",
+			hasError: true,
+		},
+		{
+			country:  "United States",
+			input:    "",
+			hasError: false,
+		},
 	}
 	var i int
 	for i = 0; i < len(tests); i++ {
-		actualError := documentValidation(tests[i].input)
+		actualError := documentValidation(mustCountry(t, tests[i].country), tests[i].input)
 		if (actualError == nil && tests[i].hasError == true) || (actualError != nil && tests[i].hasError == false) {
 			t.Fatalf("the test is failed %d: %v", i, actualError)
 		}
@@ -172,39 +229,81 @@ func TestValidationEmail(t *testing.T) {
 }
 
 func TestValidationPhone(t *testing.T) {
-	tests := []argsStr{
+	tests := []argsCountry{
 		{
+			country:  "Brasil",
 			input:    "+55 12 94321 1257",
 			hasError: false,
 		},
 		{
+			country:  "Brasil",
 			input:    "+55 12 77781 3456",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "+5512 97781 3456",
-			hasError: true,
+			hasError: false,
 		},
 		{
+			country:  "Brasil",
 			input:    "+55 11 94321 123",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "+55 11 961234 1234",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "55 11 94321 1234",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "",
 			hasError: true,
 		},
+		{
+			country:  "Brasil",
+			input:    "+55 (12) 94321-1257",
+			hasError: false,
+		},
+		{
+			country:  "Brasil",
+			input:    "+351 912 345 678",
+			hasError: true,
+		},
+		{
+			country:  "Portugal",
+			input:    "+351 912 345 678",
+			hasError: false,
+		},
+		{
+			country:  "United States",
+			input:    "+1 (415) 555-2671",
+			hasError: false,
+		},
+		{
+			country:  "United Kingdom",
+			input:    "+44 20 7946 0958",
+			hasError: false,
+		},
+		{
+			country:  "Germany",
+			input:    "+49 30 1234567",
+			hasError: false,
+		},
+		{
+			country:  "Germany",
+			input:    "+49 30 12a4567",
+			hasError: true,
+		},
 	}
 	var i int
 	for i = 0; i < len(tests); i++ {
-		actualError := phoneValidation(tests[i].input)
+		actualError := phoneValidation(mustCountry(t, tests[i].country), tests[i].input)
 		if (actualError == nil && tests[i].hasError == true) || (actualError != nil && tests[i].hasError == false) {
 			t.Fatalf("the test is failed %d: %v", i, actualError)
 		}
@@ -213,39 +312,71 @@ func TestValidationPhone(t *testing.T) {
 }
 
 func TestValidationZipCode(t *testing.T) {
-	tests := []argsStr{
+	tests := []argsCountry{
 		{
+			country:  "Brasil",
 			input:    "12245-890",
 			hasError: false,
 		},
 		{
+			country:  "Brasil",
 			input:    "112453-123",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "1234-890",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "12345-1234",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "12345-12",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "12345678",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
 			input:    "",
 			hasError: true,
 		},
+		{
+			country:  "Portugal",
+			input:    "1100-148",
+			hasError: false,
+		},
+		{
+			country:  "United States",
+			input:    "94103-1234",
+			hasError: false,
+		},
+		{
+			country:  "United Kingdom",
+			input:    "SW1A 1AA",
+			hasError: false,
+		},
+		{
+			country:  "Deutschland",
+			input:    "10115",
+			hasError: false,
+		},
+		{
+			country:  "Deutschland",
+			input:    "12245-890",
+			hasError: true,
+		},
 	}
 	var i int
 	for i = 0; i < len(tests); i++ {
-		actualError := zipCodeValidation(tests[i].input)
+		actualError := zipCodeValidation(mustCountry(t, tests[i].country), tests[i].input)
 		if (actualError == nil && tests[i].hasError == true) || (actualError != nil && tests[i].hasError == false) {
 			t.Fatalf("the test is failed %d: %v", i, actualError)
 		}
@@ -273,7 +404,15 @@ func TestValidationCountry(t *testing.T) {
 		},
 		{
 			input:    "Brazil",
-			hasError: true,
+			hasError: false,
+		},
+		{
+			input:    "portugal",
+			hasError: false,
+		},
+		{
+			input:    "US",
+			hasError: false,
 		},
 		{
 			input:    "",
@@ -282,7 +421,7 @@ func TestValidationCountry(t *testing.T) {
 	}
 	var i int
 	for i = 0; i < len(tests); i++ {
-		actualError := countryValidation(tests[i].input)
+		_, actualError := countryValidation(tests[i].input)
 		if (actualError == nil && tests[i].hasError == true) || (actualError != nil && tests[i].hasError == false) {
 			t.Fatalf("the test is failed %d: %v", i, actualError)
 		}
@@ -291,29 +430,106 @@ func TestValidationCountry(t *testing.T) {
 }
 
 func TestValidationNumber(t *testing.T) {
-	tests := []argsStr{
+	tests := []argsCountry{
 		{
+			country:  "Brasil",
 			input:    "234",
 			hasError: false,
 		},
 		{
-			input:    "12",
+			country:  "Brasil",
+			input:    "1",
 			hasError: false,
 		},
 		{
-			input:    "1",
+			country:  "Brasil",
+			input:    "9000",
 			hasError: false,
 		},
 		{
+			country:  "Brasil",
 			input:    "0",
 			hasError: true,
 		},
 		{
+			country:  "Brasil",
+			input:    "012",
+			hasError: true,
+		},
+		{
+			country:  "Brasil",
+			input:    "10000",
+			hasError: true,
+		},
+		{
+			country:  "Brasil",
 			input:    "1A",
 			hasError: true,
 		},
 		{
-			input:    "12345678",
+			country:  "Brasil",
+			input:    "",
+			hasError: true,
+		},
+		{
+			country:  "United Kingdom",
+			input:    "221B",
+			hasError: false,
+		},
+		{
+			country:  "United Kingdom",
+			input:    "221b",
+			hasError: true,
+		},
+		{
+			country:  "Portugal",
+			input:    "12A",
+			hasError: false,
+		},
+		{
+			country:  "Portugal",
+			input:    "12AB",
+			hasError: true,
+		},
+		{
+			country:  "United States",
+			input:    "12345",
+			hasError: false,
+		},
+		{
+			country:  "United States",
+			input:    "1234567",
+			hasError: true,
+		},
+		{
+			country:  "Deutschland",
+			input:    "12a",
+			hasError: false,
+		},
+		{
+			country:  "Deutschland",
+			input:    "0",
+			hasError: true,
+		},
+	}
+	var i int
+	for i = 0; i < len(tests); i++ {
+		actualError := numberValidation(mustCountry(t, tests[i].country), tests[i].input)
+		if (actualError == nil && tests[i].hasError == true) || (actualError != nil && tests[i].hasError == false) {
+			t.Fatalf("the test is failed %d: %v", i, actualError)
+		}
+		t.Logf("the test is passed %d", i)
+	}
+}
+
+func TestValidationPassword(t *testing.T) {
+	tests := []argsStr{
+		{
+			input:    "secret123",
+			hasError: false,
+		},
+		{
+			input:    "short",
 			hasError: true,
 		},
 		{
@@ -323,7 +539,7 @@ func TestValidationNumber(t *testing.T) {
 	}
 	var i int
 	for i = 0; i < len(tests); i++ {
-		actualError := numberValidation(tests[i].input)
+		actualError := passwordValidation(tests[i].input)
 		if (actualError == nil && tests[i].hasError == true) || (actualError != nil && tests[i].hasError == false) {
 			t.Fatalf("the test is failed %d: %v", i, actualError)
 		}